@@ -0,0 +1,70 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+)
+
+// AddRow is as AppendRows for one row.
+func (collection *Collection) AddRow(row *Row) error {
+	return collection.AppendRows(row)
+}
+
+// AppendRows appends rows to the collection, eg. planned links, extending
+// the header with the munged columns the rows have values for, so that
+// they are written by WriteCSV. A collection without a header is given the
+// header of the original OFCOM csv first. No row is appended if any is
+// nil or has WGS84 coordinates that are not consistent.
+//
+// The header is copied before it is extended, as it may be shared with
+// the collections returned by Filter.
+func (collection *Collection) AppendRows(rows ...*Row) error {
+	for i, row := range rows {
+		if err := checkAppendRow(row); err != nil {
+			return errors.Wrapf(err, "row %d", i)
+		}
+	}
+
+	header := collection.Header
+	if len(header) == 0 {
+		header = standardHeader
+	}
+	present := make(map[string]bool, len(header))
+	for _, heading := range header {
+		present[heading] = true
+	}
+	var extra []string
+	for _, heading := range mungedHeader {
+		if present[heading] {
+			continue
+		}
+		for _, row := range rows {
+			if value := row.Get(heading); value != "" && value != "0" {
+				extra = append(extra, heading)
+				break
+			}
+		}
+	}
+	if len(collection.Header) == 0 || len(extra) > 0 {
+		collection.Header = append(append([]string{}, header...), extra...)
+	}
+	collection.Rows = append(collection.Rows, rows...)
+	return nil
+}
+
+// checkAppendRow returns an error if a row cannot be appended: the WGS84
+// coordinates must be as read from their strings, which are what is written.
+func checkAppendRow(row *Row) error {
+	if row == nil {
+		return errors.New("nil row")
+	}
+	converted := *row
+	converted.Wgs84Longitude, converted.Wgs84Latitude = 0, 0
+	if err := converted.convertWgs84(); err != nil {
+		return err
+	}
+	if converted.Wgs84Longitude != row.Wgs84Longitude || converted.Wgs84Latitude != row.Wgs84Latitude {
+		return errors.Errorf("WGS84 coordinates %v, %v differ from %q, %q",
+			row.Wgs84Longitude, row.Wgs84Latitude, row.Wgs84LongitudeAsString, row.Wgs84LatitudeAsString)
+	}
+	return nil
+}
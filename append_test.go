@@ -0,0 +1,64 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAppendRows(t *testing.T) {
+	collection := testCollection(t, "Licence Number,Frequency\n0000001/1,7.5\n")
+	filtered := collection.Filter()
+	header := append([]string{}, collection.Header...)
+
+	planned := &Row{LicenceNumber: "PLANNED/1", Frequency: "13.0", OsEasting: 529400, OsNorthing: 181900}
+	if err := collection.AddRow(planned); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Rows) != 2 || collection.Rows[1] != planned {
+		t.Fatalf("row not appended: %v rows", len(collection.Rows))
+	}
+	want := append(header, HeadingOsEasting, HeadingOsNorthing)
+	if !reflect.DeepEqual(collection.Header, want) {
+		t.Fatalf("header %q, want %q", collection.Header, want)
+	}
+	if !reflect.DeepEqual(filtered.Header, header) {
+		t.Fatalf("header of filtered collection changed to %q", filtered.Header)
+	}
+
+	var buf bytes.Buffer
+	collection.WriteCSV(&buf)
+	const csv = "Licence Number,Frequency,OS Easting,OS Northing\n0000001/1,7.5,0,0\nPLANNED/1,13.0,529400,181900\n"
+	if buf.String() != csv {
+		t.Fatalf("wrote %q, want %q", buf.String(), csv)
+	}
+}
+
+func TestAppendRowsEmpty(t *testing.T) {
+	var collection Collection
+	row := &Row{LicenceNumber: "PLANNED/1", Wgs84LongitudeAsString: "-0.1", Wgs84Longitude: -0.1}
+	if err := collection.AppendRows(row); err != nil {
+		t.Fatal(err)
+	}
+	want := append(append([]string{}, standardHeader...), HeadingWgs84Longitude)
+	if !reflect.DeepEqual(collection.Header, want) {
+		t.Fatalf("header %q, want %q", collection.Header, want)
+	}
+}
+
+func TestAppendRowsInvalid(t *testing.T) {
+	for _, rows := range [][]*Row{
+		{nil},
+		{{LicenceNumber: "PLANNED/1"}, {Wgs84LatitudeAsString: "north"}},
+		{{Wgs84Latitude: 51.5}},
+		{{Wgs84LatitudeAsString: "51.5", Wgs84Latitude: 52}},
+	} {
+		collection := testCollection(t, "Licence Number\n0000001/1\n")
+		if err := collection.AppendRows(rows...); err == nil {
+			t.Errorf("appended %v", rows)
+		}
+		if len(collection.Rows) != 1 || len(collection.Header) != 1 {
+			t.Errorf("collection changed by failed append: %v rows, header %q", len(collection.Rows), collection.Header)
+		}
+	}
+}
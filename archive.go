@@ -0,0 +1,236 @@
+package wtrcsv
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"time"
+)
+
+// Archive stores a history of snapshots of the register compactly as a base
+// snapshot followed by per-snapshot deltas, each the rows removed and the
+// rows added or changed since the previous snapshot as found by
+// Collection.Diff. A changed row is recorded as the removal of the old row
+// plus the addition of the new.
+//
+// A reconstructed Collection contains the rows of the original snapshot:
+// rows retained from the previous snapshot keep their relative order and
+// added or changed rows follow them, in their order in the snapshot. As by
+// Diff, rows are only compared by their OFCOM columns.
+type Archive struct {
+	snapshots []archiveSnapshot
+	last      *Collection // the most recent snapshot
+}
+
+type archiveSnapshot struct {
+	Date    time.Time
+	Base    bool         // stored in full rather than as a delta
+	Header  []string     // only for a base snapshot
+	Removed []archiveKey // rows of the previous snapshot
+	Added   [][]string   // records in header order
+}
+
+// archiveKey identifies a removed row by its Row.Key and Row.Hash.
+type archiveKey struct {
+	Key  string
+	Hash uint64
+}
+
+func (s *archiveSnapshot) isBase() bool {
+	return s.Base
+}
+
+// NewArchive returns an empty Archive.
+func NewArchive() *Archive {
+	return &Archive{}
+}
+
+// Add appends a snapshot of the register taken on date. Snapshots must be
+// added in date order. A snapshot whose header differs from the previous
+// snapshot's is stored in full.
+func (archive *Archive) Add(date time.Time, collection *Collection) error {
+	n := len(archive.snapshots)
+	if n > 0 && !date.After(archive.snapshots[n-1].Date) {
+		return errors.Errorf("snapshot %s is not after %s",
+			date.Format(dateLayout), archive.snapshots[n-1].Date.Format(dateLayout))
+	}
+
+	if n == 0 || !sameHeader(archive.header(n-1), collection.Header) {
+		header := make([]string, len(collection.Header))
+		copy(header, collection.Header)
+		archive.snapshots = append(archive.snapshots, archiveSnapshot{Date: date, Base: true, Header: header, Added: collection.records()})
+	} else {
+		archive.snapshots = append(archive.snapshots, archiveDelta(date, collection, archive.last))
+	}
+	archive.last = collection.Clone()
+	return nil
+}
+
+// archiveDelta returns the delta of the collection from the previous
+// snapshot.
+func archiveDelta(date time.Time, collection, previous *Collection) archiveSnapshot {
+	diff := collection.Diff(previous)
+	delta := archiveSnapshot{Date: date}
+	for _, row := range diff.Removed {
+		delta.Removed = append(delta.Removed, archiveKey{row.Key(), row.Hash()})
+	}
+	added := make(map[*Row]bool, len(diff.Added)+len(diff.Changed))
+	for _, row := range diff.Added {
+		added[row] = true
+	}
+	for _, change := range diff.Changed {
+		delta.Removed = append(delta.Removed, archiveKey{change.Old.Key(), change.Old.Hash()})
+		added[change.New] = true
+	}
+	encoder := newRowEncoder(collection.Header, collection.computed)
+	for _, row := range collection.Rows {
+		if added[row] {
+			delta.Added = append(delta.Added, append([]string(nil), encoder.encode(row)...))
+		}
+	}
+	return delta
+}
+
+// Dates returns the dates of the archived snapshots in order.
+func (archive *Archive) Dates() []time.Time {
+	dates := make([]time.Time, len(archive.snapshots))
+	for i := range archive.snapshots {
+		dates[i] = archive.snapshots[i].Date
+	}
+	return dates
+}
+
+// Collection reconstructs the snapshot taken on date.
+func (archive *Archive) Collection(date time.Time) (*Collection, error) {
+	i := sort.Search(len(archive.snapshots), func(i int) bool {
+		return !archive.snapshots[i].Date.Before(date)
+	})
+	if i == len(archive.snapshots) || !archive.snapshots[i].Date.Equal(date) {
+		return nil, errors.Errorf("no snapshot for %s", date.Format(dateLayout))
+	}
+
+	collection, err := archive.replay(i)
+	if err != nil {
+		return nil, errors.Wrapf(err, "snapshot %s", date.Format(dateLayout))
+	}
+	return collection, nil
+}
+
+// replay reconstructs the i'th snapshot from the most recent base snapshot.
+func (archive *Archive) replay(i int) (*Collection, error) {
+	base := i
+	for !archive.snapshots[base].isBase() {
+		base--
+	}
+	header := archive.snapshots[base].Header
+	var rows []*Row
+	for j := base; j <= i; j++ {
+		snapshot := &archive.snapshots[j]
+		rows = removeArchiveKeys(rows, snapshot.Removed)
+		columns := make(map[string]string, len(header))
+		for _, record := range snapshot.Added {
+			for k, heading := range header {
+				columns[heading] = record[k]
+			}
+			row, err := parseRow(columns)
+			if err != nil {
+				return nil, errors.Wrapf(err, "row %d", len(rows))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return &Collection{Header: header, Rows: rows}, nil
+}
+
+// removeArchiveKeys returns the rows without the first row matching each
+// of the keys.
+func removeArchiveKeys(rows []*Row, keys []archiveKey) []*Row {
+	if len(keys) == 0 {
+		return rows
+	}
+	counts := make(map[archiveKey]int, len(keys))
+	for _, key := range keys {
+		counts[key]++
+	}
+	result := make([]*Row, 0, len(rows))
+	for _, row := range rows {
+		key := archiveKey{row.Key(), row.Hash()}
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// Write writes the archive gzip compressed.
+func (archive *Archive) Write(writer io.Writer) error {
+	w := gzip.NewWriter(writer)
+	if err := gob.NewEncoder(w).Encode(archive.snapshots); err != nil {
+		return errors.Wrap(err, "could not encode archive")
+	}
+	return errors.Wrap(w.Close(), "could not compress archive")
+}
+
+// ReadArchive reads an archive written by Archive.Write.
+func ReadArchive(reader io.Reader) (*Archive, error) {
+	r, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decompress archive")
+	}
+	defer r.Close()
+
+	var archive Archive
+	if err := gob.NewDecoder(r).Decode(&archive.snapshots); err != nil {
+		return nil, errors.Wrap(err, "could not decode archive")
+	}
+
+	// Rebuild the most recent snapshot so that further snapshots can be added.
+	if n := len(archive.snapshots); n > 0 {
+		if archive.last, err = archive.replay(n - 1); err != nil {
+			return nil, errors.Wrap(err, "could not replay archive")
+		}
+	}
+	return &archive, nil
+}
+
+const dateLayout = "2006-01-02"
+
+// header returns the header in effect for the i'th snapshot.
+func (archive *Archive) header(i int) []string {
+	for !archive.snapshots[i].isBase() {
+		i--
+	}
+	return archive.snapshots[i].Header
+}
+
+func sameHeader(header1, header2 []string) bool {
+	if len(header1) != len(header2) {
+		return false
+	}
+	for i := range header1 {
+		if header1[i] != header2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// records returns the rows of the collection as csv records in header
+// order, including its computed columns.
+func (collection *Collection) records() [][]string {
+	encoder := newRowEncoder(collection.Header, collection.computed)
+	records := make([][]string, len(collection.Rows))
+	for i, row := range collection.Rows {
+		records[i] = append([]string(nil), encoder.encode(row)...)
+	}
+	return records
+}
+
+// toRecord returns the row as a csv record in header order.
+func (row *Row) toRecord(header []string) []string {
+	return newRowEncoder(header, nil).encode(row)
+}
@@ -0,0 +1,107 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	const header = "Licence Number,Frequency,Licencee Company\n"
+	week1 := testCollection(t, header+`0000001/1,7.5,Company One
+0000002/1,13.0,Company Two
+0000002/1,13.0,Company Two
+`)
+	week2 := testCollection(t, header+`0000001/1,7.5,Company One
+0000002/1,13.0,Company 2
+0000003/1,18.0,Company Three
+`)
+	week3 := testCollection(t, "Licence Number,Frequency\n0000001/1,7.5\n")
+
+	date1 := time.Date(2018, 2, 7, 0, 0, 0, 0, time.UTC)
+	date2 := date1.AddDate(0, 0, 7)
+	date3 := date2.AddDate(0, 0, 7)
+
+	archive := NewArchive()
+	for i, c := range []*Collection{week1, week2, week3} {
+		if err := archive.Add(date1.AddDate(0, 0, 7*i), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Add(date1, week1); err == nil {
+		t.Fatal("expected error for out of order snapshot")
+	}
+
+	// The changed row is removed and added again.
+	delta := archive.snapshots[1]
+	if delta.isBase() || len(delta.Removed) != 2 || len(delta.Added) != 2 {
+		t.Fatalf("unexpected delta: %+v", delta)
+	}
+	if !archive.snapshots[2].isBase() {
+		t.Fatal("changed header should be stored in full")
+	}
+
+	// Round trip through the persistent format.
+	b := new(bytes.Buffer)
+	if err := archive.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := ReadArchive(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archive.Dates()) != 3 {
+		t.Fatalf("wrong number of dates: %v", archive.Dates())
+	}
+
+	for _, tc := range []struct {
+		date time.Time
+		want *Collection
+	}{{date1, week1}, {date2, week2}, {date3, week3}} {
+		got, err := archive.Collection(tc.date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sameHeader(got.Header, tc.want.Header) || len(got.Rows) != len(tc.want.Rows) {
+			t.Fatalf("%v: wrong collection: %v rows", tc.date, len(got.Rows))
+		}
+		for i := range got.Rows {
+			if *got.Rows[i] != *tc.want.Rows[i] {
+				t.Fatalf("%v: row %d differs: %+v", tc.date, i, got.Rows[i])
+			}
+		}
+	}
+
+	if _, err := archive.Collection(date1.AddDate(0, 0, 1)); err == nil {
+		t.Fatal("expected error for missing snapshot")
+	}
+}
+
+func TestArchiveEmpty(t *testing.T) {
+	date := time.Date(2018, 2, 7, 0, 0, 0, 0, time.UTC)
+	archive := NewArchive()
+	for i := 0; i < 2; i++ {
+		if err := archive.Add(date.AddDate(0, 0, 7*i), &Collection{Header: []string{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An empty header is decoded as nil, which must not lose the base.
+	b := new(bytes.Buffer)
+	if err := archive.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := ReadArchive(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, date := range archive.Dates() {
+		collection, err := archive.Collection(date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(collection.Header) != 0 || len(collection.Rows) != 0 {
+			t.Fatalf("%v: not empty: %+v", date, collection)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package wtrcsv
+
+import (
+	"strings"
+)
+
+// ColumnQuoting counts the values of a column that need quoting or escaping
+// in a csv, ie. which break naive comma splitting.
+type ColumnQuoting struct {
+	Column   string
+	Commas   int // values containing a comma
+	Quotes   int // values containing a double quote
+	Newlines int // values containing a carriage return or line feed
+	Values   int // values containing any of the above
+}
+
+// QuotingAudit reports the columns of a Collection with embedded commas,
+// quotes or newlines.
+type QuotingAudit struct {
+	Rows    int
+	Columns []ColumnQuoting // in header order, only columns with such values
+}
+
+// AuditQuoting counts, per header column, the values containing commas,
+// double quotes or newlines.
+func (collection *Collection) AuditQuoting() *QuotingAudit {
+	counts := make([]ColumnQuoting, len(collection.Header))
+	for j, heading := range collection.Header {
+		counts[j].Column = heading
+	}
+
+	encoder := newRowEncoder(collection.Header, collection.computed)
+	for _, row := range collection.Rows {
+		for j, value := range encoder.encode(row) {
+			comma := strings.IndexByte(value, ',') >= 0
+			quote := strings.IndexByte(value, '"') >= 0
+			newline := strings.IndexAny(value, "\r\n") >= 0
+			if comma {
+				counts[j].Commas++
+			}
+			if quote {
+				counts[j].Quotes++
+			}
+			if newline {
+				counts[j].Newlines++
+			}
+			if comma || quote || newline {
+				counts[j].Values++
+			}
+		}
+	}
+
+	audit := QuotingAudit{Rows: len(collection.Rows)}
+	for _, count := range counts {
+		if count.Values > 0 {
+			audit.Columns = append(audit.Columns, count)
+		}
+	}
+	return &audit
+}
@@ -0,0 +1,24 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestAuditQuoting(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Antenna Location,AP_COMMENT_INTERN
+0000001/1,"BT Tower, London","said ""hello"""
+0000002/1,Plain,"two
+lines, and comma"
+`)
+	audit := collection.AuditQuoting()
+	if audit.Rows != 2 || len(audit.Columns) != 2 {
+		t.Fatalf("unexpected audit: %+v", audit)
+	}
+	location, comment := audit.Columns[0], audit.Columns[1]
+	if location.Column != "Antenna Location" || location.Commas != 1 || location.Values != 1 {
+		t.Fatalf("unexpected location audit: %+v", location)
+	}
+	if comment.Quotes != 1 || comment.Newlines != 1 || comment.Commas != 1 || comment.Values != 2 {
+		t.Fatalf("unexpected comment audit: %+v", comment)
+	}
+}
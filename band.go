@@ -0,0 +1,90 @@
+package wtrcsv
+
+import (
+	"strings"
+)
+
+// Band is a named frequency band, [LowMHz, HighMHz).
+type Band struct {
+	Name            string
+	LowMHz, HighMHz float64
+}
+
+// Bands are the named UK bands, looked up in order so that a narrower band
+// listed first takes precedence over a wider one it overlaps. It may be
+// changed, eg. to add local names, before use.
+var Bands = []Band{
+	{"VHF Band I", 47, 68},
+	{"VHF Low Band", 68, 87.5},
+	{"VHF Band II", 87.5, 108},
+	{"VHF High Band", 146, 174},
+	{"VHF Band III", 174, 230},
+	{"UHF1", 425, 450},
+	{"UHF2", 453, 470},
+	{"UHF Band IV", 470, 582},
+	{"UHF Band V", 582, 862},
+	{"1.4 GHz", 1350, 1517},
+	{"Lower 6 GHz", 5925, 6425},
+	{"Upper 6 GHz", 6425, 7125},
+	{"7.5 GHz", 7125, 7750},
+	{"8 GHz", 7750, 8500},
+	{"11 GHz", 10700, 11700},
+	{"13 GHz", 12750, 13250},
+	{"15 GHz", 14250, 15350},
+	{"18 GHz", 17700, 19700},
+	{"23 GHz", 22000, 23600},
+	{"26 GHz", 24250, 26500},
+	{"28 GHz", 27500, 29500},
+	{"32 GHz", 31800, 33400},
+	{"38 GHz", 37000, 39500},
+	{"52 GHz", 51400, 52600},
+	{"55 GHz", 55780, 57000},
+	{"60 GHz", 57000, 66000},
+	{"70 GHz", 71000, 76000},
+	{"80 GHz", 81000, 86000},
+}
+
+// BandOf returns the name of the band of a frequency in MHz, or "" if it
+// is in none of Bands.
+func BandOf(mhz float64) string {
+	for _, band := range Bands {
+		if mhz >= band.LowMHz && mhz < band.HighMHz {
+			return band.Name
+		}
+	}
+	return ""
+}
+
+// Band returns the name of the band of the frequency of the Row, or "" if
+// it has no frequency or is in none of Bands.
+func (row *Row) Band() string {
+	f, ok := frequencyMHz(row)
+	if !ok {
+		return ""
+	}
+	return BandOf(f)
+}
+
+// KeyBand keys a Row by the name of its band.
+func KeyBand(row *Row) string {
+	return row.Band()
+}
+
+// FilterBand returns a FilterFn keeping the rows in any of the named
+// bands, eg. FilterBand("23 GHz"). Names are matched ignoring case.
+func FilterBand(names ...string) FilterFn {
+	lookup := make(map[string]bool, len(names))
+	for _, name := range names {
+		lookup[strings.ToLower(name)] = true
+	}
+	return func(row *Row) bool {
+		band := row.Band()
+		return band != "" && lookup[strings.ToLower(band)]
+	}
+}
+
+// GroupByBand groups the rows by the name of their band, "" for rows in
+// none of Bands.
+func (collection *Collection) GroupByBand() map[string]*Collection {
+	return collection.GroupBy(KeyBand)
+}
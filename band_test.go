@@ -0,0 +1,44 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestBand(t *testing.T) {
+	for _, test := range []struct {
+		mhz  float64
+		want string
+	}{
+		{6175, "Lower 6 GHz"},
+		{6425, "Upper 6 GHz"},
+		{7500, "7.5 GHz"},
+		{23000, "23 GHz"},
+		{202.928, "VHF Band III"},
+		{98.5, "VHF Band II"},
+		{73500, "70 GHz"},
+		{3000, ""},
+	} {
+		if got := BandOf(test.mhz); got != test.want {
+			t.Errorf("%v MHz: got %q, want %q", test.mhz, got, test.want)
+		}
+	}
+}
+
+func TestFilterBand(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type
+0000001/1,23.1,GHz
+0000002/1,22100,MHz
+0000003/1,18,GHz
+0000004/1,,
+`)
+	if got := licenceNumbers(collection.Filter(FilterBand("23 ghz"))); got != "0000001/1 0000002/1" {
+		t.Errorf("got %s", got)
+	}
+	if got := licenceNumbers(collection.Filter(FilterBand("18 GHz", "38 GHz"))); got != "0000003/1" {
+		t.Errorf("got %s", got)
+	}
+	groups := collection.GroupByBand()
+	if len(groups) != 3 || len(groups["23 GHz"].Rows) != 2 || licenceNumbers(groups[""]) != "0000004/1" {
+		t.Errorf("got groups %v", groups)
+	}
+}
@@ -0,0 +1,74 @@
+package wtrcsv
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// BloomFilter is a probabilistic set of keys. MayContain never returns
+// false for a key that has been added but may return true for a key that
+// has not, so it is used as a fast check before an exact lookup.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+}
+
+// NewBloomFilter returns a BloomFilter sized for n keys with the given
+// false positive rate (eg. 0.01).
+func NewBloomFilter(n int, falsePositiveRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{make([]uint64, (m+63)/64), m, k}
+}
+
+// BuildBloomFilter returns a BloomFilter of the keys of every Row.
+func (collection *Collection) BuildBloomFilter(keyFn KeyFn, falsePositiveRate float64) *BloomFilter {
+	bloom := NewBloomFilter(len(collection.Rows), falsePositiveRate)
+	for _, row := range collection.Rows {
+		bloom.Add(keyFn(row))
+	}
+	return bloom
+}
+
+// Add adds key to the filter.
+func (bloom *BloomFilter) Add(key string) {
+	h1, h2 := bloomHashes(key)
+	for i := 0; i < bloom.k; i++ {
+		bit := (h1 + uint64(i)*h2) % bloom.m
+		bloom.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain returns false if key has definitely not been added.
+func (bloom *BloomFilter) MayContain(key string) bool {
+	h1, h2 := bloomHashes(key)
+	for i := 0; i < bloom.k; i++ {
+		bit := (h1 + uint64(i)*h2) % bloom.m
+		if bloom.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns two independent hashes of key for double hashing.
+func bloomHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+
+	h = fnv.New64()
+	h.Write([]byte(key))
+	h2 := h.Sum64() | 1 // odd, so that successive bits differ
+	return h1, h2
+}
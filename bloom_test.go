@@ -0,0 +1,66 @@
+package wtrcsv
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	bloom := NewBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		bloom.Add(strconv.Itoa(i))
+	}
+	for i := 0; i < n; i++ {
+		if !bloom.MayContain(strconv.Itoa(i)) {
+			t.Fatalf("false negative: %v", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if bloom.MayContain(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > n/50 {
+		t.Fatalf("too many false positives: %v", falsePositives)
+	}
+}
+
+func TestBuildBloomFilter(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR
+0000001/1,TQ 29400 81900
+0000002/1,SJ8400098000
+`)
+	bloom := collection.BuildBloomFilter(KeyNGR, 0.001)
+	if !bloom.MayContain("TQ2940081900") || !bloom.MayContain(KeyNGR(collection.Rows[1])) {
+		t.Fatal("false negative")
+	}
+	if bloom.MayContain("NZ0000000000") {
+		t.Fatal("unexpected positive")
+	}
+}
+
+func TestKeyIndexBloomFilter(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR
+0000001/1,TQ 29400 81900
+0000002/1,SJ8400098000
+`)
+	index := collection.BuildKeyIndexWithOptions(KeyNGR, &KeyIndexOptions{BloomFalsePositiveRate: 0.001})
+	if rows := index.Get("TQ2940081900"); len(rows) != 1 {
+		t.Fatalf("rows %v", rows)
+	}
+	// A key missing from the filter is not looked up in the map.
+	index.rows["NZ0000000000"] = collection.Rows[:1]
+	if rows := index.Get("NZ0000000000"); rows != nil {
+		t.Fatal("key not in the bloom filter looked up")
+	}
+	if rows := collection.BuildKeyIndex(KeyNGR).Get("NZ0000000000"); rows != nil {
+		t.Fatalf("rows %v", rows)
+	}
+	index.Add(&Row{LicenceNumber: "0000003/1", NGR: "NZ 10000 20000"})
+	if rows := index.Get("NZ1000020000"); len(rows) != 1 {
+		t.Fatal("row added not in the bloom filter")
+	}
+}
@@ -0,0 +1,23 @@
+package wtrcsv
+
+// Clone returns a copy of the Row that shares nothing with it.
+func (row *Row) Clone() *Row {
+	clone := *row // the fields are all values
+	return &clone
+}
+
+// Clone returns a copy of the collection, its header and each of its rows,
+// that shares nothing with it but the functions of its computed columns.
+func (collection *Collection) Clone() *Collection {
+	clone := &Collection{Rows: make([]*Row, len(collection.Rows))}
+	if collection.Header != nil {
+		clone.Header = append([]string{}, collection.Header...)
+	}
+	if collection.computed != nil {
+		clone.computed = append([]computedColumn{}, collection.computed...)
+	}
+	for i, row := range collection.Rows {
+		clone.Rows[i] = row.Clone()
+	}
+	return clone
+}
@@ -0,0 +1,41 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRowCloneDeep(t *testing.T) {
+	// Row.Clone is a copy of the struct, so each field must be a value.
+	typ := reflect.TypeOf(Row{})
+	for i := 0; i < typ.NumField(); i++ {
+		switch field := typ.Field(i); field.Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Float64, reflect.Bool:
+		default:
+			t.Errorf("field %s of kind %v is not copied by Clone", field.Name, field.Type.Kind())
+		}
+	}
+}
+
+func TestCollectionClone(t *testing.T) {
+	collection := testCollection(t, "Licence Number,Frequency\n0000001/1,7.5\n0000002/1,13.0\n")
+	if err := collection.AddColumn("Band", KeyBand); err != nil {
+		t.Fatal(err)
+	}
+	clone := collection.Clone()
+	if !reflect.DeepEqual(clone.Header, collection.Header) || !reflect.DeepEqual(clone.Rows, collection.Rows) {
+		t.Fatal("clone differs")
+	}
+
+	clone.Rows[0].Frequency = "8.0"
+	clone.Header[0] = "Changed"
+	if err := clone.AddColumn("Other", KeyBand); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Rows[0].Frequency != "7.5" || collection.Header[0] != "Licence Number" || len(collection.Header) != 3 {
+		t.Fatal("changing the clone changed the collection")
+	}
+	if len(clone.computed) != 2 || len(collection.computed) != 1 {
+		t.Fatalf("%d and %d computed columns", len(clone.computed), len(collection.computed))
+	}
+}
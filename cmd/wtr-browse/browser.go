@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/recombinant/go-wtrcsv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tableColumns are the columns of the table view, if present. The
+// Frequency column shows the frequency with its unit.
+var tableColumns = []string{
+	"Licence Number", "Licencee Company", "Frequency",
+	"Product Description 31", "NGR", "Antenna Location",
+}
+
+func value(row *wtrcsv.Row, heading string) string {
+	return row.Get(heading)
+}
+
+// cell returns the value of a heading as shown in the table.
+func cell(row *wtrcsv.Row, heading string) string {
+	if heading == "Frequency" {
+		return row.DisplayFrequency()
+	}
+	return value(row, heading)
+}
+
+// sortValue returns the value of a heading used to sort the table.
+func sortValue(row *wtrcsv.Row, heading string) string {
+	if heading == "Frequency" {
+		if mhz, ok := row.FrequencyMHz(); ok {
+			return strconv.FormatFloat(mhz, 'f', -1, 64)
+		}
+	}
+	return value(row, heading)
+}
+
+// browser is the state of the TUI, independent of the terminal.
+type browser struct {
+	collection *wtrcsv.Collection
+	columns    []string
+	rows       []*wtrcsv.Row // matching the search, sorted
+
+	search    string
+	searching bool // typing a search
+	sortBy    int  // column index, -1 for the csv order
+	sortDesc  bool
+	column    int // selected column, for sorting
+	cursor    int
+	top       int
+	detail    bool
+
+	width, height int
+}
+
+func newBrowser(collection *wtrcsv.Collection) *browser {
+	b := &browser{collection: collection, sortBy: -1, width: 80, height: 24}
+	present := make(map[string]bool)
+	for _, heading := range collection.Header {
+		present[heading] = true
+	}
+	for _, heading := range tableColumns {
+		if present[heading] {
+			b.columns = append(b.columns, heading)
+		}
+	}
+	if len(b.columns) == 0 {
+		b.columns = collection.Header
+	}
+	b.update()
+	return b
+}
+
+// matches reports whether any column of a row contains the search, ignoring
+// case.
+func (b *browser) matches(row *wtrcsv.Row, search string) bool {
+	for _, heading := range b.collection.Header {
+		if strings.Contains(strings.ToLower(value(row, heading)), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// less orders values numerically if both are numbers.
+func less(a, b string) bool {
+	x, errX := strconv.ParseFloat(a, 64)
+	y, errY := strconv.ParseFloat(b, 64)
+	if errX == nil && errY == nil {
+		return x < y
+	}
+	return a < b
+}
+
+// update applies the search and sort, keeping the cursor on the same row
+// if it is still shown.
+func (b *browser) update() {
+	var current *wtrcsv.Row
+	if b.cursor < len(b.rows) {
+		current = b.rows[b.cursor]
+	}
+
+	search := strings.ToLower(b.search)
+	b.rows = b.rows[:0]
+	for _, row := range b.collection.Rows {
+		if search == "" || b.matches(row, search) {
+			b.rows = append(b.rows, row)
+		}
+	}
+	if b.sortBy >= 0 {
+		heading := b.columns[b.sortBy]
+		sort.SliceStable(b.rows, func(i, j int) bool {
+			x, y := sortValue(b.rows[i], heading), sortValue(b.rows[j], heading)
+			if b.sortDesc {
+				return less(y, x)
+			}
+			return less(x, y)
+		})
+	}
+
+	b.cursor = 0
+	for i, row := range b.rows {
+		if row == current {
+			b.cursor = i
+			break
+		}
+	}
+	b.scroll()
+}
+
+// tableHeight is the number of table rows shown.
+func (b *browser) tableHeight() int {
+	h := b.height - 3 // heading, separator and status lines
+	if b.detail {
+		h -= h / 2
+	}
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+func (b *browser) scroll() {
+	h := b.tableHeight()
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+h {
+		b.top = b.cursor - h + 1
+	}
+}
+
+func (b *browser) move(n int) {
+	b.cursor += n
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+	b.scroll()
+}
+
+// Keys.
+const (
+	keyUp = iota + 0x110000 // beyond runes
+	keyDown
+	keyLeft
+	keyRight
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter     = '\r'
+	keyEscape    = 0x1b
+	keyBackspace = 0x7f
+	keyCtrlC     = 0x03
+)
+
+// key handles a key, returning false to quit.
+func (b *browser) key(k rune) bool {
+	if b.searching {
+		switch k {
+		case keyEnter, keyEscape:
+			b.searching = false
+		case keyBackspace, '\b':
+			if b.search != "" {
+				_, size := utf8.DecodeLastRuneInString(b.search)
+				b.search = b.search[:len(b.search)-size]
+				b.update()
+			}
+		case keyCtrlC:
+			return false
+		default:
+			if k >= ' ' && k < keyUp {
+				b.search += string(k)
+				b.update()
+			}
+		}
+		return true
+	}
+
+	switch k {
+	case 'q', keyCtrlC:
+		return false
+	case keyUp, 'k':
+		b.move(-1)
+	case keyDown, 'j':
+		b.move(1)
+	case keyPageUp:
+		b.move(-b.tableHeight())
+	case keyPageDown, ' ':
+		b.move(b.tableHeight())
+	case keyHome, 'g':
+		b.move(-len(b.rows))
+	case keyEnd, 'G':
+		b.move(len(b.rows))
+	case keyLeft, 'h':
+		if b.column > 0 {
+			b.column--
+		}
+	case keyRight, 'l':
+		if b.column < len(b.columns)-1 {
+			b.column++
+		}
+	case 's':
+		// Sort by the selected column, then reverse, then csv order.
+		switch {
+		case b.sortBy != b.column:
+			b.sortBy, b.sortDesc = b.column, false
+		case !b.sortDesc:
+			b.sortDesc = true
+		default:
+			b.sortBy = -1
+		}
+		b.update()
+	case '/':
+		b.searching = true
+	case keyEscape:
+		b.search = ""
+		b.update()
+	case keyEnter:
+		b.detail = !b.detail
+		b.scroll()
+	}
+	return true
+}
+
+// fit pads or truncates s to n columns.
+func fit(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) > n {
+		r := []rune(s)
+		if n == 1 {
+			return string(r[:1])
+		}
+		return string(r[:n-1]) + "…"
+	}
+	return s + strings.Repeat(" ", n-utf8.RuneCountInString(s))
+}
+
+// columnWidths shares the width between the columns in proportion to their
+// widest value on screen, within limits.
+func (b *browser) columnWidths(rows []*wtrcsv.Row) []int {
+	widths := make([]int, len(b.columns))
+	total := 0
+	for i, heading := range b.columns {
+		w := utf8.RuneCountInString(heading) + 2 // room for the sort mark
+		for _, row := range rows {
+			if n := utf8.RuneCountInString(cell(row, heading)); n > w {
+				w = n
+			}
+		}
+		if w > 40 {
+			w = 40
+		}
+		widths[i] = w
+		total += w + 1
+	}
+	for total > b.width {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 4 {
+			break
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
+
+// ANSI escape sequences.
+const (
+	ansiClear   = "\x1b[H\x1b[2J"
+	ansiReverse = "\x1b[7m"
+	ansiBold    = "\x1b[1m"
+	ansiReset   = "\x1b[0m"
+)
+
+// render draws the screen.
+func (b *browser) render(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	w.WriteString(ansiClear)
+
+	h := b.tableHeight()
+	end := b.top + h
+	if end > len(b.rows) {
+		end = len(b.rows)
+	}
+	visible := b.rows[b.top:end]
+	widths := b.columnWidths(visible)
+
+	w.WriteString(ansiBold)
+	for i, heading := range b.columns {
+		mark := "  "
+		if i == b.sortBy {
+			mark = " ▲"
+			if b.sortDesc {
+				mark = " ▼"
+			}
+		}
+		cell := fit(fit(heading, widths[i]-2)+mark, widths[i])
+		if i == b.column {
+			cell = ansiReverse + cell + ansiReset + ansiBold
+		}
+		w.WriteString(cell + " ")
+	}
+	w.WriteString(ansiReset + "\r\n")
+
+	for i, row := range visible {
+		line := ""
+		for j, heading := range b.columns {
+			line += fit(cell(row, heading), widths[j]) + " "
+		}
+		line = fit(line, b.width)
+		if b.top+i == b.cursor {
+			line = ansiReverse + line + ansiReset
+		}
+		w.WriteString(line + "\r\n")
+	}
+	for i := len(visible); i < h; i++ {
+		w.WriteString("\r\n")
+	}
+
+	if b.detail {
+		w.WriteString(strings.Repeat("─", b.width) + "\r\n")
+		b.renderDetail(w, b.height-3-h)
+	}
+
+	status := fmt.Sprintf(" %d/%d", b.cursor+1, len(b.rows))
+	if len(b.rows) == 0 {
+		status = " 0/0"
+	}
+	if b.search != "" || b.searching {
+		status += "  search: " + b.search
+		if b.searching {
+			status += "▏"
+		}
+	}
+	status += "  (/ search, ←→ column, s sort, enter detail, q quit)"
+	w.WriteString(ansiReverse + fit(status, b.width) + ansiReset)
+	return w.Flush()
+}
+
+// renderDetail writes every column of the row at the cursor, in two
+// columns if there is room.
+func (b *browser) renderDetail(w *bufio.Writer, lines int) {
+	if b.cursor >= len(b.rows) || lines <= 0 {
+		for i := 0; i < lines; i++ {
+			w.WriteString("\r\n")
+		}
+		return
+	}
+	row := b.rows[b.cursor]
+	var items []string
+	for _, heading := range b.collection.Header {
+		items = append(items, heading+": "+value(row, heading))
+	}
+	perLine := 1
+	if len(items) > lines {
+		perLine = (len(items) + lines - 1) / lines
+	}
+	width := b.width / perLine
+	for i := 0; i < lines; i++ {
+		line := ""
+		for j := 0; j < perLine; j++ {
+			if k := i + j*lines; k < len(items) {
+				line += fit(items[k], width)
+			}
+		}
+		w.WriteString(strings.TrimRight(line, " ") + "\r\n")
+	}
+}
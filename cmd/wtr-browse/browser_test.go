@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"github.com/recombinant/go-wtrcsv"
+	"strings"
+	"testing"
+)
+
+func testBrowser(t *testing.T) *browser {
+	collection := wtrcsv.ReadCSV(strings.NewReader(`Licence Number,Licencee Company,Frequency,Frequency Type,NGR
+0000001/1,Acme,7.5,GHz,TQ 29400 81900
+0000002/1,Acme,450.1,MHz,NT 25700 73500
+0000003/1,Other,18,GHz,SJ 83800 98200
+`))
+	return newBrowser(collection)
+}
+
+func licenceNumbers(b *browser) string {
+	var numbers []string
+	for _, row := range b.rows {
+		numbers = append(numbers, row.LicenceNumber)
+	}
+	return strings.Join(numbers, " ")
+}
+
+func keys(b *browser, s string) {
+	for _, k := range s {
+		b.key(k)
+	}
+}
+
+func TestBrowserSearch(t *testing.T) {
+	b := testBrowser(t)
+	keys(b, "/acm")
+	if got := licenceNumbers(b); got != "0000001/1 0000002/1" {
+		t.Fatalf("got %q", got)
+	}
+	keys(b, "e\r")
+	if b.searching || b.search != "acme" {
+		t.Fatalf("searching %v %q", b.searching, b.search)
+	}
+	b.key(keyEscape)
+	if got := licenceNumbers(b); got != "0000001/1 0000002/1 0000003/1" {
+		t.Fatalf("search not cleared: %q", got)
+	}
+	keys(b, "/SJ 8")
+	b.key(keyBackspace)
+	b.key(keyBackspace)
+	keys(b, " 8")
+	if got := licenceNumbers(b); got != "0000003/1" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestBrowserSort(t *testing.T) {
+	b := testBrowser(t)
+	b.key(keyDown) // 0000002/1
+	b.key(keyRight)
+	b.key(keyRight) // Frequency
+	b.key('s')
+	if got := licenceNumbers(b); got != "0000002/1 0000001/1 0000003/1" {
+		t.Fatalf("frequency sort: got %q", got)
+	}
+	if b.rows[b.cursor].LicenceNumber != "0000002/1" {
+		t.Error("cursor did not follow row")
+	}
+	b.key('s')
+	if got := licenceNumbers(b); got != "0000003/1 0000001/1 0000002/1" {
+		t.Fatalf("reverse sort: got %q", got)
+	}
+	b.key('s')
+	if got := licenceNumbers(b); got != "0000001/1 0000002/1 0000003/1" {
+		t.Fatalf("csv order: got %q", got)
+	}
+}
+
+func TestBrowserRender(t *testing.T) {
+	b := testBrowser(t)
+	b.width, b.height = 60, 12
+	b.key(keyEnd)
+	b.key(keyEnter)
+	w := new(bytes.Buffer)
+	if err := b.render(w); err != nil {
+		t.Fatal(err)
+	}
+	screen := w.String()
+	for _, s := range []string{"Licence Number", "Other", "18 GHz", "3/3", "NGR: SJ 83800 98200"} {
+		if !strings.Contains(screen, s) {
+			t.Errorf("%q not shown", s)
+		}
+	}
+	if !b.key('j') || b.key('q') {
+		t.Error("q does not quit")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	kr := newKeyReader(strings.NewReader("a\x1b[A\x1b[6~é\x1b[99X\r"))
+	var got []rune
+	for i := 0; i < 6; i++ {
+		k, err := kr.read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, k)
+	}
+	want := []rune{'a', keyUp, keyPageDown, 'é', 0, keyEnter}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("key %d: got %x, want %x", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Command wtr-browse browses the OFCOM Wireless Telegraphy Register in the
+// terminal: a table with incremental search, sorting by column and a detail
+// pane for the selected row.
+//
+// Usage:
+//
+//	wtr-browse [file]
+//	wtr filter -company Acme | wtr-browse
+//
+// The file defaults to the register cached by wtr fetch, or stdin if it is
+// not a terminal. Keys are read from the terminal, which is put into raw
+// mode with stty(1).
+package main
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "wtr-browse: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// isTerminal reports whether a file is a character device.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func read(args []string) (*wtrcsv.Collection, error) {
+	var path string
+	switch {
+	case len(args) > 1:
+		return nil, errors.New("usage: wtr-browse [file]")
+	case len(args) == 1:
+		path = args[0]
+	case !isTerminal(os.Stdin):
+		return wtrcsv.ReadCSV(os.Stdin), nil
+	default:
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, errors.Wrap(err, "no file given")
+		}
+		path = filepath.Join(dir, "wtr", "WTR.csv")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open input (run wtr fetch?)")
+	}
+	defer file.Close()
+	return wtrcsv.ReadCSV(file), nil
+}
+
+// stty runs stty(1) on the terminal.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), errors.Wrap(err, "stty")
+}
+
+// terminalSize returns the rows and columns of the terminal.
+func terminalSize(tty *os.File) (int, int, error) {
+	out, err := stty(tty, "size")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, errors.Errorf("unexpected stty size %q", out)
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "stty size")
+	}
+	columns, err := strconv.Atoi(fields[1])
+	return rows, columns, errors.Wrap(err, "stty size")
+}
+
+func run(args []string) error {
+	collection, err := read(args)
+	if err != nil {
+		return err
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return errors.Wrap(err, "wtr-browse needs a terminal")
+	}
+	defer tty.Close()
+
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return err
+	}
+	defer stty(tty, saved)
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l") // alternate screen, hide cursor
+	defer fmt.Fprint(tty, "\x1b[?25h\x1b[?1049l")
+
+	b := newBrowser(collection)
+	keys := newKeyReader(tty)
+	for {
+		if rows, columns, err := terminalSize(tty); err == nil && rows > 0 && columns > 0 {
+			b.width, b.height = columns, rows
+			b.scroll()
+		}
+		if err := b.render(tty); err != nil {
+			return err
+		}
+		k, err := keys.read()
+		if err != nil {
+			return err
+		}
+		if !b.key(k) {
+			return nil
+		}
+	}
+}
+
+// keyReader decodes keys, including the escape sequences of the cursor
+// keys, from a terminal in raw mode.
+type keyReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func newKeyReader(r io.Reader) *keyReader {
+	return &keyReader{r: r}
+}
+
+// escapeKeys are the escape sequences of keys, after "\x1b[".
+var escapeKeys = map[string]rune{
+	"A": keyUp, "B": keyDown, "C": keyRight, "D": keyLeft,
+	"H": keyHome, "F": keyEnd, "1~": keyHome, "4~": keyEnd,
+	"5~": keyPageUp, "6~": keyPageDown,
+}
+
+func (kr *keyReader) read() (rune, error) {
+	for {
+		if k, n := decodeKey(kr.buf); n > 0 {
+			kr.buf = kr.buf[n:]
+			return k, nil
+		}
+		b := make([]byte, 64)
+		n, err := kr.r.Read(b)
+		if err != nil {
+			return 0, err
+		}
+		kr.buf = append(kr.buf, b[:n]...)
+	}
+}
+
+// decodeKey decodes the first key of b, returning the number of bytes used
+// or 0 if more are needed. A terminal sends an escape sequence in a single
+// read, so a lone escape is the escape key.
+func decodeKey(b []byte) (rune, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	if b[0] == keyEscape {
+		if len(b) == 1 {
+			return keyEscape, 1
+		}
+		if b[1] == '[' || b[1] == 'O' {
+			for i := 2; i < len(b); i++ {
+				if b[i] >= 0x40 && b[i] <= 0x7e { // final byte
+					if k, ok := escapeKeys[string(b[2:i+1])]; ok {
+						return k, i + 1
+					}
+					return 0, i + 1 // unknown sequence, ignored
+				}
+			}
+			return 0, 0
+		}
+		return keyEscape, 1
+	}
+	if !utf8.FullRune(b) {
+		return 0, 0
+	}
+	r, n := utf8.DecodeRune(b)
+	return r, n
+}
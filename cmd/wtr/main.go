@@ -0,0 +1,711 @@
+// Command wtr fetches, filters and converts the OFCOM Wireless Telegraphy
+// Register.
+//
+// Usage:
+//
+//	wtr fetch [-url url] [-cache dir] [-force] [-snapshots dir]
+//	wtr filter [-in file] [-out file] [-company name]... [-product-code code]... [-bbox minlon,minlat,maxlon,maxlat] [-near lat,lon,km] [-within file.geojson] [-band name]... [-frequency low,high] [-issued-after yyyy-mm-dd] [-match heading=regexp]... [-columns headings] [-delimiter char] [-crlf]
+//	wtr convert -to geojson|json|html|png|markdown|report|sqlite [-in file] [-out file] [-missing empty|skip|centroid] [-warnings] [-aliases file]
+//	wtr diff -old file [-in file] [-out file] [-format csv|json]
+//	wtr trends -snapshots dir [-by company,product-code] [-out file] [-format csv|json]
+//	wtr validate [-in file] [-out file]
+//	wtr serve [-in file] [-snapshots dir] [-addr host:port] [-tokens file] [-rate n] [-burst n] [-log] [-viewer] [-viewer-assets dir]
+//
+// The input defaults to the cached register written by fetch; "-" is stdin.
+// The output defaults to stdout, except for sqlite which needs a file.
+// -missing is the policy for rows without coordinates when converting to
+// geojson or html; the counts of rows affected (also for png) are written to
+// stderr.
+// fetch -snapshots also keeps a dated, compressed copy of each download in
+// a wtrcsv.SnapshotStore.
+// diff writes the rows added, removed and changed since the -old snapshot,
+// and a summary of the counts to stderr.
+// trends writes the licence counts per company and per product code of
+// every snapshot written by fetch -snapshots, as a tidy time series.
+// validate writes a JSON wtrcsv.ValidationReport of the rows and fails if
+// any row failed validation.
+// serve -snapshots serves the most recent snapshot written by fetch
+// -snapshots, unless given -in, in which case the input is added to the
+// snapshots.
+// markdown and report are a summary report of the rows as Markdown or HTML.
+// Converting to sqlite needs a database/sql driver registered under
+// wtrcsv.SQLiteDriverName to be linked into the binary.
+package main
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"github.com/recombinant/go-wtrcsv/server"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultURL = "http://static.ofcom.org.uk/static/radiolicensing/html/register/WTR.csv"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "wtr: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected a subcommand: fetch, filter, convert, diff, trends, validate or serve")
+	}
+	switch args[0] {
+	case "fetch":
+		return fetch(args[1:], stdout)
+	case "filter":
+		return filter(args[1:], stdin, stdout)
+	case "convert":
+		return convert(args[1:], stdin, stdout)
+	case "diff":
+		return diff(args[1:], stdin, stdout)
+	case "trends":
+		return trends(args[1:], stdout)
+	case "validate":
+		return validate(args[1:], stdin, stdout)
+	case "serve":
+		return serve(args[1:], stdin)
+	}
+	return errors.Errorf("unknown subcommand %q", args[0])
+}
+
+// stringsFlag is a flag that may be given more than once.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// defaultCache returns the directory of the cached register.
+func defaultCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, "wtr")
+}
+
+func cachePath(dir string) string {
+	return filepath.Join(dir, "WTR.csv")
+}
+
+func fetch(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	url := flags.String("url", defaultURL, "register URL")
+	cache := flags.String("cache", defaultCache(), "cache directory")
+	force := flags.Bool("force", false, "download even if cached")
+	snapshots := flags.String("snapshots", "", "directory of dated snapshots to add the download to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	path := cachePath(*cache)
+	if _, err := os.Stat(path); err == nil && !*force {
+		fmt.Fprintln(stdout, path)
+		return nil
+	}
+	if err := os.MkdirAll(*cache, 0755); err != nil {
+		return errors.Wrap(err, "could not create cache directory")
+	}
+
+	resp, err := http.Get(*url)
+	if err != nil {
+		return errors.Wrapf(err, "could not GET URL: \"%s\"", *url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("bad http status: %s", resp.Status)
+	}
+
+	// Download to a temporary file so that a failed download does not
+	// replace the cache.
+	out, err := os.Create(path + ".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "could not create file \"%s\"", path)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return errors.Wrap(err, "failed to copy body")
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrap(err, "failed to copy body")
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return errors.Wrap(err, "could not update cache")
+	}
+	if *snapshots != "" {
+		if err := saveSnapshot(*snapshots, path); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(stdout, path)
+	return nil
+}
+
+// saveSnapshot adds the cached register to the snapshots, dated today.
+func saveSnapshot(dir, path string) error {
+	store, err := wtrcsv.NewSnapshotStore(dir)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "could not open cache")
+	}
+	defer file.Close()
+	return store.Save(time.Now(), file)
+}
+
+// read reads the input csv, gzip compressed if named *.gz: the cached
+// register by default, or stdin. The delimiter is detected, so tab and
+// semicolon separated files are read too.
+func read(in string, stdin io.Reader) (*wtrcsv.Collection, error) {
+	if in == "-" {
+		return readCSV(stdin)
+	}
+	if in == "" {
+		in = cachePath(defaultCache())
+	}
+	file, err := os.Open(in)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open input (run wtr fetch?)")
+	}
+	defer file.Close()
+	if strings.HasSuffix(in, ".gz") {
+		r, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decompress input")
+		}
+		defer r.Close()
+		return readCSV(r)
+	}
+	return readCSV(file)
+}
+
+func readCSV(r io.Reader) (*wtrcsv.Collection, error) {
+	collection, _, err := wtrcsv.ReadCSVWithOptions(r, &wtrcsv.ReadOptions{DetectDelimiter: true})
+	return collection, err
+}
+
+// create opens the output: stdout by default.
+func create(out string, stdout io.Writer) (io.Writer, func() error, error) {
+	if out == "" || out == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not create output")
+	}
+	return file, file.Close, nil
+}
+
+// parseBBox parses "minlon,minlat,maxlon,maxlat".
+func parseBBox(s string) ([4]float64, error) {
+	var bbox [4]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return bbox, errors.Errorf("bbox %q: expected minlon,minlat,maxlon,maxlat", s)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return bbox, errors.Wrapf(err, "bbox %q", s)
+		}
+		bbox[i] = v
+	}
+	if bbox[0] > bbox[2] || bbox[1] > bbox[3] {
+		return bbox, errors.Errorf("bbox %q: minimum exceeds maximum", s)
+	}
+	return bbox, nil
+}
+
+// parseCircle parses "lat,lon,radius".
+func parseCircle(s string) ([3]float64, error) {
+	var circle [3]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return circle, errors.Errorf("near %q: expected lat,lon,radius", s)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return circle, errors.Wrapf(err, "near %q", s)
+		}
+		circle[i] = v
+	}
+	if circle[2] < 0 {
+		return circle, errors.Errorf("near %q: negative radius", s)
+	}
+	return circle, nil
+}
+
+// parseFrequencyRange parses "low,high" in MHz.
+func parseFrequencyRange(s string) ([2]float64, error) {
+	var r [2]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return r, errors.Errorf("frequency %q: expected low,high", s)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return r, errors.Wrapf(err, "frequency %q", s)
+		}
+		r[i] = v
+	}
+	if r[0] > r[1] {
+		return r, errors.Errorf("frequency %q: low exceeds high", s)
+	}
+	return r, nil
+}
+
+func filter(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("filter", flag.ContinueOnError)
+	in := flags.String("in", "", "input csv (default the cached register, - for stdin)")
+	out := flags.String("out", "", "output csv (default stdout)")
+	var companies, productCodes, bands, matches stringsFlag
+	flags.Var(&companies, "company", "licencee company (repeatable)")
+	flags.Var(&productCodes, "product-code", "numerical product code (repeatable)")
+	flags.Var(&matches, "match", "column heading=regular expression (repeatable)")
+	flags.Var(&bands, "band", "named band, eg. \"23 GHz\" (repeatable)")
+	bbox := flags.String("bbox", "", "WGS84 bounding box minlon,minlat,maxlon,maxlat")
+	near := flags.String("near", "", "WGS84 circle lat,lon,radius in km")
+	within := flags.String("within", "", "GeoJSON file of the polygons to keep")
+	frequency := flags.String("frequency", "", "frequency range low,high in MHz")
+	issuedAfter := flags.String("issued-after", "", "keep licences issued after the date yyyy-mm-dd")
+	columns := flags.String("columns", "", "comma separated headings of the columns to write, in order (default all)")
+	delimiter := flags.String("delimiter", ",", "output delimiter, one character or \"tab\"")
+	crlf := flags.Bool("crlf", false, "end output lines with CRLF")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options := &wtrcsv.CSVOptions{UseCRLF: *crlf}
+	if *columns != "" {
+		options.Columns = strings.Split(*columns, ",")
+	}
+	switch comma := []rune(*delimiter); {
+	case *delimiter == "tab":
+		options.Comma = '\t'
+	case len(comma) == 1:
+		options.Comma = comma[0]
+	default:
+		return errors.Errorf("-delimiter %q is not one character", *delimiter)
+	}
+
+	var filters []wtrcsv.FilterFn
+	if len(companies) > 0 {
+		filters = append(filters, wtrcsv.FilterCompanies(companies...))
+	}
+	if len(productCodes) > 0 {
+		filters = append(filters, wtrcsv.FilterNumericalProductCodes(productCodes...))
+	}
+	if len(bands) > 0 {
+		filters = append(filters, wtrcsv.FilterBand(bands...))
+	}
+	for _, match := range matches {
+		i := strings.IndexByte(match, '=')
+		if i < 0 {
+			return errors.Errorf("match %q: expected heading=regexp", match)
+		}
+		re, err := regexp.Compile(match[i+1:])
+		if err != nil {
+			return errors.Wrapf(err, "match %q", match)
+		}
+		f, err := wtrcsv.FilterColumnMatches(match[:i], re)
+		if err != nil {
+			return errors.Wrapf(err, "match %q", match)
+		}
+		filters = append(filters, f)
+	}
+	if *frequency != "" {
+		r, err := parseFrequencyRange(*frequency)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, wtrcsv.FilterFrequencyRange(r[0], r[1]))
+	}
+	if *issuedAfter != "" {
+		date, err := time.Parse("2006-01-02", *issuedAfter)
+		if err != nil {
+			return errors.Wrap(err, "-issued-after")
+		}
+		filters = append(filters, wtrcsv.FilterIssuedAfter(date))
+	}
+	if *bbox != "" {
+		b, err := parseBBox(*bbox)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, wtrcsv.FilterBoundingBox(b[1], b[0], b[3], b[2]))
+	}
+	if *near != "" {
+		c, err := parseCircle(*near)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, wtrcsv.FilterWithinRadius(c[0], c[1], c[2]))
+	}
+	if *within != "" {
+		b, err := ioutil.ReadFile(*within)
+		if err != nil {
+			return errors.Wrap(err, "could not read -within")
+		}
+		f, err := wtrcsv.FilterWithinPolygon(b)
+		if err != nil {
+			return errors.Wrapf(err, "-within %s", *within)
+		}
+		filters = append(filters, f)
+	}
+
+	collection, err := read(*in, stdin)
+	if err != nil {
+		return err
+	}
+	w, closeFn, err := create(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if err := collection.Filter(filters...).WriteCSVWithOptions(w, options); err != nil {
+		closeFn()
+		return err
+	}
+	return closeFn()
+}
+
+func convert(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	in := flags.String("in", "", "input csv (default the cached register, - for stdin)")
+	out := flags.String("out", "", "output file (default stdout)")
+	to := flags.String("to", "", "output format: geojson, json, html (Leaflet map), png (heatmap), markdown, report (HTML summary) or sqlite")
+	missing := flags.String("missing", "empty", "rows without coordinates: empty, skip or centroid")
+	warnings := flags.Bool("warnings", false, "list the rows affected by -missing on stderr")
+	aliases := flags.String("aliases", "", "csv of licensee alias,display name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *aliases != "" {
+		f, err := os.Open(*aliases)
+		if err != nil {
+			return errors.Wrap(err, "could not open aliases")
+		}
+		err = wtrcsv.LoadLicenseeAliases(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	geometryOptions := wtrcsv.GeometryOptions{}
+	switch *missing {
+	case "empty":
+	case "skip":
+		geometryOptions.Missing = wtrcsv.MissingSkip
+	case "centroid":
+		geometryOptions.Missing = wtrcsv.MissingCentroid
+	default:
+		return errors.Errorf("unknown -missing policy %q", *missing)
+	}
+
+	var write func(collection *wtrcsv.Collection, w io.Writer) error
+	switch *to {
+	case "geojson":
+		write = func(collection *wtrcsv.Collection, w io.Writer) error {
+			report, err := collection.WriteGeoJSONWithOptions(w, &geometryOptions)
+			reportGeometry(report, *warnings)
+			return err
+		}
+	case "json":
+		write = (*wtrcsv.Collection).WriteJSON
+	case "html":
+		write = func(collection *wtrcsv.Collection, w io.Writer) error {
+			report, err := collection.WriteLeafletHTML(w, &wtrcsv.MapOptions{GeometryOptions: geometryOptions})
+			reportGeometry(report, *warnings)
+			return err
+		}
+	case "png":
+		write = func(collection *wtrcsv.Collection, w io.Writer) error {
+			report, err := collection.WriteHeatmapPNG(w, nil)
+			reportGeometry(report, *warnings)
+			return err
+		}
+	case "markdown":
+		write = func(collection *wtrcsv.Collection, w io.Writer) error {
+			return collection.WriteReportMarkdown(w, nil)
+		}
+	case "report":
+		write = func(collection *wtrcsv.Collection, w io.Writer) error {
+			return collection.WriteReportHTML(w, nil)
+		}
+	case "sqlite":
+		if *out == "" || *out == "-" {
+			return errors.New("sqlite output needs -out file")
+		}
+		if !driverRegistered(wtrcsv.SQLiteDriverName) {
+			return errors.Errorf("no %q database driver linked into this binary", wtrcsv.SQLiteDriverName)
+		}
+	default:
+		return errors.Errorf("unknown output format %q", *to)
+	}
+
+	collection, err := read(*in, stdin)
+	if err != nil {
+		return err
+	}
+	if write == nil {
+		return collection.WriteSQLite(*out)
+	}
+	w, closeFn, err := create(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if err := write(collection, w); err != nil {
+		closeFn()
+		return err
+	}
+	return closeFn()
+}
+
+// reportGeometry writes the counts of rows without coordinates to stderr
+// and, if warnings, the rows themselves.
+func reportGeometry(report *wtrcsv.GeometryReport, warnings bool) {
+	if report == nil || report.Located == report.Rows+report.Skipped {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "wtr: %d rows written: %d located, %d by centroid, %d without geometry; %d skipped\n",
+		report.Rows, report.Located, report.Substituted, report.Empty, report.Skipped)
+	if warnings {
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(os.Stderr, "wtr: %s\n", warning)
+		}
+	}
+}
+
+func driverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
+
+func diff(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	in := flags.String("in", "", "new csv (default the cached register, - for stdin)")
+	old := flags.String("old", "", "old csv")
+	out := flags.String("out", "", "output file (default stdout)")
+	format := flags.String("format", "csv", "output format: csv or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *old == "" || *old == "-" {
+		return errors.New("diff needs an -old file")
+	}
+	var write func(*wtrcsv.Diff, io.Writer) error
+	switch *format {
+	case "csv":
+		write = (*wtrcsv.Diff).WriteDiffCSV
+	case "json":
+		write = (*wtrcsv.Diff).WriteDiffJSON
+	default:
+		return errors.Errorf("unknown -format %q", *format)
+	}
+
+	previous, err := read(*old, nil)
+	if err != nil {
+		return err
+	}
+	collection, err := read(*in, stdin)
+	if err != nil {
+		return err
+	}
+	d := collection.Diff(previous)
+	fmt.Fprintf(os.Stderr, "wtr: %s\n", d)
+	w, closeFn, err := create(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if err := write(d, w); err != nil {
+		closeFn()
+		return err
+	}
+	return closeFn()
+}
+
+func validate(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	in := flags.String("in", "", "input csv (default the cached register, - for stdin)")
+	out := flags.String("out", "", "output file (default stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	collection, err := read(*in, stdin)
+	if err != nil {
+		return err
+	}
+	report := collection.Validate()
+	w, closeFn, err := create(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteValidationJSON(w); err != nil {
+		closeFn()
+		return err
+	}
+	if err := closeFn(); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return errors.New(report.String())
+	}
+	return nil
+}
+
+// trendDimensions are the values of trends -by.
+var trendDimensions = map[string]wtrcsv.TrendDimension{
+	"company":      wtrcsv.TrendByCompany,
+	"product-code": wtrcsv.TrendByProductCode,
+}
+
+func trends(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("trends", flag.ContinueOnError)
+	snapshots := flags.String("snapshots", "", "directory of dated snapshots")
+	by := flags.String("by", "company,product-code", "comma separated dimensions: company, product-code")
+	out := flags.String("out", "", "output file (default stdout)")
+	format := flags.String("format", "csv", "output format: csv or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *snapshots == "" {
+		return errors.New("trends needs a -snapshots directory")
+	}
+	var dimensions []wtrcsv.TrendDimension
+	for _, name := range strings.Split(*by, ",") {
+		dimension, ok := trendDimensions[strings.TrimSpace(name)]
+		if !ok {
+			return errors.Errorf("unknown -by %q", name)
+		}
+		dimensions = append(dimensions, dimension)
+	}
+	var write func(wtrcsv.Trends, io.Writer) error
+	switch *format {
+	case "csv":
+		write = wtrcsv.Trends.WriteTrendsCSV
+	case "json":
+		write = wtrcsv.Trends.WriteTrendsJSON
+	default:
+		return errors.Errorf("unknown -format %q", *format)
+	}
+
+	store, err := wtrcsv.NewSnapshotStore(*snapshots)
+	if err != nil {
+		return err
+	}
+	t, err := store.Trends(dimensions...)
+	if err != nil {
+		return err
+	}
+	w, closeFn, err := create(*out, stdout)
+	if err != nil {
+		return err
+	}
+	if err := write(t, w); err != nil {
+		closeFn()
+		return err
+	}
+	return closeFn()
+}
+
+func serve(args []string, stdin io.Reader) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	in := flags.String("in", "", "input csv (default the cached register, - for stdin)")
+	snapshots := flags.String("snapshots", "", "directory of dated snapshots to serve the latest of")
+	addr := flags.String("addr", "localhost:8080", "listen address")
+	tokens := flags.String("tokens", "", `file of "token client" lines; if given, requests need a bearer token`)
+	rate := flags.Float64("rate", 0, "requests per second per client (0 for no limit)")
+	burst := flags.Int("burst", 0, "requests allowed at once per client (default the rate)")
+	logRequests := flags.Bool("log", false, "log requests to stderr")
+	viewer := flags.Bool("viewer", false, "serve a map viewer at /")
+	viewerAssets := flags.String("viewer-assets", "", "directory of leaflet.js and leaflet.css for the viewer (default from unpkg.com)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := &server.Options{RateLimit: *rate, Burst: *burst, Viewer: *viewer, ViewerAssetDir: *viewerAssets}
+	if *tokens != "" {
+		clients, err := readTokens(*tokens)
+		if err != nil {
+			return err
+		}
+		options.Authenticate = server.BearerTokens(clients)
+	}
+	if *logRequests {
+		options.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+
+	if *snapshots != "" {
+		store, err := wtrcsv.NewSnapshotStore(*snapshots)
+		if err != nil {
+			return err
+		}
+		options.Store = store
+	}
+
+	var handler *server.Server
+	if options.Store != nil && *in == "" {
+		var err error
+		if handler, err = server.NewFromStore(options); err != nil {
+			return err
+		}
+	} else {
+		collection, err := read(*in, stdin)
+		if err != nil {
+			return err
+		}
+		handler = server.NewWithOptions(&wtrcsv.Collection{}, options)
+		if err := handler.Refresh(collection); err != nil {
+			return err
+		}
+	}
+	log.Printf("serving %d licences on %s", len(handler.Collection().Rows), *addr)
+	return http.ListenAndServe(*addr, handler)
+}
+
+// readTokens reads a file of "token client" lines, ignoring blank lines and
+// lines starting with #.
+func readTokens(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read tokens")
+	}
+	clients := make(map[string]string)
+	for i, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, errors.Errorf("%s:%d: expected \"token client\"", path, i+1)
+		}
+		clients[fields[0]] = fields[1]
+	}
+	return clients, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/recombinant/go-wtrcsv"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCSV = `Licence Number,Licencee Company,Product Description 31,WGS84 Longitude,WGS84 Latitude
+0000001/1,Acme,301010,-0.1388,51.5215
+0000002/1,Acme,302010,-3.1883,55.9533
+0000003/1,Other,301010,-0.1388,51.5215
+`
+
+func TestFilter(t *testing.T) {
+	out := new(bytes.Buffer)
+	args := []string{"filter", "-in", "-", "--company", "Acme", "--bbox", "-1,51,1,52"}
+	if err := run(args, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "0000001/1,") {
+		t.Fatalf("wrong output:\n%s", out)
+	}
+
+	out.Reset()
+	args = []string{"filter", "-in", "-", "-product-code", "301010"}
+	if err := run(args, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(out.String(), "\n"); n != 3 {
+		t.Fatalf("expected header and 2 rows, got %d lines", n)
+	}
+
+	out.Reset()
+	args = []string{"filter", "-in", "-", "-match", "Licencee Company=^Oth"}
+	if err := run(args, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "0000003/1,") {
+		t.Fatalf("wrong output:\n%s", out)
+	}
+
+	out.Reset()
+	args = []string{"filter", "-in", "-", "-near", "55.95,-3.19,10"}
+	if err := run(args, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "0000002/1,") {
+		t.Fatalf("wrong output:\n%s", out)
+	}
+
+	out.Reset()
+	args = []string{"filter", "-in", "-", "-company", "Other", "-columns", "Licencee Company,Licence Number", "-delimiter", "tab", "-crlf"}
+	if err := run(args, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Licencee Company\tLicence Number\r\nOther\t0000003/1\r\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	// Tab separated input is detected.
+	out.Reset()
+	args = []string{"filter", "-in", "-", "-company", "Other"}
+	if err := run(args, strings.NewReader(strings.Replace(testCSV, ",", "\t", -1)), out); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "0000003/1,") {
+		t.Fatalf("wrong output:\n%s", out)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := run([]string{"convert", "-to", "json", "-in", "-"}, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil || len(rows) != 3 {
+		t.Fatalf("bad json (%v):\n%s", err, out)
+	}
+
+	for _, args := range [][]string{
+		{"convert", "-to", "kml", "-in", "-"},
+		{"convert", "-to", "sqlite", "-in", "-"},
+		{"filter", "-in", "-", "-bbox", "1,2,3"},
+		{"filter", "-in", "-", "-near", "51,0,-1"},
+		{"filter", "-in", "-", "-frequency", "7600,7500"},
+		{"filter", "-in", "-", "-match", "Colour=red"},
+		{"nonsense"},
+		{},
+	} {
+		if err := run(args, strings.NewReader(testCSV), new(bytes.Buffer)); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	file, err := ioutil.TempFile("", "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(strings.Replace(testCSV, "0000002/1,Acme,302010", "0000002/1,Acme,302011", 1))
+	file.Close()
+
+	out := new(bytes.Buffer)
+	if err := run([]string{"diff", "-in", "-", "-old", file.Name()}, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	// The product code is not a key column, so the row has changed.
+	want := "changed,0000002/1,,,,Product Description 31,302011,302010"
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || lines[1] != want {
+		t.Errorf("wrong output:\n%s", out)
+	}
+	if err := run([]string{"diff", "-in", "-"}, strings.NewReader(testCSV), out); err == nil {
+		t.Error("expected error without -old")
+	}
+}
+
+func TestTrends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := wtrcsv.NewSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(time.Date(2018, 2, 7, 0, 0, 0, 0, time.UTC), strings.NewReader(testCSV)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	if err := run([]string{"trends", "-snapshots", dir, "-by", "company"}, nil, out); err != nil {
+		t.Fatal(err)
+	}
+	want := "date,dimension,key,licences,rows\n2018-02-07,company,Acme,2,2\n2018-02-07,company,Other,1,1\n"
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+	if err := run([]string{"trends", "-snapshots", dir, "-by", "colour"}, nil, out); err == nil {
+		t.Error("expected error for unknown dimension")
+	}
+}
+
+func TestReadTokens(t *testing.T) {
+	file, err := ioutil.TempFile("", "tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("# comment\nsecret webteam\n\nother ops\n")
+	file.Close()
+
+	clients, err := readTokens(file.Name())
+	if err != nil || len(clients) != 2 || clients["secret"] != "webteam" {
+		t.Fatalf("%v: %v", clients, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := run([]string{"validate", "-in", "-"}, strings.NewReader(testCSV), out); err != nil {
+		t.Fatal(err)
+	}
+	var report wtrcsv.ValidationReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 3 || !report.OK() {
+		t.Errorf("wrong report %s", out)
+	}
+
+	err := run([]string{"validate", "-in", "-"}, strings.NewReader(testCSV+"ABC,Acme,301010,-0.1388,51.5215\n"), out)
+	if err == nil || err.Error() != "1 of 4 rows failed validation" {
+		t.Errorf("got %v", err)
+	}
+}
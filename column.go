@@ -0,0 +1,37 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"regexp"
+)
+
+// isHeading reports whether a heading is one of Headings.
+func isHeading(heading string) bool {
+	_, ok := rowColumnIndex[heading]
+	return ok
+}
+
+// Get returns the value of a column of the Row by its heading, eg.
+// row.Get("Antenna Location"), as it would be written to a csv. It is ""
+// for a heading that is not one of Headings.
+func (row *Row) Get(heading string) string {
+	i, ok := rowColumnIndex[heading]
+	if !ok {
+		return ""
+	}
+	column := rowColumns[i]
+	return column.get(reflect.ValueOf(row).Elem().Field(column.index))
+}
+
+// FilterColumnMatches returns a FilterFn keeping the rows whose value of a
+// column, by its heading, matches a regular expression. It is an error if
+// the heading is not one of Headings.
+func FilterColumnMatches(heading string, re *regexp.Regexp) (FilterFn, error) {
+	if !isHeading(heading) {
+		return nil, errors.Errorf("unknown column %q", heading)
+	}
+	return func(row *Row) bool {
+		return re.MatchString(row.Get(heading))
+	}, nil
+}
@@ -0,0 +1,43 @@
+package wtrcsv
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRowGet(t *testing.T) {
+	row := &Row{AntennaLocation: "Roof", OsEasting: 529400, Wgs84LatitudeAsString: "51.5"}
+	for heading, want := range map[string]string{
+		"Antenna Location":    "Roof",
+		HeadingOsEasting:      "529400",
+		HeadingWgs84Latitude:  "51.5",
+		"Licencee First Name": "",
+		"Colour":              "",
+	} {
+		if got := row.Get(heading); got != want {
+			t.Errorf("%s: got %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestFilterColumnMatches(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Antenna Location,Licencee Company
+0000001/1,Roof,Acme
+0000002/1,Mast,Acme Telecom
+0000003/1,roof top,Other
+`)
+	filter, err := FilterColumnMatches("Antenna Location", regexp.MustCompile(`(?i)^roof`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := licenceNumbers(collection.Filter(filter)); got != "0000001/1 0000003/1" {
+		t.Errorf("got %s", got)
+	}
+	filter, _ = FilterColumnMatches("Licencee Company", regexp.MustCompile(`^Acme$`))
+	if got := licenceNumbers(collection.Filter(filter)); got != "0000001/1" {
+		t.Errorf("got %s", got)
+	}
+	if _, err := FilterColumnMatches("Colour", regexp.MustCompile(`.`)); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
@@ -0,0 +1,146 @@
+package wtrcsv
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// textColumn holds the values of a column back to back in one string, so
+// a column of a million values is two allocations rather than a million.
+// A column is limited to 4GB.
+type textColumn struct {
+	text strings.Builder
+	ends []uint32 // the end of each value in text
+}
+
+// columnarData are the values of the rows, a textColumn per element of
+// rowColumns. It is not changed once read.
+type columnarData struct {
+	columns []textColumn
+	text    []string // the text of the columns, once read
+}
+
+func (data *columnarData) value(column, i int) string {
+	ends := data.columns[column].ends
+	start := uint32(0)
+	if i > 0 {
+		start = ends[i-1]
+	}
+	return data.text[column][start:ends[i]]
+}
+
+// ColumnarCollection is a read only Collection stored as a slice per column
+// rather than a Row per row, for analyses of the whole register with less
+// memory and garbage collection. Filter and GroupBy return collections
+// sharing the same storage. Rows are materialised by Row and Collection.
+type ColumnarCollection struct {
+	Header []string
+	data   *columnarData
+	index  []int // the rows of data in this collection, in order
+}
+
+// ReadColumnarCSV is as ReadCSVWithOptions but returns a ColumnarCollection.
+func ReadColumnarCSV(reader io.Reader, options *ReadOptions) (*ColumnarCollection, *ReadReport, error) {
+	data := &columnarData{columns: make([]textColumn, len(rowColumns))}
+	n := 0
+	header, report, err := decodeCSV(reader, options, func(row *Row) error {
+		value := reflect.ValueOf(row).Elem()
+		for i, column := range rowColumns {
+			c := &data.columns[i]
+			c.text.WriteString(column.get(value.Field(column.index)))
+			c.ends = append(c.ends, uint32(c.text.Len()))
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return nil, report, err
+	}
+	data.text = make([]string, len(data.columns))
+	for i := range data.columns {
+		data.text[i] = data.columns[i].text.String()
+	}
+	index := make([]int, n)
+	for i := range index {
+		index[i] = i
+	}
+	return &ColumnarCollection{header, data, index}, report, nil
+}
+
+// Len returns the number of rows.
+func (collection *ColumnarCollection) Len() int {
+	return len(collection.index)
+}
+
+// Get returns the value of a column of the ith row by its heading, as Row.Get.
+func (collection *ColumnarCollection) Get(i int, heading string) string {
+	column, ok := rowColumnIndex[heading]
+	if !ok {
+		return ""
+	}
+	return collection.data.value(column, collection.index[i])
+}
+
+// fill sets row to the ith row.
+func (collection *ColumnarCollection) fill(i int, row *Row) {
+	*row = Row{}
+	value := reflect.ValueOf(row).Elem()
+	for c, column := range rowColumns {
+		// The values were written by column.get so do not fail.
+		column.set(value.Field(column.index), collection.data.value(c, collection.index[i]))
+	}
+	row.convertWgs84()
+}
+
+// Row returns the ith row as a new Row.
+func (collection *ColumnarCollection) Row(i int) *Row {
+	row := new(Row)
+	collection.fill(i, row)
+	return row
+}
+
+// Collection returns the rows as a Collection.
+func (collection *ColumnarCollection) Collection() *Collection {
+	rows := make([]*Row, collection.Len())
+	for i := range rows {
+		rows[i] = collection.Row(i)
+	}
+	return &Collection{Header: collection.Header, Rows: rows}
+}
+
+// Filter is as Collection.Filter. The Row passed to the filterFuncs is
+// reused from row to row, so must not be kept.
+func (collection *ColumnarCollection) Filter(filterFuncs ...FilterFn) *ColumnarCollection {
+	record := filterMetrics()
+	filtered := &ColumnarCollection{collection.Header, collection.data, []int{}}
+	var row Row
+	for i, j := range collection.index {
+		collection.fill(i, &row)
+		if matchesAll(&row, filterFuncs) {
+			filtered.index = append(filtered.index, j)
+		}
+	}
+	if record != nil {
+		record(len(filtered.index))
+	}
+	return filtered
+}
+
+// GroupBy is as Collection.GroupBy. The Row passed to keyFn is reused from
+// row to row, so must not be kept.
+func (collection *ColumnarCollection) GroupBy(keyFn KeyFn) map[string]*ColumnarCollection {
+	groups := make(map[string]*ColumnarCollection)
+	var row Row
+	for i, j := range collection.index {
+		collection.fill(i, &row)
+		key := keyFn(&row)
+		group, ok := groups[key]
+		if !ok {
+			group = &ColumnarCollection{Header: collection.Header, data: collection.data}
+			groups[key] = group
+		}
+		group.index = append(group.index, j)
+	}
+	return groups
+}
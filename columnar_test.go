@@ -0,0 +1,44 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadColumnarCSV(t *testing.T) {
+	const csv = `Licence Number,Licencee Company,Product Description 31,OS Easting,WGS84 Longitude,WGS84 Latitude
+0000001/1,Acme,301010,529400,-0.1388,51.5215
+0000002/1,Acme,302010,,,
+0000003/1,Other,301010,,-3.1883,55.9533
+`
+	columnar, _, err := ReadColumnarCSV(strings.NewReader(csv), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := testCollection(t, csv)
+	if columnar.Len() != 3 || columnar.Get(2, "Licencee Company") != "Other" || columnar.Get(0, "Colour") != "" {
+		t.Fatalf("wrong columnar collection: %d rows", columnar.Len())
+	}
+	for i, row := range collection.Rows {
+		if got := *columnar.Row(i); got != *row {
+			t.Errorf("row %d: got %+v, want %+v", i, got, *row)
+		}
+	}
+
+	acme := columnar.Filter(FilterCompanies("Acme"))
+	if got := licenceNumbers(acme.Collection()); got != "0000001/1 0000002/1" {
+		t.Errorf("filtered %s", got)
+	}
+	groups := acme.GroupBy(KeyProductCode)
+	if len(groups) != 2 || groups["302010"].Len() != 1 || groups["302010"].Get(0, "Licence Number") != "0000002/1" {
+		t.Errorf("wrong groups %v", groups)
+	}
+
+	var want, got bytes.Buffer
+	collection.WriteCSV(&want)
+	columnar.Collection().WriteCSV(&got)
+	if got.String() != want.String() {
+		t.Errorf("got csv\n%s\nwant\n%s", &got, &want)
+	}
+}
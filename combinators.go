@@ -0,0 +1,48 @@
+package wtrcsv
+
+// Filter ANDs its FilterFns; these combine them otherwise, eg. "(Fixed
+// Links or Self Co-ordinated Links) and not Vodafone" is
+//
+//	collection.Filter(
+//		AnyOf(KeyProductCode, "301010", "301011"),
+//		Not(FilterCompanies("Vodafone Limited")))
+
+// And returns a FilterFn keeping the rows kept by all of the filters, for
+// use within Or and Not. With no filters it keeps every row.
+func And(filters ...FilterFn) FilterFn {
+	return func(row *Row) bool {
+		return matchesAll(row, filters)
+	}
+}
+
+// Or returns a FilterFn keeping the rows kept by any of the filters,
+// trying them in order. With no filters it keeps no rows.
+func Or(filters ...FilterFn) FilterFn {
+	return func(row *Row) bool {
+		for _, filter := range filters {
+			if filter(row) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a FilterFn keeping the rows dropped by filter.
+func Not(filter FilterFn) FilterFn {
+	return func(row *Row) bool {
+		return !filter(row)
+	}
+}
+
+// AnyOf returns a FilterFn keeping the rows whose key is any of the values,
+// eg. AnyOf(KeyNGR, "TQ2940081900").
+func AnyOf(keyFn KeyFn, values ...string) FilterFn {
+	lookup := make(map[string]bool, len(values))
+	for _, value := range values {
+		lookup[value] = true
+	}
+	return func(row *Row) bool {
+		return lookup[keyFn(row)]
+	}
+}
@@ -0,0 +1,33 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Product Description 31
+0000001/1,Vodafone Limited,301010
+0000002/1,Acme,301010
+0000003/1,Acme,301011
+0000004/1,Other,305010
+`)
+	fixed := FilterNumericalProductCodes("301010")
+	selfCoord := FilterNumericalProductCodes("301011")
+	vodafone := FilterCompanies("Vodafone Limited")
+	for _, test := range []struct {
+		name   string
+		filter FilterFn
+		want   string
+	}{
+		{"or", Or(fixed, selfCoord), "0000001/1 0000002/1 0000003/1"},
+		{"or and not", And(Or(fixed, selfCoord), Not(vodafone)), "0000002/1 0000003/1"},
+		{"not", Not(vodafone), "0000002/1 0000003/1 0000004/1"},
+		{"any of", AnyOf(KeyProductCode, "301011", "305010"), "0000003/1 0000004/1"},
+		{"empty or", Or(), ""},
+		{"empty and", And(), "0000001/1 0000002/1 0000003/1 0000004/1"},
+	} {
+		if got := licenceNumbers(collection.Filter(test.filter)); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
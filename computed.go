@@ -0,0 +1,58 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+)
+
+// computedColumn is a column of a Collection derived from each Row rather
+// than read, see AddColumn.
+type computedColumn struct {
+	heading string
+	fn      func(row *Row) string
+}
+
+// AddColumn adds a computed column to the end of the header, eg. the band
+// name or the distance to a reference point, whose value for each row is
+// fn(row). It is written by WriteCSV and WriteCSVWithOptions, and carried
+// through Filter, GroupBy and the other methods returning a Collection with
+// the same header. It is an error if the heading is one of Headings or is
+// already a column of the collection.
+//
+// The header is copied before it is extended, as it may be shared with
+// the collections returned by Filter.
+func (collection *Collection) AddColumn(heading string, fn func(row *Row) string) error {
+	if heading == "" || fn == nil {
+		return errors.New("computed column needs a heading and a function")
+	}
+	if isHeading(heading) {
+		return errors.Errorf("%q is a column of Row", heading)
+	}
+	for _, h := range collection.Header {
+		if h == heading {
+			return errors.Errorf("column %q already in header", heading)
+		}
+	}
+	collection.Header = append(append([]string{}, collection.Header...), heading)
+	collection.computed = append(append([]computedColumn{}, collection.computed...), computedColumn{heading, fn})
+	return nil
+}
+
+// isColumn reports whether a heading is one of Headings or a computed
+// column of the collection.
+func (collection *Collection) isColumn(heading string) bool {
+	if isHeading(heading) {
+		return true
+	}
+	for _, column := range collection.computed {
+		if column.heading == heading {
+			return true
+		}
+	}
+	return false
+}
+
+// withRows returns a Collection of rows with the header and computed
+// columns of the collection.
+func (collection *Collection) withRows(rows []*Row) *Collection {
+	return &Collection{Header: collection.Header, Rows: rows, computed: collection.computed}
+}
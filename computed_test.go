@@ -0,0 +1,74 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAddColumn(t *testing.T) {
+	collection := testCollection(t, "Licence Number,Frequency,Frequency Type\n0000001/1,7.5,GHz\n0000002/1,13,GHz\n")
+	unextended := collection.Filter()
+	if err := collection.AddColumn("Band", KeyBand); err != nil {
+		t.Fatal(err)
+	}
+	if len(unextended.Header) != 3 {
+		t.Fatalf("header of filtered collection changed to %q", unextended.Header)
+	}
+
+	filtered := collection.Filter(func(row *Row) bool { return row.LicenceNumber == "0000002/1" })
+	var buf bytes.Buffer
+	filtered.WriteCSV(&buf)
+	if want := "Licence Number,Frequency,Frequency Type,Band\n0000002/1,13,GHz,13 GHz\n"; buf.String() != want {
+		t.Fatalf("wrote %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := collection.WriteCSVWithOptions(&buf, &CSVOptions{Columns: []string{"Band", "Licence Number"}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Band,Licence Number\n7.5 GHz,0000001/1\n13 GHz,0000002/1\n"; buf.String() != want {
+		t.Fatalf("wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestAddColumnInvalid(t *testing.T) {
+	collection := testCollection(t, "Licence Number\n0000001/1\n")
+	if err := collection.AddColumn("Band", KeyBand); err != nil {
+		t.Fatal(err)
+	}
+	for _, heading := range []string{"", "Licence Number", HeadingOsEasting, "Band"} {
+		if err := collection.AddColumn(heading, KeyBand); err == nil {
+			t.Errorf("added column %q", heading)
+		}
+	}
+	if err := collection.AddColumn("Nothing", nil); err == nil {
+		t.Error("added column without a function")
+	}
+}
+
+func TestAddColumnExporters(t *testing.T) {
+	collection := testCollection(t, "Licence Number,Frequency,Frequency Type,WGS84 Longitude,WGS84 Latitude\n0000001/1,7.5,GHz,-0.1,51.5\n")
+	if err := collection.AddColumn("Band", KeyBand); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := collection.WriteGeoJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"Band":"7.5 GHz"`)) {
+		t.Errorf("no computed property in %s", buf.String())
+	}
+
+	var shp, shx, dbf bytes.Buffer
+	if err := collection.WriteShapefile(&shp, &shx, &dbf, []string{"Licence Number", "Band"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(dbf.Bytes(), []byte("0000001/17.5 GHz")) {
+		t.Errorf("no computed field in %q", dbf.String())
+	}
+
+	if records := collection.records(); records[0][5] != "7.5 GHz" {
+		t.Errorf("wrong archive record %q", records[0])
+	}
+}
@@ -0,0 +1,153 @@
+package wtrcsv
+
+import (
+	"math"
+	"sort"
+)
+
+// Proposal is a proposed assignment to be screened against the register.
+type Proposal struct {
+	Latitude        float64 // WGS84 degrees
+	Longitude       float64 // WGS84 degrees
+	FrequencyMHz    float64 // centre frequency
+	BandwidthMHz    float64
+	ERPdBW          float64
+	AzimuthDeg      float64 // antenna direction, clockwise from north
+	Omnidirectional bool    // if true AzimuthDeg is ignored
+	// Pattern of the proposed antenna. If nil the options' cone is used.
+	Pattern AntennaPattern
+}
+
+// CoordinationOptions control CheckCoordination. The zero value of a field
+// selects its default.
+type CoordinationOptions struct {
+	MaxDistanceKm float64 // default 100km
+	GuardBandMHz  float64 // channels closer than this are treated as overlapping
+	// Without a pattern, an existing or proposed antenna is taken to have
+	// no discrimination within half of BeamwidthDeg of its azimuth and
+	// OffBeamDiscriminationDB outside it (see ConePattern).
+	BeamwidthDeg            float64 // default 10 degrees
+	OffBeamDiscriminationDB float64 // default 25dB
+	MaxResults              int     // default all
+	// PatternFor returns the pattern of an existing station's antenna, or
+	// nil to use the cone.
+	PatternFor func(row *Row) AntennaPattern
+}
+
+// Conflict is a potential conflict between a Proposal and an existing Row.
+type Conflict struct {
+	Row                    *Row
+	DistanceKm             float64
+	BearingDeg             float64 // from the proposal to the existing station
+	FrequencySeparationMHz float64 // between the centre frequencies
+	OverlapMHz             float64 // of the channels, including the guard band
+	OffAxisProposalDeg     float64 // angle off the proposal's azimuth
+	OffAxisExistingDeg     float64 // angle off the existing station's azimuth
+	DiscriminationDB       float64 // angular discrimination of both antennas
+	// InterferenceDBW is the worse of the two directions of the ERP less
+	// angular discrimination and free space path loss. Conflicts are ranked
+	// on it.
+	InterferenceDBW float64
+}
+
+func (options *CoordinationOptions) withDefaults() CoordinationOptions {
+	o := CoordinationOptions{}
+	if options != nil {
+		o = *options
+	}
+	if o.MaxDistanceKm <= 0 {
+		o.MaxDistanceKm = 100
+	}
+	if o.BeamwidthDeg <= 0 {
+		o.BeamwidthDeg = 10
+	}
+	if o.OffBeamDiscriminationDB <= 0 {
+		o.OffBeamDiscriminationDB = 25
+	}
+	return o
+}
+
+// CheckCoordination screens a proposed assignment against the collection
+// and returns the rows using overlapping channels within the maximum
+// distance, ranked with the greatest potential interference first. Rows
+// without a location or frequency are ignored. options may be nil.
+func (collection *Collection) CheckCoordination(proposal Proposal, options *CoordinationOptions) []Conflict {
+	o := options.withDefaults()
+	proposalPattern := proposal.Pattern
+	if proposalPattern == nil {
+		proposalPattern = o.cone()
+	}
+	var conflicts []Conflict
+
+	for _, row := range collection.Rows {
+		frequency, ok := frequencyMHz(row)
+		if !ok {
+			continue
+		}
+		bandwidth, _ := channelWidthMHz(row)
+		separation := math.Abs(frequency - proposal.FrequencyMHz)
+		overlap := (bandwidth+proposal.BandwidthMHz)/2 + o.GuardBandMHz - separation
+		if overlap <= 0 && separation > 0 {
+			continue // not co-channel
+		}
+
+		lat, lon, ok := rowLatLon(row)
+		if !ok {
+			continue
+		}
+		distance := distanceKm(proposal.Latitude, proposal.Longitude, lat, lon)
+		if distance > o.MaxDistanceKm {
+			continue
+		}
+
+		conflict := Conflict{
+			Row:                    row,
+			DistanceKm:             distance,
+			BearingDeg:             bearingDeg(proposal.Latitude, proposal.Longitude, lat, lon),
+			FrequencySeparationMHz: separation,
+			OverlapMHz:             math.Max(overlap, 0),
+		}
+
+		if !proposal.Omnidirectional {
+			conflict.OffAxisProposalDeg = angleBetween(proposal.AzimuthDeg, conflict.BearingDeg)
+			conflict.DiscriminationDB += proposalPattern.DiscriminationDB(conflict.OffAxisProposalDeg)
+		}
+		if azimuth, ok := azimuthDeg(row); ok {
+			back := bearingDeg(lat, lon, proposal.Latitude, proposal.Longitude)
+			conflict.OffAxisExistingDeg = angleBetween(azimuth, back)
+			conflict.DiscriminationDB += o.patternFor(row).DiscriminationDB(conflict.OffAxisExistingDeg)
+		}
+
+		// Free space path loss, with a floor of 10m for co-sited stations.
+		loss := FreeSpacePathLossDB(math.Max(frequency, proposal.FrequencyMHz), math.Max(distance, 0.01))
+		erp := proposal.ERPdBW
+		if existing, ok := erpDBW(row); ok && existing > erp {
+			erp = existing
+		}
+		conflict.InterferenceDBW = erp - conflict.DiscriminationDB - loss
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].InterferenceDBW > conflicts[j].InterferenceDBW
+	})
+	if o.MaxResults > 0 && len(conflicts) > o.MaxResults {
+		conflicts = conflicts[:o.MaxResults]
+	}
+	return conflicts
+}
+
+// cone is the crude beamwidth cone model.
+func (options *CoordinationOptions) cone() AntennaPattern {
+	return ConePattern{options.BeamwidthDeg, options.OffBeamDiscriminationDB}
+}
+
+func (options *CoordinationOptions) patternFor(row *Row) AntennaPattern {
+	if options.PatternFor != nil {
+		if pattern := options.PatternFor(row); pattern != nil {
+			return pattern
+		}
+	}
+	return options.cone()
+}
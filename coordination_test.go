@@ -0,0 +1,59 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeo(t *testing.T) {
+	// London to Manchester.
+	d := distanceKm(51.5074, -0.1278, 53.4808, -2.2426)
+	if math.Abs(d-262) > 2 {
+		t.Fatalf("wrong distance: %v", d)
+	}
+	if b := bearingDeg(51, 0, 52, 0); math.Abs(b) > 1e-9 {
+		t.Fatalf("wrong bearing north: %v", b)
+	}
+	if b := bearingDeg(51, 0, 51, -1); math.Abs(b-270) > 1 {
+		t.Fatalf("wrong bearing west: %v", b)
+	}
+	if a := angleBetween(350, 10); a != 20 {
+		t.Fatalf("wrong angle: %v", a)
+	}
+
+	row := &Row{SidLatNS: "N", SidLatDeg: "51", SidLatMin: "30", SidLatSec: "36",
+		SidLongEW: "W", SidLongDeg: "0", SidLongMin: "7", SidLongSec: "30"}
+	lat, lon, ok := rowLatLon(row)
+	if !ok || math.Abs(lat-51.51) > 1e-9 || math.Abs(lon+0.125) > 1e-9 {
+		t.Fatalf("wrong SID location: %v, %v", lat, lon)
+	}
+}
+
+func TestCheckCoordination(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type,Channel Width,Channel Width type,Antenna ERP,Antenna ERP type,Antenna AZIMUTH,WGS84 Longitude,WGS84 Latitude
+near-facing,7500,MHz,28,MHz,30,dBW,270,0.1,51.5
+near-away,7.51,GHz,28,MHz,30,dBW,90,0.1,51.5
+far,7500,MHz,28,MHz,30,dBW,270,1.0,51.5
+other-channel,7600,MHz,28,MHz,30,dBW,270,0.1,51.5
+no-location,7500,MHz,28,MHz,30,dBW,270,,
+`)
+	proposal := Proposal{Latitude: 51.5, Longitude: 0, FrequencyMHz: 7500, BandwidthMHz: 28, ERPdBW: 20, AzimuthDeg: 90}
+
+	conflicts := collection.CheckCoordination(proposal, &CoordinationOptions{MaxDistanceKm: 50})
+	if len(conflicts) != 2 {
+		t.Fatalf("wrong number of conflicts: %v", len(conflicts))
+	}
+	if conflicts[0].Row.LicenceNumber != "near-facing" || conflicts[1].Row.LicenceNumber != "near-away" {
+		t.Fatalf("wrong ranking: %v, %v", conflicts[0].Row.LicenceNumber, conflicts[1].Row.LicenceNumber)
+	}
+	if conflicts[0].DiscriminationDB != 0 || conflicts[1].DiscriminationDB != 25 {
+		t.Fatalf("wrong discrimination: %v, %v", conflicts[0].DiscriminationDB, conflicts[1].DiscriminationDB)
+	}
+	if math.Abs(conflicts[1].FrequencySeparationMHz-10) > 1e-9 {
+		t.Fatalf("wrong separation: %v", conflicts[1].FrequencySeparationMHz)
+	}
+
+	if conflicts := collection.CheckCoordination(proposal, nil); len(conflicts) != 3 {
+		t.Fatalf("wrong number of conflicts with defaults: %v", len(conflicts))
+	}
+}
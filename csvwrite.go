@@ -0,0 +1,221 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Quoting selects which csv fields are enclosed in double quotes.
+type Quoting int
+
+const (
+	// QuoteMinimal quotes only the fields that need it, as encoding/csv does.
+	QuoteMinimal Quoting = iota
+	// QuoteAll quotes every field.
+	QuoteAll
+	// QuoteNonNumeric quotes every field that is not a number, including
+	// empty fields.
+	QuoteNonNumeric
+)
+
+// CSVOptions control WriteCSVWithOptions. The zero value writes as WriteCSV.
+type CSVOptions struct {
+	Quoting Quoting
+	UseCRLF bool // end lines with \r\n rather than \n
+	// Comma is the delimiter, eg. ';' or '\t'. 0 is a comma.
+	Comma rune
+	// Columns are the headings of the columns written by
+	// WriteCSVWithOptions, in order, rather than the header of the
+	// Collection. Each must be one of Headings or a computed column (see
+	// AddColumn).
+	Columns []string
+	// Progress, if not nil, is called with the progress of the write every
+	// 10000 rows and by Flush, eg. to drive a progress bar. The bytes are
+	// those written to the writer, which is buffered, so lag the rows until
+	// flushed.
+	Progress func(progress Progress)
+}
+
+// WriteCSVWithOptions is as WriteCSV but with control over quoting and line
+// endings, and returns an error rather than exiting.
+func (collection *Collection) WriteCSVWithOptions(writer io.Writer, options *CSVOptions) error {
+	header := collection.Header
+	if options != nil && options.Columns != nil {
+		for _, heading := range options.Columns {
+			if !collection.isColumn(heading) {
+				return errors.Errorf("unknown column %q", heading)
+			}
+		}
+		header = options.Columns
+	}
+	w := NewWriter(writer, options)
+	w.computed = collection.computed
+	w.progress.TotalRows = len(collection.Rows)
+	if err := w.WriteHeader(header); err != nil {
+		return err
+	}
+	for _, row := range collection.Rows {
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Writer writes a csv a Row at a time, eg. as the rows are read or
+// filtered, rather than from a Collection:
+//
+//	w.WriteHeader(collection.Header)
+//	collection.FilterEach(w.WriteRow, filterFuncs...)
+type Writer struct {
+	w        *csvRecordWriter
+	encoder  *rowEncoder
+	computed []computedColumn // of the Collection written, if any
+
+	progressFn func(progress Progress)
+	progress   Progress
+	counter    *countingWriter
+}
+
+// NewWriter returns a Writer with the options, which may be nil.
+func NewWriter(writer io.Writer, options *CSVOptions) *Writer {
+	w := &Writer{}
+	if options != nil && options.Progress != nil {
+		w.progressFn = options.Progress
+		w.counter = &countingWriter{w: writer}
+		writer = w.counter
+	}
+	w.w = newCSVRecordWriter(writer, options)
+	return w
+}
+
+// WriteHeader writes the header, which is the columns written of each Row.
+// It must be called once, before WriteRow.
+func (w *Writer) WriteHeader(header []string) error {
+	if w.encoder != nil {
+		return errors.New("CSV header already written")
+	}
+	if !validDelimiter(w.w.comma) {
+		return errors.Errorf("invalid CSV delimiter %q", w.w.comma)
+	}
+	w.encoder = newRowEncoder(header, w.computed)
+	return errors.Wrap(w.w.write(header), "could not write CSV header")
+}
+
+// WriteRow writes the columns of the header of a Row.
+func (w *Writer) WriteRow(row *Row) error {
+	if w.encoder == nil {
+		return errors.New("CSV header not written")
+	}
+	if err := w.w.write(w.encoder.encode(row)); err != nil {
+		return errors.Wrap(err, "could not write CSV row")
+	}
+	if w.progressFn != nil {
+		if w.progress.Rows++; w.progress.Rows%progressInterval == 0 {
+			w.reportProgress()
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	if err := w.w.flush(); err != nil {
+		return errors.Wrap(err, "could not write CSV")
+	}
+	if w.progressFn != nil {
+		w.reportProgress()
+	}
+	return nil
+}
+
+func (w *Writer) reportProgress() {
+	w.progress.Bytes = w.counter.n
+	w.progressFn(w.progress)
+}
+
+// csvRecordWriter writes csv records with the quoting and line endings of
+// CSVOptions.
+type csvRecordWriter struct {
+	w       *bufio.Writer
+	quoting Quoting
+	lineEnd string
+	comma   rune
+}
+
+func newCSVRecordWriter(writer io.Writer, options *CSVOptions) *csvRecordWriter {
+	var o CSVOptions
+	if options != nil {
+		o = *options
+	}
+	lineEnd := "\n"
+	if o.UseCRLF {
+		lineEnd = "\r\n"
+	}
+	comma := o.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	return &csvRecordWriter{bufio.NewWriter(writer), o.Quoting, lineEnd, comma}
+}
+
+// validDelimiter reports whether a delimiter can be read back, as
+// encoding/csv requires.
+func validDelimiter(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+var csvNumber = regexp.MustCompile(`^[+-]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+func (w *csvRecordWriter) needsQuotes(field string) bool {
+	switch w.quoting {
+	case QuoteAll:
+		return true
+	case QuoteNonNumeric:
+		if !csvNumber.MatchString(field) {
+			return true
+		}
+	}
+	// As encoding/csv.
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsAny(field, "\"\r\n") || strings.ContainsRune(field, w.comma) {
+		return true
+	}
+	return field[0] == ' ' || field[0] == '\t'
+}
+
+func (w *csvRecordWriter) write(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			w.w.WriteRune(w.comma)
+		}
+		if !w.needsQuotes(field) {
+			w.w.WriteString(field)
+			continue
+		}
+		w.w.WriteByte('"')
+		for {
+			i := strings.IndexByte(field, '"')
+			if i < 0 {
+				break
+			}
+			w.w.WriteString(field[:i+1])
+			w.w.WriteByte('"')
+			field = field[i+1:]
+		}
+		w.w.WriteString(field)
+		w.w.WriteByte('"')
+	}
+	_, err := w.w.WriteString(w.lineEnd)
+	return err
+}
+
+func (w *csvRecordWriter) flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,150 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWriteCSVWithOptions(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Antenna Location,AP_COMMENT_INTERN
+0000001/1,7.5,"BT Tower, London",
+0000002/1,13.0,"said ""hi""", leading space
+`)
+
+	for _, tc := range []struct {
+		options CSVOptions
+		want    string
+	}{
+		{CSVOptions{}, "Licence Number,Frequency,Antenna Location,AP_COMMENT_INTERN\n" +
+			"0000001/1,7.5,\"BT Tower, London\",\n" +
+			"0000002/1,13.0,\"said \"\"hi\"\"\",\" leading space\"\n"},
+		{CSVOptions{Quoting: QuoteAll, UseCRLF: true}, "\"Licence Number\",\"Frequency\",\"Antenna Location\",\"AP_COMMENT_INTERN\"\r\n" +
+			"\"0000001/1\",\"7.5\",\"BT Tower, London\",\"\"\r\n" +
+			"\"0000002/1\",\"13.0\",\"said \"\"hi\"\"\",\" leading space\"\r\n"},
+		{CSVOptions{Quoting: QuoteNonNumeric}, "\"Licence Number\",\"Frequency\",\"Antenna Location\",\"AP_COMMENT_INTERN\"\n" +
+			"\"0000001/1\",7.5,\"BT Tower, London\",\"\"\n" +
+			"\"0000002/1\",13.0,\"said \"\"hi\"\"\",\" leading space\"\n"},
+	} {
+		b := new(bytes.Buffer)
+		if err := collection.WriteCSVWithOptions(b, &tc.options); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tc.want {
+			t.Fatalf("%+v: unexpected csv:\n%s", tc.options, b.String())
+		}
+	}
+
+	// The default matches WriteCSV.
+	b1, b2 := new(bytes.Buffer), new(bytes.Buffer)
+	collection.WriteCSV(b1)
+	if err := collection.WriteCSVWithOptions(b2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b1.String() != b2.String() {
+		t.Fatalf("default differs from WriteCSV:\n%s\n%s", b1.String(), b2.String())
+	}
+}
+
+// benchmarkCollection returns a collection of n rows with every column of
+// the OFCOM csv.
+func benchmarkCollection(n int) *Collection {
+	rows := make([]*Row, n)
+	for i := range rows {
+		var row Row
+		value := reflect.ValueOf(&row).Elem()
+		for _, column := range rowColumns {
+			if !column.munged {
+				value.Field(column.index).SetString("value " + strconv.Itoa(i))
+			}
+		}
+		rows[i] = &row
+	}
+	return &Collection{Header: standardHeader, Rows: rows}
+}
+
+func BenchmarkWriteCSV(b *testing.B) {
+	collection := benchmarkCollection(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		collection.WriteCSV(ioutil.Discard)
+	}
+}
+
+// BenchmarkWriteCSVToMap writes as WriteCSV did before rowEncoder, with a
+// map of the columns per row, for comparison.
+func BenchmarkWriteCSVToMap(b *testing.B) {
+	collection := benchmarkCollection(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w := csv.NewWriter(ioutil.Discard)
+		w.Write(collection.Header)
+		record := make([]string, len(collection.Header))
+		for _, row := range collection.Rows {
+			rowAsMap := row.toMap()
+			for j, heading := range collection.Header {
+				record[j] = rowAsMap[heading]
+			}
+			w.Write(record)
+		}
+		w.Flush()
+	}
+}
+
+func BenchmarkWriteCSVWithOptions(b *testing.B) {
+	collection := benchmarkCollection(1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		collection.WriteCSVWithOptions(ioutil.Discard, &CSVOptions{Quoting: QuoteAll})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := NewWriter(&b, nil)
+	if err := w.WriteRow(&Row{}); err == nil {
+		t.Error("no error writing a row before the header")
+	}
+	if err := w.WriteHeader([]string{"Licence Number", "Frequency", "Notes"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader([]string{"NGR"}); err == nil {
+		t.Error("no error writing the header twice")
+	}
+	for _, row := range []*Row{{LicenceNumber: "0000001/1", Frequency: "7.5"}, {LicenceNumber: "0000002/1"}} {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Licence Number,Frequency,Notes\n0000001/1,7.5,\n0000002/1,,\n"; b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", &b, want)
+	}
+}
+
+func TestWriteCSVColumnsAndDelimiter(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Antenna Location
+0000001/1,7.5,"BT Tower; London"
+`)
+	var b bytes.Buffer
+	options := &CSVOptions{Comma: ';', Columns: []string{"Antenna Location", "Licence Number", "NGR"}}
+	if err := collection.WriteCSVWithOptions(&b, options); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Antenna Location;Licence Number;NGR\n\"BT Tower; London\";0000001/1;\n"; b.String() != want {
+		t.Errorf("got %q, want %q", &b, want)
+	}
+
+	options.Columns = []string{"Colour"}
+	if err := collection.WriteCSVWithOptions(&b, options); err == nil {
+		t.Error("no error for an unknown column")
+	}
+	if err := collection.WriteCSVWithOptions(&b, &CSVOptions{Comma: '"'}); err == nil {
+		t.Error("no error for a quote delimiter")
+	}
+}
@@ -0,0 +1,39 @@
+package wtrcsv
+
+// DedupeOptions are the options of DedupeWithOptions.
+type DedupeOptions struct {
+	// Prefer chooses the row kept for a key: the row that sorts first, the
+	// earliest of equal rows. nil keeps the first row. For example
+	// ByLicenceIssueDate keeps the earliest issued.
+	Prefer CompareFn
+}
+
+// Dedupe returns the collection with one row per key, the first, and the
+// number of rows removed.
+func (collection *Collection) Dedupe(keyFn KeyFn) (*Collection, int) {
+	return collection.DedupeWithOptions(keyFn, nil)
+}
+
+// DedupeWithOptions is as Dedupe with options. The row kept for a key is
+// at the position of the first row with that key. A nil options is the
+// same as Dedupe.
+func (collection *Collection) DedupeWithOptions(keyFn KeyFn, options *DedupeOptions) (*Collection, int) {
+	var prefer CompareFn
+	if options != nil {
+		prefer = options.Prefer
+	}
+	positions := make(map[string]int, len(collection.Rows))
+	rows := make([]*Row, 0, len(collection.Rows))
+	for _, row := range collection.Rows {
+		key := keyFn(row)
+		i, ok := positions[key]
+		switch {
+		case !ok:
+			positions[key] = len(rows)
+			rows = append(rows, row)
+		case prefer != nil && prefer(row, rows[i]) < 0:
+			rows[i] = row
+		}
+	}
+	return collection.withRows(rows), len(collection.Rows) - len(rows)
+}
@@ -0,0 +1,35 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licence issue date,Frequency
+0000001/1,01/01/2001,7500
+0000002/1,01/01/2001,7500
+0000001/1,05/03/2010,7500
+0000001/1,01/01/2005,7500
+0000003/1,,18000
+`)
+	deduped, removed := collection.Dedupe(KeyLicenceNumber)
+	if got := licenceNumbers(deduped); got != "0000001/1 0000002/1 0000003/1" || removed != 2 {
+		t.Errorf("got %s, %d removed", got, removed)
+	}
+	if deduped.Rows[0].LicenceIssueDate != "01/01/2001" {
+		t.Errorf("kept %s", deduped.Rows[0].LicenceIssueDate)
+	}
+
+	latest, removed := collection.DedupeWithOptions(KeyLicenceNumber, &DedupeOptions{Prefer: Descending(ByLicenceIssueDate)})
+	// 05/03/2010 is the latest
+	if got := licenceNumbers(latest); got != "0000001/1 0000002/1 0000003/1" || removed != 2 {
+		t.Errorf("got %s, %d removed", got, removed)
+	}
+	if latest.Rows[0].LicenceIssueDate != "05/03/2010" {
+		t.Errorf("kept %s", latest.Rows[0].LicenceIssueDate)
+	}
+
+	if _, removed := collection.Dedupe(KeyFrequency); removed != 3 {
+		t.Errorf("%d removed by frequency", removed)
+	}
+}
@@ -0,0 +1,152 @@
+package wtrcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// FieldChange is a change to a column of a Row.
+type FieldChange struct {
+	Column string `json:"column"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+// RowChange is a Row present in both snapshots (by Row.Key) whose content
+// has changed.
+type RowChange struct {
+	Old    *Row          `json:"old"`
+	New    *Row          `json:"new"`
+	Fields []FieldChange `json:"fields"` // in column order
+}
+
+// Diff is the difference between two snapshots of the register.
+type Diff struct {
+	Added   []*Row      `json:"added"`   // in the order of the new snapshot
+	Removed []*Row      `json:"removed"` // in the order of the old snapshot
+	Changed []RowChange `json:"changed"` // in the order of the new snapshot
+}
+
+// Diff compares the collection with an older snapshot of the register.
+// Rows are matched by Row.Key, any rows with the same key being matched
+// first where their content is unchanged and then in order. A matched row
+// is changed if any of its OFCOM columns differ.
+func (collection *Collection) Diff(old *Collection) *Diff {
+	type contentKey struct {
+		key  string
+		hash uint64
+	}
+	// Queues of the indices of the old rows by content and by key.
+	byContent := make(map[contentKey][]int, len(old.Rows))
+	for i, row := range old.Rows {
+		k := contentKey{row.Key(), row.Hash()}
+		byContent[k] = append(byContent[k], i)
+	}
+	matched := make([]bool, len(old.Rows))
+	unchanged := make([]bool, len(collection.Rows))
+	for i, row := range collection.Rows {
+		k := contentKey{row.Key(), row.Hash()}
+		if queue := byContent[k]; len(queue) > 0 {
+			matched[queue[0]] = true
+			unchanged[i] = true
+			byContent[k] = queue[1:]
+		}
+	}
+
+	byKey := make(map[string][]int)
+	for i, row := range old.Rows {
+		if !matched[i] {
+			key := row.Key()
+			byKey[key] = append(byKey[key], i)
+		}
+	}
+	diff := &Diff{}
+	for i, row := range collection.Rows {
+		if unchanged[i] {
+			continue
+		}
+		key := row.Key()
+		queue := byKey[key]
+		if len(queue) == 0 {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+		byKey[key] = queue[1:]
+		matched[queue[0]] = true
+		oldRow := old.Rows[queue[0]]
+		diff.Changed = append(diff.Changed, RowChange{oldRow, row, fieldChanges(oldRow, row)})
+	}
+	for i, row := range old.Rows {
+		if !matched[i] {
+			diff.Removed = append(diff.Removed, row)
+		}
+	}
+	return diff
+}
+
+// fieldChanges returns the OFCOM columns that differ between two rows.
+func fieldChanges(old, new *Row) []FieldChange {
+	oldRecord, newRecord := old.toRecord(standardHeader), new.toRecord(standardHeader)
+	var changes []FieldChange
+	for i, column := range standardHeader {
+		if oldRecord[i] != newRecord[i] {
+			changes = append(changes, FieldChange{column, oldRecord[i], newRecord[i]})
+		}
+	}
+	return changes
+}
+
+// diffHeader is the header of WriteDiffCSV.
+var diffHeader = []string{
+	"Change", "Licence Number", "Frequency", "NGR", "Antenna AZIMUTH", "Column", "Old", "New",
+}
+
+// WriteDiffCSV writes the diff as csv, one record per added or removed row
+// and one per changed column of a changed row. Change is "added",
+// "removed" or "changed"; Column, Old and New are empty for added and
+// removed rows.
+func (diff *Diff) WriteDiffCSV(writer io.Writer) error {
+	w := newCSVRecordWriter(writer, nil)
+	if err := w.write(diffHeader); err != nil {
+		return errors.Wrap(err, "could not write diff header")
+	}
+	record := func(change string, row *Row, fields ...string) []string {
+		return append([]string{change, row.LicenceNumber, row.Frequency, row.NGR, row.AntennaAzimuth}, fields...)
+	}
+	for _, row := range diff.Added {
+		if err := w.write(record("added", row, "", "", "")); err != nil {
+			return errors.Wrap(err, "could not write diff")
+		}
+	}
+	for _, row := range diff.Removed {
+		if err := w.write(record("removed", row, "", "", "")); err != nil {
+			return errors.Wrap(err, "could not write diff")
+		}
+	}
+	for _, change := range diff.Changed {
+		for _, field := range change.Fields {
+			if err := w.write(record("changed", change.New, field.Column, field.Old, field.New)); err != nil {
+				return errors.Wrap(err, "could not write diff")
+			}
+		}
+	}
+	return errors.Wrap(w.flush(), "could not write diff")
+}
+
+// WriteDiffJSON writes the diff as a JSON object with arrays added, removed
+// and changed, the rows having the field names of WriteJSON.
+func (diff *Diff) WriteDiffJSON(writer io.Writer) error {
+	b, err := json.Marshal(diff)
+	if err != nil {
+		return errors.Wrap(err, "could not encode diff")
+	}
+	_, err = writer.Write(append(b, '\n'))
+	return errors.Wrap(err, "could not write diff")
+}
+
+// String summarises the diff, eg. "3 added, 1 removed, 2 changed".
+func (diff *Diff) String() string {
+	return fmt.Sprintf("%d added, %d removed, %d changed", len(diff.Added), len(diff.Removed), len(diff.Changed))
+}
@@ -0,0 +1,77 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	const header = "Licence Number,Frequency,NGR,Antenna AZIMUTH,Antenna ERP,Status\n"
+	old := testCollection(t, header+`0000001/1,7.5,TQ 29400 81900,90,30,Live
+0000002/1,13.0,SJ 84000 98000,270,20,Live
+0000003/1,18.0,NZ 10000 20000,0,10,Live
+0000005/1,23.0,SU 10000 20000,45,10,Live
+0000005/1,23.0,SU 10000 20000,45,12,Live
+`)
+	collection := testCollection(t, header+`0000004/1,23.0,SU 10000 20000,45,10,Live
+0000001/1,7.5,TQ 29400 81900,90,30,Live
+0000002/1,13.0,SJ 84000 98000,270,25,Cancelled
+0000005/1,23.0,SU 10000 20000,45,12,Live
+`)
+	diff := collection.Diff(old)
+	if diff.String() != "1 added, 2 removed, 1 changed" {
+		t.Fatalf("got %s", diff)
+	}
+	if diff.Added[0].LicenceNumber != "0000004/1" {
+		t.Errorf("added %s", diff.Added[0].LicenceNumber)
+	}
+	if diff.Removed[0].LicenceNumber != "0000003/1" || diff.Removed[1].AntennaErp != "10" {
+		t.Errorf("removed %s and %s", diff.Removed[0].LicenceNumber, diff.Removed[1].AntennaErp)
+	}
+	change := diff.Changed[0]
+	if change.Old != old.Rows[1] || change.New != collection.Rows[2] {
+		t.Errorf("wrong rows changed")
+	}
+	want := []FieldChange{{"Antenna ERP", "20", "25"}, {"Status", "Live", "Cancelled"}}
+	if len(change.Fields) != len(want) || change.Fields[0] != want[0] || change.Fields[1] != want[1] {
+		t.Errorf("got fields %v, want %v", change.Fields, want)
+	}
+	if d := collection.Diff(collection); d.String() != "0 added, 0 removed, 0 changed" {
+		t.Errorf("collection differs from itself: %s", d)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := diff.WriteDiffCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	const wantCSV = `Change,Licence Number,Frequency,NGR,Antenna AZIMUTH,Column,Old,New
+added,0000004/1,23.0,SU 10000 20000,45,,,
+removed,0000003/1,18.0,NZ 10000 20000,0,,,
+removed,0000005/1,23.0,SU 10000 20000,45,,,
+changed,0000002/1,13.0,SJ 84000 98000,270,Antenna ERP,20,25
+changed,0000002/1,13.0,SJ 84000 98000,270,Status,Live,Cancelled
+`
+	if buf.String() != wantCSV {
+		t.Errorf("got csv\n%s\nwant\n%s", buf, wantCSV)
+	}
+
+	buf.Reset()
+	if err := diff.WriteDiffJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Added   []map[string]interface{} `json:"added"`
+		Removed []map[string]interface{} `json:"removed"`
+		Changed []struct {
+			New    map[string]interface{} `json:"new"`
+			Fields []FieldChange          `json:"fields"`
+		} `json:"changed"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Added) != 1 || len(decoded.Removed) != 2 || decoded.Changed[0].New["licence_number"] != "0000002/1" || len(decoded.Changed[0].Fields) != 2 {
+		t.Errorf("wrong json %s", buf)
+	}
+}
@@ -0,0 +1,142 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Emission is a decoded ITU emission designator (ITU-R Radio Regulations
+// Appendix 1), eg. "28M0D7W": a necessary bandwidth of 28 MHz, amplitude
+// and angle modulation (D), two or more digital channels (7) and a
+// combination of information types (W).
+type Emission struct {
+	Designator  string  // as parsed, upper case
+	BandwidthHz float64 // necessary bandwidth
+	Modulation  byte    // first symbol of the class, eg. 'F', see EmissionModulations
+	Nature      byte    // second symbol, eg. '7', see EmissionNatures
+	Information byte    // third symbol, eg. 'W', see EmissionInformation
+}
+
+// EmissionModulations describe the first symbol of the emission class, the
+// type of modulation of the main carrier.
+var EmissionModulations = map[byte]string{
+	'N': "Unmodulated carrier",
+	'A': "Double-sideband amplitude modulation",
+	'H': "Single-sideband, full carrier",
+	'R': "Single-sideband, reduced or variable level carrier",
+	'J': "Single-sideband, suppressed carrier",
+	'B': "Independent sidebands",
+	'C': "Vestigial sideband",
+	'F': "Frequency modulation",
+	'G': "Phase modulation",
+	'D': "Amplitude and angle modulation",
+	'P': "Sequence of unmodulated pulses",
+	'K': "Pulses modulated in amplitude",
+	'L': "Pulses modulated in width or duration",
+	'M': "Pulses modulated in position or phase",
+	'Q': "Pulses with angle modulation of the carrier",
+	'V': "Combination of pulse modulations",
+	'W': "Combination of amplitude, angle and pulse modulation",
+	'X': "Other",
+}
+
+// EmissionNatures describe the second symbol of the emission class, the
+// nature of the signal modulating the main carrier.
+var EmissionNatures = map[byte]string{
+	'0': "No modulating signal",
+	'1': "Single channel of quantized or digital information, no modulating sub-carrier",
+	'2': "Single channel of quantized or digital information, with modulating sub-carrier",
+	'3': "Single channel of analogue information",
+	'7': "Two or more channels of quantized or digital information",
+	'8': "Two or more channels of analogue information",
+	'9': "Composite of analogue and digital channels",
+	'X': "Other",
+}
+
+// EmissionInformation describe the third symbol of the emission class, the
+// type of information transmitted.
+var EmissionInformation = map[byte]string{
+	'N': "No information",
+	'A': "Telegraphy for aural reception",
+	'B': "Telegraphy for automatic reception",
+	'C': "Facsimile",
+	'D': "Data transmission, telemetry, telecommand",
+	'E': "Telephony",
+	'F': "Television (video)",
+	'W': "Combination of the above",
+	'X': "Other",
+}
+
+// emissionMultipliers are the bandwidth letters, which mark the decimal
+// point.
+var emissionMultipliers = map[byte]float64{'H': 1, 'K': 1e3, 'M': 1e6, 'G': 1e9}
+
+// ParseEmission decodes an emission designator: four characters of
+// necessary bandwidth then the three symbols of the class. Any further
+// optional characteristics are ignored.
+func ParseEmission(s string) (*Emission, error) {
+	designator := strings.ToUpper(strings.TrimSpace(s))
+	if len(designator) < 7 {
+		return nil, errors.Errorf("emission designator %q is too short", s)
+	}
+	bandwidth, err := parseEmissionBandwidth(designator[:4])
+	if err != nil {
+		return nil, errors.Wrapf(err, "emission designator %q", s)
+	}
+	emission := &Emission{designator, bandwidth, designator[4], designator[5], designator[6]}
+	if _, ok := EmissionModulations[emission.Modulation]; !ok {
+		return nil, errors.Errorf("emission designator %q: unknown modulation %q", s, emission.Modulation)
+	}
+	if _, ok := EmissionNatures[emission.Nature]; !ok {
+		return nil, errors.Errorf("emission designator %q: unknown nature of signal %q", s, emission.Nature)
+	}
+	if _, ok := EmissionInformation[emission.Information]; !ok {
+		return nil, errors.Errorf("emission designator %q: unknown type of information %q", s, emission.Information)
+	}
+	return emission, nil
+}
+
+// parseEmissionBandwidth parses the bandwidth part of a designator, three
+// digits and a letter for the decimal point, eg. "28M0" or "1K10".
+func parseEmissionBandwidth(s string) (float64, error) {
+	for i := 0; i < len(s); i++ {
+		multiplier, ok := emissionMultipliers[s[i]]
+		if !ok {
+			continue
+		}
+		digits := s[:i] + s[i+1:]
+		if !isDigits(digits) || i == 0 && s[i] != 'H' {
+			break
+		}
+		v, _ := strconv.Atoi(digits)
+		return float64(v) * multiplier / math.Pow(10, float64(len(s)-1-i)), nil
+	}
+	return 0, errors.Errorf("bad bandwidth %q", s)
+}
+
+// Emission decodes the emission code of the Row. ok is false if it is
+// empty or cannot be parsed.
+func (row *Row) Emission() (emission *Emission, ok bool) {
+	emission, err := ParseEmission(row.EmissionCode)
+	return emission, err == nil
+}
+
+// FilterEmissionBandwidthAtLeast returns a FilterFn keeping the rows whose
+// emission code has a necessary bandwidth of at least hz.
+func FilterEmissionBandwidthAtLeast(hz float64) FilterFn {
+	return func(row *Row) bool {
+		emission, ok := row.Emission()
+		return ok && emission.BandwidthHz >= hz
+	}
+}
+
+// FilterEmissionModulations returns a FilterFn keeping the rows whose
+// emission code has any of the modulation symbols, eg. 'D' or 'F'.
+func FilterEmissionModulations(modulations ...byte) FilterFn {
+	return func(row *Row) bool {
+		emission, ok := row.Emission()
+		return ok && strings.IndexByte(string(modulations), emission.Modulation) >= 0
+	}
+}
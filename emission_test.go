@@ -0,0 +1,49 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestParseEmission(t *testing.T) {
+	for _, test := range []struct {
+		designator string
+		want       Emission
+	}{
+		{"28M0D7W", Emission{"28M0D7W", 28e6, 'D', '7', 'W'}},
+		{" 1k10f3e ", Emission{"1K10F3E", 1100, 'F', '3', 'E'}},
+		{"100HA1A", Emission{"100HA1A", 100, 'A', '1', 'A'}},
+		{"H250J3E", Emission{"H250J3E", 0.25, 'J', '3', 'E'}},
+		{"2G50G7WET", Emission{"2G50G7WET", 2.5e9, 'G', '7', 'W'}},
+		{"16K0F3EJN", Emission{"16K0F3EJN", 16000, 'F', '3', 'E'}},
+	} {
+		got, err := ParseEmission(test.designator)
+		if err != nil {
+			t.Errorf("%q: %v", test.designator, err)
+		} else if *got != test.want {
+			t.Errorf("%q: got %+v, want %+v", test.designator, *got, test.want)
+		}
+	}
+	for _, designator := range []string{"", "28M0D7", "28X0D7W", "M280D7W", "2M8MD7W", "28M0Z7W", "28M0D5W", "28M0D7Z"} {
+		if _, err := ParseEmission(designator); err == nil {
+			t.Errorf("%q: expected error", designator)
+		}
+	}
+}
+
+func TestFilterEmission(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Emission Code
+0000001/1,28M0D7W
+0000002/1,12K5F3E
+0000003/1,
+0000004/1,56M0G7W
+`)
+	if got := licenceNumbers(collection.Filter(FilterEmissionBandwidthAtLeast(20e6))); got != "0000001/1 0000004/1" {
+		t.Errorf("got %s", got)
+	}
+	if got := licenceNumbers(collection.Filter(FilterEmissionModulations('F', 'G'))); got != "0000002/1 0000004/1" {
+		t.Errorf("got %s", got)
+	}
+	if _, ok := collection.Rows[2].Emission(); ok {
+		t.Error("empty emission code decoded")
+	}
+}
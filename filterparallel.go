@@ -0,0 +1,43 @@
+package wtrcsv
+
+import (
+	"sync"
+)
+
+// FilterParallel is as Filter but calls the filterFuncs on n goroutines,
+// each with a contiguous part of the rows, keeping the order of the rows.
+// The filterFuncs must be safe to call concurrently. n less than 2 is as
+// Filter.
+func (collection *Collection) FilterParallel(n int, filterFuncs ...FilterFn) *Collection {
+	if n < 2 || len(collection.Rows) < 2 {
+		return collection.Filter(filterFuncs...)
+	}
+	if n > len(collection.Rows) {
+		n = len(collection.Rows)
+	}
+	record := filterMetrics()
+	parts := make([][]*Row, n)
+	var wg sync.WaitGroup
+	for p := range parts {
+		low, high := p*len(collection.Rows)/n, (p+1)*len(collection.Rows)/n
+		wg.Add(1)
+		go func(p int, rows []*Row) {
+			defer wg.Done()
+			parts[p] = filterAppend(nil, rows, filterFuncs)
+		}(p, collection.Rows[low:high])
+	}
+	wg.Wait()
+
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+	rows := make([]*Row, 0, size)
+	for _, part := range parts {
+		rows = append(rows, part...)
+	}
+	if record != nil {
+		record(len(rows))
+	}
+	return collection.withRows(rows)
+}
@@ -0,0 +1,29 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFilterParallel(t *testing.T) {
+	rows := make([]*Row, 1001)
+	for i := range rows {
+		rows[i] = &Row{LicenceNumber: strconv.Itoa(i)}
+	}
+	collection := &Collection{Header: []string{"Licence Number"}, Rows: rows}
+	third := func(row *Row) bool {
+		i, _ := strconv.Atoi(row.LicenceNumber)
+		return i%3 == 1
+	}
+	want := collection.Filter(third)
+	for _, n := range []int{0, 1, 2, 7, 2000} {
+		got := collection.FilterParallel(n, third)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d goroutines: got %d rows, want %d", n, len(got.Rows), len(want.Rows))
+		}
+	}
+	if got := (&Collection{Rows: []*Row{}}).FilterParallel(4, third); len(got.Rows) != 0 {
+		t.Error("rows from an empty collection")
+	}
+}
@@ -0,0 +1,155 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Frequency is a frequency in whole Hz. Unlike float64 MHz it represents
+// the register's decimal values exactly, so frequencies compare equal with
+// == and order with <.
+type Frequency int64
+
+// Frequency units.
+const (
+	Hertz     Frequency = 1
+	Kilohertz Frequency = 1e3
+	Megahertz Frequency = 1e6
+	Gigahertz Frequency = 1e9
+)
+
+// frequencyExponents are the powers of ten of the frequency units to Hz.
+var frequencyExponents = map[string]int{"hz": 0, "khz": 3, "mhz": 6, "ghz": 9}
+
+// ParseFrequency combines a decimal value and its unit (Hz, kHz, MHz or
+// GHz, in any case), eg. "7.4565" and "GHz", into a Frequency, rounding to
+// the nearest Hz.
+func ParseFrequency(value, unit string) (Frequency, error) {
+	exponent, ok := frequencyExponents[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		return 0, errors.Errorf("unknown frequency unit %q", unit)
+	}
+	s := strings.TrimSpace(value)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if whole+fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		// Not plain decimal, eg. with an exponent.
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(v) || math.Abs(v)*math.Pow(10, float64(exponent)) >= math.MaxInt64 {
+			return 0, errors.Errorf("bad frequency %q", value)
+		}
+		return Frequency(math.Round(v * math.Pow(10, float64(exponent)))), nil
+	}
+
+	// Shift the decimal point right by the exponent, rounding the digits
+	// that remain after it.
+	for len(fraction) < exponent {
+		fraction += "0"
+	}
+	digits, rest := strings.TrimLeft(whole+fraction[:exponent], "0"), fraction[exponent:]
+	if len(digits) > 18 {
+		return 0, errors.Errorf("frequency %q is too large", value)
+	}
+	hz, _ := strconv.ParseInt("0"+digits, 10, 64)
+	if rest != "" && rest[0] >= '5' {
+		hz++
+	}
+	if negative {
+		hz = -hz
+	}
+	return Frequency(hz), nil
+}
+
+// FrequencyFromMHz returns the Frequency of mhz, rounded to the nearest Hz.
+func FrequencyFromMHz(mhz float64) Frequency {
+	return Frequency(math.Round(mhz * 1e6))
+}
+
+// Hz returns the frequency in Hz.
+func (f Frequency) Hz() int64 {
+	return int64(f)
+}
+
+// MHz returns the frequency in MHz.
+func (f Frequency) MHz() float64 {
+	return float64(f) / 1e6
+}
+
+// String formats the frequency in the largest unit in which it is at least
+// 1, without trailing zeros, eg. "7.4565 GHz" or "458.5 MHz".
+func (f Frequency) String() string {
+	sign := ""
+	hz := uint64(f)
+	if f < 0 {
+		sign, hz = "-", uint64(-f)
+	}
+	unit := displayUnits[len(displayUnits)-1]
+	for _, u := range displayUnits {
+		if float64(hz) >= u.hz {
+			unit = u
+			break
+		}
+	}
+	scale := uint64(unit.hz)
+	s := sign + strconv.FormatUint(hz/scale, 10)
+	if fraction := hz % scale; fraction != 0 {
+		digits := strconv.FormatUint(fraction+scale, 10)[1:] // zero padded
+		s += "." + strings.TrimRight(digits, "0")
+	}
+	return s + " " + unit.name
+}
+
+// FrequencyHz returns the frequency of the Row, combining the Frequency
+// and Frequency Type columns exactly. ok is false if either is unknown.
+func (row *Row) FrequencyHz() (frequency Frequency, ok bool) {
+	frequency, err := ParseFrequency(row.Frequency, row.FrequencyType)
+	return frequency, err == nil
+}
+
+// ChannelWidthHz returns the channel width of the Row, combining the
+// Channel Width and Channel Width type columns exactly. ok is false if
+// either is unknown.
+func (row *Row) ChannelWidthHz() (width Frequency, ok bool) {
+	width, err := ParseFrequency(row.ChannelWidth, row.ChannelWidthType)
+	return width, err == nil && width >= 0
+}
+
+// ChannelEdges returns the lower and upper edges of the channel of the Row:
+// its frequency less and plus half its channel width. Without a channel
+// width both are the frequency. ok is false without a frequency.
+func (row *Row) ChannelEdges() (lower, upper Frequency, ok bool) {
+	f, ok := row.FrequencyHz()
+	if !ok {
+		return 0, 0, false
+	}
+	width, _ := row.ChannelWidthHz()
+	lower = f - width/2
+	return lower, lower + width, true
+}
+
+// ChannelsOverlap reports whether the channels of two rows overlap, ie.
+// share any frequency, touching edges included. It is false if either row
+// has no frequency.
+func ChannelsOverlap(a, b *Row) bool {
+	aLower, aUpper, okA := a.ChannelEdges()
+	bLower, bUpper, okB := b.ChannelEdges()
+	return okA && okB && aLower <= bUpper && bLower <= aUpper
+}
+
+// FilterFrequencyRange returns a FilterFn keeping the rows with a frequency
+// in [lowMHz, highMHz], whatever the unit of their Frequency Type. The
+// bounds are rounded to the nearest Hz and compared exactly, so
+// FilterFrequencyRange(7500, 7500) keeps 7.5 GHz.
+func FilterFrequencyRange(lowMHz, highMHz float64) FilterFn {
+	low, high := FrequencyFromMHz(lowMHz), FrequencyFromMHz(highMHz)
+	return func(row *Row) bool {
+		f, ok := row.FrequencyHz()
+		return ok && f >= low && f <= high
+	}
+}
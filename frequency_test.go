@@ -0,0 +1,120 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestParseFrequency(t *testing.T) {
+	for _, test := range []struct {
+		value, unit string
+		want        Frequency
+	}{
+		{"7.4565", "GHz", 7456500000},
+		{"7456.5", "MHz", 7456500000},
+		{" 458.500001 ", "mhz", 458500001},
+		{"12.5", "kHz", 12500},
+		{"0.0000005", "MHz", 1}, // rounded
+		{"0.0000004", "MHz", 0},
+		{"-2.5", "MHz", -2500000},
+		{"18", "GHz", 18 * Gigahertz},
+		{"1e3", "MHz", Gigahertz},
+		{".5", "GHz", 500 * Megahertz},
+	} {
+		got, err := ParseFrequency(test.value, test.unit)
+		if err != nil || got != test.want {
+			t.Errorf("%q %s: got %d (%v), want %d", test.value, test.unit, got, err, test.want)
+		}
+	}
+	for _, test := range [][2]string{{"7.5", "furlongs"}, {"", "MHz"}, {"seven", "MHz"}, {"1.2.3", "MHz"}, {"99999999999", "GHz"}} {
+		if _, err := ParseFrequency(test[0], test[1]); err == nil {
+			t.Errorf("%q %s: expected error", test[0], test[1])
+		}
+	}
+
+	// The floating point sum 7.4565e9 is not the same as the exact value.
+	a, _ := ParseFrequency("7.4565", "GHz")
+	b, _ := ParseFrequency("7456.5", "MHz")
+	if a != b || a.String() != "7.4565 GHz" || a.MHz() != 7456.5 || FrequencyFromMHz(7456.5) != a {
+		t.Errorf("got %v and %v", a, b)
+	}
+}
+
+func TestFrequencyString(t *testing.T) {
+	for f, want := range map[Frequency]string{
+		7456500000:      "7.4565 GHz",
+		458500001:       "458.500001 MHz",
+		-2500000:        "-2.5 MHz",
+		999:             "999 Hz",
+		1000:            "1 kHz",
+		0:               "0 Hz",
+		Frequency(1e18): "1000000000 GHz",
+	} {
+		if got := f.String(); got != want {
+			t.Errorf("%d: got %q, want %q", int64(f), got, want)
+		}
+	}
+	row := Row{Frequency: "7.5", FrequencyType: "GHz"}
+	if f, ok := row.FrequencyHz(); !ok || f != 7500*Megahertz {
+		t.Errorf("got %v", f)
+	}
+	if KeyFrequency(&row) != KeyFrequency(&Row{Frequency: "7500", FrequencyType: "MHz"}) {
+		t.Error("equal frequencies keyed differently")
+	}
+}
+
+func TestChannelEdges(t *testing.T) {
+	a := &Row{Frequency: "7.5", FrequencyType: "GHz", ChannelWidth: "28", ChannelWidthType: "MHz"}
+	if w, ok := a.ChannelWidthHz(); !ok || w != 28*Megahertz {
+		t.Errorf("got width %v", w)
+	}
+	lower, upper, ok := a.ChannelEdges()
+	if !ok || lower != 7486*Megahertz || upper != 7514*Megahertz {
+		t.Errorf("got edges %v to %v", lower, upper)
+	}
+	narrow := &Row{Frequency: "450.1", FrequencyType: "MHz", ChannelWidth: "12.5", ChannelWidthType: "kHz"}
+	if lower, upper, _ := narrow.ChannelEdges(); lower != 450093750 || upper != 450106250 {
+		t.Errorf("got edges %v to %v", lower, upper)
+	}
+	noWidth := &Row{Frequency: "7514", FrequencyType: "MHz"}
+	if lower, upper, ok := noWidth.ChannelEdges(); !ok || lower != upper {
+		t.Errorf("got edges %v to %v", lower, upper)
+	}
+
+	for _, test := range []struct {
+		a, b *Row
+		want bool
+	}{
+		{a, noWidth, true}, // touching
+		{a, &Row{Frequency: "7540", FrequencyType: "MHz", ChannelWidth: "28", ChannelWidthType: "MHz"}, false},
+		{a, &Row{Frequency: "7530", FrequencyType: "MHz", ChannelWidth: "40", ChannelWidthType: "MHz"}, true},
+		{a, narrow, false},
+		{a, &Row{}, false},
+	} {
+		if got := ChannelsOverlap(test.a, test.b); got != test.want || ChannelsOverlap(test.b, test.a) != test.want {
+			t.Errorf("%+v and %+v: got %v", test.a, test.b, got)
+		}
+	}
+}
+
+func TestFilterFrequencyRange(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type
+0000001/1,7.5,GHz
+0000002/1,7500.000001,MHz
+0000003/1,450000,kHz
+0000004/1,7.49999,GHz
+0000005/1,7500,
+`)
+	for _, test := range []struct {
+		low, high float64
+		want      string
+	}{
+		{7500, 7500, "0000001/1"},
+		{7499, 7501, "0000001/1 0000002/1 0000004/1"},
+		{400, 500, "0000003/1"},
+		{7501, 7499, ""},
+	} {
+		if got := licenceNumbers(collection.Filter(FilterFrequencyRange(test.low, test.high))); got != test.want {
+			t.Errorf("%v-%v: got %q, want %q", test.low, test.high, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package wtrcsv
+
+import (
+	"math"
+	"sort"
+)
+
+// frequencyInterval is the band occupied by a row: its frequency plus and
+// minus half its channel width, in MHz.
+type frequencyInterval struct {
+	low, high float64
+	row       *Row
+}
+
+// rowFrequencyInterval returns the band occupied by a Row, its channel
+// edges. Without a channel width the band is the frequency alone.
+func rowFrequencyInterval(row *Row) (frequencyInterval, bool) {
+	lower, upper, ok := row.ChannelEdges()
+	if !ok {
+		return frequencyInterval{}, false
+	}
+	return frequencyInterval{lower.MHz(), upper.MHz(), row}, true
+}
+
+// FrequencyIndex is an interval index of rows by the band they occupy
+// (see QueryFrequencyRange). Rows without a frequency are not indexed.
+//
+// The intervals are kept sorted by their lower edge with a tree of the
+// greatest upper edge over them, so a query is O(log n) plus the rows
+// found. Add and Remove are O(n).
+type FrequencyIndex struct {
+	intervals []frequencyInterval
+	maxHigh   []float64 // the tree, node 1 covering all intervals
+}
+
+// BuildFrequencyIndex returns a FrequencyIndex of the rows.
+func (collection *Collection) BuildFrequencyIndex() *FrequencyIndex {
+	index := &FrequencyIndex{}
+	for _, row := range collection.Rows {
+		if interval, ok := rowFrequencyInterval(row); ok {
+			index.intervals = append(index.intervals, interval)
+		}
+	}
+	sort.SliceStable(index.intervals, func(i, j int) bool {
+		return index.intervals[i].low < index.intervals[j].low
+	})
+	index.build()
+	return index
+}
+
+// build rebuilds the tree of greatest upper edges.
+func (index *FrequencyIndex) build() {
+	index.maxHigh = make([]float64, 4*len(index.intervals)+1)
+	if len(index.intervals) > 0 {
+		index.buildNode(1, 0, len(index.intervals))
+	}
+}
+
+func (index *FrequencyIndex) buildNode(node, l, r int) float64 {
+	if r-l == 1 {
+		index.maxHigh[node] = index.intervals[l].high
+	} else {
+		m := (l + r) / 2
+		index.maxHigh[node] = math.Max(index.buildNode(2*node, l, m), index.buildNode(2*node+1, m, r))
+	}
+	return index.maxHigh[node]
+}
+
+// Len returns the number of rows indexed.
+func (index *FrequencyIndex) Len() int {
+	return len(index.intervals)
+}
+
+// QueryFrequencyRange returns the rows whose band (the frequency plus and
+// minus half the channel width) overlaps [lowMHz, highMHz], including
+// bands that only touch it, in order of the lower edge of their band.
+func (index *FrequencyIndex) QueryFrequencyRange(lowMHz, highMHz float64) []*Row {
+	if len(index.intervals) == 0 || lowMHz > highMHz {
+		return nil
+	}
+	// Only the intervals starting at or below highMHz can overlap.
+	k := sort.Search(len(index.intervals), func(i int) bool { return index.intervals[i].low > highMHz })
+	var rows []*Row
+	var search func(node, l, r int)
+	search = func(node, l, r int) {
+		if l >= k || index.maxHigh[node] < lowMHz {
+			return
+		}
+		if r-l == 1 {
+			rows = append(rows, index.intervals[l].row)
+			return
+		}
+		m := (l + r) / 2
+		search(2*node, l, m)
+		search(2*node+1, m, r)
+	}
+	search(1, 0, len(index.intervals))
+	return rows
+}
+
+// Add implements Index, inserting a row (if it has a frequency).
+func (index *FrequencyIndex) Add(row *Row) {
+	interval, ok := rowFrequencyInterval(row)
+	if !ok {
+		return
+	}
+	i := sort.Search(len(index.intervals), func(i int) bool { return index.intervals[i].low > interval.low })
+	index.intervals = append(index.intervals, frequencyInterval{})
+	copy(index.intervals[i+1:], index.intervals[i:])
+	index.intervals[i] = interval
+	index.build()
+}
+
+// Remove implements Index.
+func (index *FrequencyIndex) Remove(row *Row) {
+	for i := range index.intervals {
+		if index.intervals[i].row == row {
+			index.intervals = append(index.intervals[:i], index.intervals[i+1:]...)
+			index.build()
+			return
+		}
+	}
+}
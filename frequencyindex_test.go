@@ -0,0 +1,81 @@
+package wtrcsv
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestQueryFrequencyRange(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type,Channel Width,Channel Width type
+0000001/1,7.5,GHz,28,MHz
+0000002/1,7520,MHz,,
+0000003/1,450.1,MHz,12.5,kHz
+0000004/1,18,GHz,55,MHz
+0000005/1,,,,
+`)
+	index := collection.BuildFrequencyIndex()
+	if index.Len() != 4 {
+		t.Fatalf("%d rows indexed", index.Len())
+	}
+	for _, test := range []struct {
+		low, high float64
+		want      string
+	}{
+		{7510, 7515, "0000001/1"},           // inside the 7486 to 7514 MHz channel
+		{7514, 7520, "0000001/1 0000002/1"}, // touching
+		{450.10625, 451, "0000003/1"},
+		{450.107, 451, ""},
+		{0, 100000, "0000003/1 0000001/1 0000002/1 0000004/1"},
+		{20000, 10000, ""},
+	} {
+		got := licenceNumbers(&Collection{Rows: index.QueryFrequencyRange(test.low, test.high)})
+		if got != test.want {
+			t.Errorf("%v-%v: got %q, want %q", test.low, test.high, got, test.want)
+		}
+	}
+
+	index.Remove(collection.Rows[0])
+	if got := index.QueryFrequencyRange(7510, 7515); len(got) != 0 {
+		t.Errorf("removed row found")
+	}
+	index.Add(collection.Rows[0])
+	if got := index.QueryFrequencyRange(7510, 7515); len(got) != 1 {
+		t.Errorf("added row not found")
+	}
+}
+
+func TestFrequencyIndexBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rows := make([]*Row, 1000)
+	for i := range rows {
+		rows[i] = &Row{
+			LicenceNumber:    strconv.Itoa(i),
+			Frequency:        strconv.FormatFloat(1000*r.Float64(), 'f', 3, 64),
+			FrequencyType:    "MHz",
+			ChannelWidth:     strconv.FormatFloat(10*r.ExpFloat64(), 'f', 3, 64),
+			ChannelWidthType: "MHz",
+		}
+	}
+	index := (&Collection{Rows: rows}).BuildFrequencyIndex()
+	for i := 0; i < 200; i++ {
+		low := 1000 * r.Float64()
+		high := low + 20*r.Float64()
+		want := make(map[*Row]bool)
+		for _, row := range rows {
+			interval, _ := rowFrequencyInterval(row)
+			if interval.low <= high && interval.high >= low {
+				want[row] = true
+			}
+		}
+		got := index.QueryFrequencyRange(low, high)
+		if len(got) != len(want) {
+			t.Fatalf("%v-%v: got %d rows, want %d", low, high, len(got), len(want))
+		}
+		for _, row := range got {
+			if !want[row] {
+				t.Fatalf("%v-%v: unexpected row %s", low, high, row.LicenceNumber)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"math"
+)
+
+// speedOfLight is in metres per second.
+const speedOfLight = 299792458.0
+
+// FresnelRadiusM returns the radius in metres of the first Fresnel zone at
+// a frequency, d1Km and d2Km from the ends of a path.
+func FresnelRadiusM(frequencyMHz, d1Km, d2Km float64) float64 {
+	if d1Km <= 0 || d2Km <= 0 || frequencyMHz <= 0 {
+		return 0
+	}
+	wavelength := speedOfLight / (frequencyMHz * 1e6)
+	return math.Sqrt(wavelength * d1Km * d2Km * 1000 / (d1Km + d2Km))
+}
+
+// FresnelPoint is the clearance of the first Fresnel zone at a point of a
+// PathProfile.
+type FresnelPoint struct {
+	DistanceKm float64 // from the A end
+	RadiusM    float64 // of the first Fresnel zone
+	ClearanceM float64 // of the line of sight, as ProfilePoint
+	// Fraction is ClearanceM over RadiusM: 1 or more is clear of the zone,
+	// less than 0 obstructs the line of sight.
+	Fraction   float64
+	Obstructed bool // Fraction is less than the Required of the report
+}
+
+// ClearanceReport is the result of PathProfile.FresnelClearance.
+type ClearanceReport struct {
+	FrequencyMHz float64
+	Required     float64 // the fraction of the zone to be clear, eg. 0.6
+	// Points are the points of the profile between the ends.
+	Points []FresnelPoint
+	// MinFraction is the least Fraction of the points, at MinFractionKm
+	// from the A end, +Inf if there are none.
+	MinFraction   float64
+	MinFractionKm float64
+	// ObstructedFraction is the fraction of the points that are obstructed.
+	ObstructedFraction float64
+	Clear              bool // whether no point is obstructed
+}
+
+// FresnelClearance checks the clearance of the first Fresnel zone of the
+// link at its frequency (see Link.FrequencyMHz), over the
+// points of the profile between the ends. A point is obstructed where less
+// than the required fraction of the radius of the zone is clear, 0.6 being
+// the usual criterion for microwave links.
+func (profile *PathProfile) FresnelClearance(required float64) (*ClearanceReport, error) {
+	f, ok := profile.Link.FrequencyMHz()
+	if !ok {
+		return nil, errors.Errorf("link %s has no frequency", profile.Link.LicenceNumber())
+	}
+	report := &ClearanceReport{FrequencyMHz: f, Required: required, MinFraction: math.Inf(1), Clear: true}
+	if len(profile.Points) <= 2 {
+		return report, nil
+	}
+	obstructed := 0
+	for _, point := range profile.Points[1 : len(profile.Points)-1] {
+		radius := FresnelRadiusM(f, point.DistanceKm, profile.LengthKm-point.DistanceKm)
+		fresnel := FresnelPoint{DistanceKm: point.DistanceKm, RadiusM: radius, ClearanceM: point.ClearanceM}
+		fresnel.Fraction = point.ClearanceM / radius
+		fresnel.Obstructed = fresnel.Fraction < required
+		if fresnel.Fraction < report.MinFraction {
+			report.MinFraction, report.MinFractionKm = fresnel.Fraction, point.DistanceKm
+		}
+		if fresnel.Obstructed {
+			obstructed++
+			report.Clear = false
+		}
+		report.Points = append(report.Points, fresnel)
+	}
+	report.ObstructedFraction = float64(obstructed) / float64(len(report.Points))
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFresnelRadius(t *testing.T) {
+	// 17.32 sqrt(d1 d2 / (f d)), in GHz and km, is the usual approximation.
+	if r := FresnelRadiusM(7500, 5, 5); math.Abs(r-17.32*math.Sqrt(25/(7.5*10))) > 0.01 {
+		t.Errorf("radius %v", r)
+	}
+	if r := FresnelRadiusM(7500, 0, 10); r != 0 {
+		t.Errorf("radius at an end %v", r)
+	}
+}
+
+func TestFresnelClearance(t *testing.T) {
+	const csv = `Licence Number,NGR,Frequency,Frequency Type,Antenna Height,WGS84 Longitude,WGS84 Latitude
+0000001/1,TQ 00000 00000,7500,MHz,30,0.0,51.5
+0000001/1,TQ 10000 00000,7661,MHz,30,0.1,51.5
+`
+	link := testCollection(t, csv).Links().Links[0]
+	for _, test := range []struct {
+		ridge int16
+		clear bool
+	}{
+		{10, true},
+		{35, false}, // the line of sight is clear, but not 60% of the zone
+	} {
+		tiles := NewSRTMTiles()
+		tiles.AddHGT("N51E000.hgt", testHGT(func(row, col int) int16 {
+			if col == 5 {
+				return test.ridge
+			}
+			return 10
+		}))
+		profile, err := link.PathProfile(tiles, 21)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := profile.FresnelClearance(0.6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !profile.LineOfSight().Clear || report.Clear != test.clear || report.FrequencyMHz != 7500 || len(report.Points) != 19 {
+			t.Fatalf("ridge of %vm: %+v", test.ridge, report)
+		}
+		middle := report.Points[9]
+		if math.Abs(middle.RadiusM-FresnelRadiusM(7500, profile.LengthKm/2, profile.LengthKm/2)) > 1e-9 || middle.Obstructed == test.clear {
+			t.Fatalf("ridge of %vm: middle point %+v", test.ridge, middle)
+		}
+		if !test.clear && (report.ObstructedFraction != 1.0/19 || report.MinFractionKm != middle.DistanceKm) {
+			t.Fatalf("ridge of %vm: %v obstructed, least at %vkm", test.ridge, report.ObstructedFraction, report.MinFractionKm)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package wtrcsv
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusKm = 6371.0088 // mean radius
+
+// rowLatLon returns the location of a Row in degrees, from the WGS84 columns
+// if present, otherwise from the SID latitude and longitude columns (which
+// are treated as WGS84; the difference is no more than ~100m in the UK).
+func rowLatLon(row *Row) (lat, lon float64, ok bool) {
+	if row.hasWgs84() {
+		return row.Wgs84Latitude, row.Wgs84Longitude, true
+	}
+	lat, ok = dmsToDegrees(row.SidLatDeg, row.SidLatMin, row.SidLatSec, row.SidLatNS, "S")
+	if !ok {
+		return 0, 0, false
+	}
+	lon, ok = dmsToDegrees(row.SidLongDeg, row.SidLongMin, row.SidLongSec, row.SidLongEW, "W")
+	if !ok {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// dmsToDegrees converts degrees, minutes and seconds to signed degrees.
+func dmsToDegrees(degrees, minutes, seconds, hemisphere, negative string) (float64, bool) {
+	d, err := strconv.ParseFloat(strings.TrimSpace(degrees), 64)
+	if err != nil {
+		return 0, false
+	}
+	var m, s float64
+	if minutes = strings.TrimSpace(minutes); minutes != "" {
+		if m, err = strconv.ParseFloat(minutes, 64); err != nil {
+			return 0, false
+		}
+	}
+	if seconds = strings.TrimSpace(seconds); seconds != "" {
+		if s, err = strconv.ParseFloat(seconds, 64); err != nil {
+			return 0, false
+		}
+	}
+	value := d + m/60 + s/3600
+	if strings.EqualFold(strings.TrimSpace(hemisphere), negative) {
+		value = -value
+	}
+	return value, true
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// distanceKm is the great-circle (haversine) distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi, dLambda := radians(lat2-lat1), radians(lon2-lon1)
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// bearingDeg is the initial great-circle bearing from point 1 to point 2 in
+// degrees clockwise from north, in [0, 360).
+func bearingDeg(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dLambda := radians(lon2 - lon1)
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// angleBetween is the absolute difference between two bearings, in [0, 180].
+func angleBetween(bearing1, bearing2 float64) float64 {
+	d := math.Mod(math.Abs(bearing1-bearing2), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
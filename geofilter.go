@@ -0,0 +1,54 @@
+package wtrcsv
+
+import (
+	"math"
+)
+
+// FilterBoundingBox returns a FilterFn keeping the rows located (by their
+// WGS84 columns, or their latitude and longitude columns) inside the
+// bounding box, including its edges.
+func FilterBoundingBox(minLat, minLon, maxLat, maxLon float64) FilterFn {
+	return func(row *Row) bool {
+		lat, lon, ok := rowLatLon(row)
+		return ok && lat >= minLat && lat <= maxLat && lon >= minLon && lon <= maxLon
+	}
+}
+
+// FilterBoundingBoxOSGB is as FilterBoundingBox for a box of OSGB36
+// National Grid eastings and northings in metres. Rows are located by
+// their OS columns, or their NGR.
+func FilterBoundingBoxOSGB(minEasting, minNorthing, maxEasting, maxNorthing float64) FilterFn {
+	return func(row *Row) bool {
+		e, n, ok := rowOS(row)
+		return ok && float64(e) >= minEasting && float64(e) <= maxEasting &&
+			float64(n) >= minNorthing && float64(n) <= maxNorthing
+	}
+}
+
+// FilterWithinRadius returns a FilterFn keeping the rows located (as
+// FilterBoundingBox) within radiusKm of a WGS84 position, by great-circle
+// distance.
+func FilterWithinRadius(lat, lon, radiusKm float64) FilterFn {
+	// Rows further north or south than the radius need no distance.
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	return func(row *Row) bool {
+		rowLat, rowLon, ok := rowLatLon(row)
+		return ok && math.Abs(rowLat-lat) <= dLat && distanceKm(lat, lon, rowLat, rowLon) <= radiusKm
+	}
+}
+
+// FilterWithinRadiusOSGB is as FilterWithinRadius for a National Grid
+// position in metres, by planar distance between the rows' OS columns or
+// NGR. Over the distances of interference studies the difference from the
+// great-circle distance is well under 1%.
+func FilterWithinRadiusOSGB(easting, northing, radiusKm float64) FilterFn {
+	r2 := radiusKm * 1000 * radiusKm * 1000
+	return func(row *Row) bool {
+		e, n, ok := rowOS(row)
+		if !ok {
+			return false
+		}
+		de, dn := float64(e)-easting, float64(n)-northing
+		return de*de+dn*dn <= r2
+	}
+}
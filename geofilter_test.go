@@ -0,0 +1,55 @@
+package wtrcsv
+
+import (
+	"strings"
+	"testing"
+)
+
+// licenceNumbers returns the licence numbers of the rows, space separated.
+func licenceNumbers(collection *Collection) string {
+	numbers := make([]string, len(collection.Rows))
+	for i, row := range collection.Rows {
+		numbers[i] = row.LicenceNumber
+	}
+	return strings.Join(numbers, " ")
+}
+
+func TestFilterBoundingBox(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR,WGS84 Longitude,WGS84 Latitude
+0000001/1,SJ 83800 98200,-2.2446,53.4794
+0000002/1,TQ 29400 81900,-0.1246,51.5007
+0000003/1,SJ 8380 9820,,
+`)
+	// Greater Manchester.
+	if got := licenceNumbers(collection.Filter(FilterBoundingBox(53.3, -2.7, 53.7, -1.9))); got != "0000001/1" {
+		t.Errorf("WGS84: got %s", got)
+	}
+	if got := licenceNumbers(collection.Filter(FilterBoundingBoxOSGB(350000, 380000, 410000, 420000))); got != "0000001/1 0000003/1" {
+		t.Errorf("OSGB: got %s", got)
+	}
+	if got := licenceNumbers(collection.Filter(FilterBoundingBox(51.5007, -0.1246, 51.5007, -0.1246))); got != "0000002/1" {
+		t.Errorf("edges: got %s", got)
+	}
+}
+
+func TestFilterWithinRadius(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR,WGS84 Longitude,WGS84 Latitude
+0000001/1,SJ 83800 98200,-2.2446,53.4794
+0000002/1,SJ 90000 90000,-2.1516,53.4058
+0000003/1,TQ 29400 81900,-0.1246,51.5007
+0000004/1,,,
+`)
+	// Manchester to Stockport is about 10km, to London about 260km.
+	for radius, want := range map[float64]string{
+		5:   "0000001/1",
+		25:  "0000001/1 0000002/1",
+		300: "0000001/1 0000002/1 0000003/1",
+	} {
+		if got := licenceNumbers(collection.Filter(FilterWithinRadius(53.4794, -2.2446, radius))); got != want {
+			t.Errorf("%vkm: got %s, want %s", radius, got, want)
+		}
+		if got := licenceNumbers(collection.Filter(FilterWithinRadiusOSGB(383800, 398200, radius))); got != want {
+			t.Errorf("OSGB %vkm: got %s, want %s", radius, got, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+// geohashAlphabet is the geohash base 32 alphabet.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// maxGeohashPrecision is the longest geohash, a cell of a few centimetres.
+const maxGeohashPrecision = 12
+
+// Geohash returns the geohash of a WGS84 position with precision (1 to 12)
+// characters. A geohash of 5 characters is a cell of about 5km by 5km; each
+// character more divides the cell by 32.
+func Geohash(lat, lon float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > maxGeohashPrecision {
+		precision = maxGeohashPrecision
+	}
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+	hash := make([]byte, 0, precision)
+	even := true // bits alternate longitude, latitude
+	var ch, bit int
+	for len(hash) < precision {
+		r, v := &latRange, lat
+		if even {
+			r, v = &lonRange, lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			ch, bit = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// GeohashBBox returns the cell of a geohash as longitude (X) and latitude
+// (Y) bounds.
+func GeohashBBox(hash string) (BBox, error) {
+	if hash == "" {
+		return BBox{}, errors.New("empty geohash")
+	}
+	latRange, lonRange := [2]float64{-90, 90}, [2]float64{-180, 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		ch := strings.IndexRune(geohashAlphabet, c)
+		if ch < 0 {
+			return BBox{}, errors.Errorf("invalid geohash \"%s\"", hash)
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			r := &latRange
+			if even {
+				r = &lonRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if ch&mask != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return BBox{lonRange[0], latRange[0], lonRange[1], latRange[1]}, nil
+}
+
+// GeohashBin aggregates the rows in a geohash cell.
+type GeohashBin struct {
+	Geohash string
+	// Key is the group of the rows, eg. the product code, or "" if the
+	// rows were not grouped.
+	Key   string
+	BBox  BBox
+	Count int
+	// Licences is the number of distinct licence numbers.
+	Licences int
+	// Frequencies is the number of rows with a known frequency, over which
+	// the frequency statistics are calculated.
+	Frequencies      int
+	MinFrequencyMHz  float64
+	MaxFrequencyMHz  float64
+	MeanFrequencyMHz float64
+}
+
+// GeohashBins assigns each row located by its WGS84 columns (or its
+// latitude and longitude columns) to a geohash cell of precision characters
+// and returns the count and statistics of the rows in each cell. If by is
+// not nil the rows are also grouped by its key (eg. KeyProductCode), giving
+// a bin per key and cell. Bins are in key then geohash order. Rows without
+// a position are left out.
+func (collection *Collection) GeohashBins(precision int, by KeyFn) ([]GeohashBin, error) {
+	if precision < 1 || precision > maxGeohashPrecision {
+		return nil, errors.Errorf("geohash precision %d not in 1 to %d", precision, maxGeohashPrecision)
+	}
+
+	type bin struct {
+		GeohashBin
+		licences     map[string]bool
+		frequencySum float64
+	}
+	bins := make(map[[2]string]*bin)
+	for _, row := range collection.Rows {
+		lat, lon, ok := rowLatLon(row)
+		if !ok {
+			continue
+		}
+		cell := [2]string{"", Geohash(lat, lon, precision)}
+		if by != nil {
+			cell[0] = by(row)
+		}
+		b, ok := bins[cell]
+		if !ok {
+			b = &bin{licences: make(map[string]bool)}
+			b.Key, b.Geohash = cell[0], cell[1]
+			b.MinFrequencyMHz, b.MaxFrequencyMHz = math.Inf(1), math.Inf(-1)
+			bins[cell] = b
+		}
+		b.Count++
+		b.licences[row.LicenceNumber] = true
+		if mhz, ok := frequencyMHz(row); ok {
+			b.Frequencies++
+			b.frequencySum += mhz
+			b.MinFrequencyMHz = math.Min(b.MinFrequencyMHz, mhz)
+			b.MaxFrequencyMHz = math.Max(b.MaxFrequencyMHz, mhz)
+		}
+	}
+
+	result := make([]GeohashBin, 0, len(bins))
+	for _, b := range bins {
+		b.Licences = len(b.licences)
+		if b.Frequencies > 0 {
+			b.MeanFrequencyMHz = b.frequencySum / float64(b.Frequencies)
+		} else {
+			b.MinFrequencyMHz, b.MaxFrequencyMHz = 0, 0
+		}
+		b.BBox, _ = GeohashBBox(b.Geohash)
+		result = append(result, b.GeohashBin)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		return result[i].Geohash < result[j].Geohash
+	})
+	return result, nil
+}
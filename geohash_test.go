@@ -0,0 +1,67 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	// Well known geohashes.
+	for _, test := range []struct {
+		lat, lon  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{51.5007, -0.1246, 7, "gcpuvpm"},
+		{-25.382708, -49.265506, 8, "6gkzwgjz"},
+	} {
+		if got := Geohash(test.lat, test.lon, test.precision); got != test.want {
+			t.Errorf("%v, %v: got %s, want %s", test.lat, test.lon, got, test.want)
+		}
+	}
+
+	bbox, err := GeohashBBox("gcpuvpm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bbox.Contains(-0.1246, 51.5007) || bbox.MaxX-bbox.MinX > 0.0014 {
+		t.Errorf("wrong cell %+v", bbox)
+	}
+	if _, err := GeohashBBox("gcpa"); err == nil {
+		t.Error("invalid geohash accepted")
+	}
+}
+
+func TestGeohashBins(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type,Product Description 31,WGS84 Longitude,WGS84 Latitude
+0000001/1,7.5,GHz,301010,-0.1246,51.5007
+0000001/1,7.7,GHz,301010,-0.1247,51.5008
+0000002/1,450,MHz,305010,-0.1248,51.5006
+0000003/1,18,GHz,301010,-3.1883,55.9533
+0000004/1,18,GHz,301010,,
+`)
+	bins, err := collection.GeohashBins(5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bins) != 2 {
+		t.Fatalf("got %d bins %+v", len(bins), bins)
+	}
+	london := bins[0]
+	if london.Geohash != "gcpuv" || london.Count != 3 || london.Licences != 2 ||
+		london.MinFrequencyMHz != 450 || london.MaxFrequencyMHz != 7700 || london.Frequencies != 3 {
+		t.Errorf("wrong bin %+v", london)
+	}
+
+	bins, err = collection.GeohashBins(5, KeyProductCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bins) != 3 || bins[0].Key != "301010" || bins[2].Key != "305010" || bins[2].Count != 1 {
+		t.Errorf("wrong grouped bins %+v", bins)
+	}
+
+	if _, err := collection.GeohashBins(13, nil); err == nil {
+		t.Error("precision 13 accepted")
+	}
+}
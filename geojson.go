@@ -0,0 +1,198 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+)
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// WriteGeoJSON writes the collection as a GeoJSON FeatureCollection. Every
+// Row is a Point feature located by its WGS84 columns (a null geometry if
+// they are absent) with the header columns as string properties.
+func (collection *Collection) WriteGeoJSON(writer io.Writer) error {
+	_, err := collection.WriteGeoJSONWithOptions(writer, nil)
+	return err
+}
+
+// WriteGeoJSONWithOptions is as WriteGeoJSON with a policy for rows without
+// coordinates, returning the counts of rows affected. options may be nil.
+func (collection *Collection) WriteGeoJSONWithOptions(writer io.Writer, options *GeometryOptions) (*GeometryReport, error) {
+	located, report := collection.locate(options, true)
+	w := bufio.NewWriter(writer)
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return nil, errors.Wrap(err, "could not write GeoJSON")
+	}
+
+	encoder := newRowEncoder(collection.Header, collection.computed)
+	for i, row := range located.Rows {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return nil, errors.Wrap(err, "could not write GeoJSON")
+			}
+		}
+		b, err := json.Marshal(row.geoJSONFeature(collection.Header, encoder))
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not encode licence %s", row.LicenceNumber)
+		}
+		if _, err := w.Write(b); err != nil {
+			return nil, errors.Wrap(err, "could not write GeoJSON")
+		}
+	}
+
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return nil, errors.Wrap(err, "could not write GeoJSON")
+	}
+	return report, errors.Wrap(w.Flush(), "could not write GeoJSON")
+}
+
+// geoJSONFeature returns the feature of a row, with the properties of the
+// header encoded by encoder.
+func (row *Row) geoJSONFeature(header []string, encoder *rowEncoder) *geoJSONFeature {
+	record := encoder.encode(row)
+	properties := make(map[string]interface{}, len(header))
+	for j, heading := range header {
+		properties[heading] = record[j]
+	}
+
+	feature := geoJSONFeature{Type: "Feature", Properties: properties}
+	if row.Wgs84LongitudeAsString != "" && row.Wgs84LatitudeAsString != "" {
+		coordinates, _ := json.Marshal([]float64{row.Wgs84Longitude, row.Wgs84Latitude})
+		feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: coordinates}
+	}
+	return &feature
+}
+
+// ReadGeoJSON reads a GeoJSON FeatureCollection (as written by WriteGeoJSON
+// or edited in a GIS) back into a Collection. Feature properties are matched
+// to Row fields by the csv header names. A Point geometry is used for the
+// WGS84 columns where these are absent from the properties or disagree with
+// them, so that features moved in the GIS keep their new location.
+func ReadGeoJSON(reader io.Reader) (*Collection, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber() // keep numerical properties verbatim
+
+	var featureCollection geoJSONFeatureCollection
+	if err := decoder.Decode(&featureCollection); err != nil {
+		return nil, errors.Wrap(err, "could not decode GeoJSON")
+	}
+	if featureCollection.Type != "FeatureCollection" {
+		return nil, errors.Errorf("unexpected GeoJSON type: \"%s\"", featureCollection.Type)
+	}
+
+	present := make(map[string]bool)
+	collection := Collection{Rows: make([]*Row, len(featureCollection.Features))}
+	for i, feature := range featureCollection.Features {
+		columns := make(map[string]string, len(feature.Properties)+2)
+		for name, value := range feature.Properties {
+			s, err := geoJSONPropertyString(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "feature %d: property \"%s\"", i, name)
+			}
+			columns[name] = s
+		}
+
+		if err := applyGeoJSONGeometry(feature.Geometry, columns); err != nil {
+			return nil, errors.Wrapf(err, "feature %d", i)
+		}
+
+		row, err := parseRow(columns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "feature %d", i)
+		}
+		collection.Rows[i] = row
+
+		for name := range columns {
+			present[name] = true
+		}
+	}
+
+	collection.Header = orderHeader(present)
+	return &collection, nil
+}
+
+// applyGeoJSONGeometry sets the WGS84 columns from a Point geometry.
+func applyGeoJSONGeometry(geometry *geoJSONGeometry, columns map[string]string) error {
+	if geometry == nil || geometry.Type != "Point" {
+		return nil
+	}
+	var coordinates []json.Number
+	if err := json.Unmarshal(geometry.Coordinates, &coordinates); err != nil {
+		return errors.Wrap(err, "could not decode Point coordinates")
+	}
+	if len(coordinates) < 2 {
+		return errors.New("Point has fewer than two coordinates")
+	}
+
+	for i, heading := range []string{HeadingWgs84Longitude, HeadingWgs84Latitude} {
+		coordinate, err := coordinates[i].Float64()
+		if err != nil {
+			return errors.Wrap(err, "could not convert Point coordinate")
+		}
+		if s, ok := columns[heading]; ok {
+			if value, err := strconv.ParseFloat(s, 64); err == nil && value == coordinate {
+				continue // properties agree with the geometry
+			}
+		}
+		columns[heading] = coordinates[i].String()
+	}
+	return nil
+}
+
+func geoJSONPropertyString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// orderHeader returns the present headings with the OFCOM columns first in
+// their standard order, then the munged columns, then any others sorted.
+func orderHeader(present map[string]bool) []string {
+	header := make([]string, 0, len(present))
+	known := make(map[string]bool)
+	for _, headings := range [][]string{standardHeader, mungedHeader} {
+		for _, heading := range headings {
+			known[heading] = true
+			if present[heading] {
+				header = append(header, heading)
+			}
+		}
+	}
+
+	var others []string
+	for heading := range present {
+		if !known[heading] {
+			others = append(others, heading)
+		}
+	}
+	sort.Strings(others)
+	return append(header, others...)
+}
@@ -0,0 +1,60 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGeoJSON(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Licencee Company,WGS84 Longitude,WGS84 Latitude
+0000001/1,7.5,"Company, One",-0.138800,51.521500
+0000002/1,13.0,Company Two,,
+`)
+	// Row 2 has no location.
+	b := new(bytes.Buffer)
+	if err := collection.WriteGeoJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"geometry":null`) {
+		t.Fatal("missing location should have null geometry")
+	}
+
+	collection2, err := ReadGeoJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(collection2.Header, ",") != strings.Join(collection.Header, ",") {
+		t.Fatalf("header not round-tripped: %v", collection2.Header)
+	}
+	if len(collection2.Rows) != 2 {
+		t.Fatalf("wrong number of rows: %v", len(collection2.Rows))
+	}
+	row := collection2.Rows[0]
+	if row.LicenseeCompany != "Company, One" || row.Wgs84LatitudeAsString != "51.521500" {
+		t.Fatalf("row not round-tripped: %+v", row)
+	}
+}
+
+func TestReadGeoJSONEdited(t *testing.T) {
+	// As edited in a GIS: numeric properties, moved geometry.
+	const text = `{"type":"FeatureCollection","features":[
+{"type":"Feature","geometry":{"type":"Point","coordinates":[-1.5,53.25]},
+ "properties":{"Licence Number":"0000001/1","Frequency":7.5,"WGS84 Longitude":"-0.1388","WGS84 Latitude":"51.5215"}}]}`
+
+	collection, err := ReadGeoJSON(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := collection.Rows[0]
+	if row.Frequency != "7.5" {
+		t.Fatalf("numeric property: %q", row.Frequency)
+	}
+	if row.Wgs84Longitude != -1.5 || row.Wgs84Latitude != 53.25 {
+		t.Fatalf("geometry not applied: %v, %v", row.Wgs84Longitude, row.Wgs84Latitude)
+	}
+
+	if _, err := ReadGeoJSON(strings.NewReader(`{"type":"Feature"}`)); err == nil {
+		t.Fatal("expected error for non FeatureCollection")
+	}
+}
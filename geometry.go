@@ -0,0 +1,126 @@
+package wtrcsv
+
+import (
+	"strconv"
+)
+
+// MissingCoordinates is what a geometry exporter does with a row without
+// WGS84 coordinates.
+type MissingCoordinates int
+
+const (
+	// MissingEmpty writes the row without a geometry (eg. a GeoJSON null
+	// geometry, a null shape or a NULL column). Exporters that cannot
+	// write a row without a geometry (the Leaflet map) skip it.
+	MissingEmpty MissingCoordinates = iota
+	// MissingSkip leaves the row out.
+	MissingSkip
+	// MissingCentroid locates the row at the centroid of its licence area,
+	// or writes it as MissingEmpty if there is none.
+	MissingCentroid
+)
+
+// GeometryOptions are the options common to the geometry exporters.
+type GeometryOptions struct {
+	Missing MissingCoordinates
+	// Centroid returns the centroid of the licence area of a row for
+	// MissingCentroid. By default it is the mean position of the rows of
+	// the same licence number that have coordinates.
+	Centroid func(row *Row) (longitude, latitude float64, ok bool)
+}
+
+// GeometryReport counts the rows written by a geometry exporter.
+type GeometryReport struct {
+	Rows        int // rows written
+	Located     int // rows located by their own coordinates
+	Substituted int // rows located by a licence area centroid
+	Empty       int // rows written without a geometry
+	Skipped     int // rows left out
+	// Warnings are the rows substituted, empty or skipped and any values
+	// or names changed to fit the format.
+	Warnings []Warning
+}
+
+// licenceCentroids returns a Centroid func giving the mean position of the
+// located rows of each licence number.
+func (collection *Collection) licenceCentroids() func(row *Row) (float64, float64, bool) {
+	type sum struct {
+		longitude, latitude float64
+		n                   int
+	}
+	sums := make(map[string]*sum)
+	for _, row := range collection.Rows {
+		if !row.hasWgs84() {
+			continue
+		}
+		s, ok := sums[row.LicenceNumber]
+		if !ok {
+			s = &sum{}
+			sums[row.LicenceNumber] = s
+		}
+		s.longitude += row.Wgs84Longitude
+		s.latitude += row.Wgs84Latitude
+		s.n++
+	}
+	return func(row *Row) (float64, float64, bool) {
+		s, ok := sums[row.LicenceNumber]
+		if !ok {
+			return 0, 0, false
+		}
+		return s.longitude / float64(s.n), s.latitude / float64(s.n), true
+	}
+}
+
+// locate applies the missing coordinates policy, returning the rows to
+// write and the report. Substituted rows are copies with WGS84 coordinates;
+// the collection is unchanged. If empty is false, rows that would be
+// written without a geometry are skipped.
+func (collection *Collection) locate(options *GeometryOptions, empty bool) (*Collection, *GeometryReport) {
+	policy := MissingEmpty
+	var centroid func(row *Row) (float64, float64, bool)
+	if options != nil {
+		policy = options.Missing
+		centroid = options.Centroid
+	}
+	if policy == MissingCentroid && centroid == nil {
+		centroid = collection.licenceCentroids()
+	}
+
+	report := &GeometryReport{}
+	var warnings warningList
+	located := collection.withRows(make([]*Row, 0, len(collection.Rows)))
+	for i, row := range collection.Rows {
+		switch {
+		case row.hasWgs84():
+			report.Located++
+		case policy == MissingSkip:
+			report.Skipped++
+			warnings.addRow(WarningRowSkipped, i, row, "", "no coordinates")
+			continue
+		case policy == MissingCentroid:
+			if longitude, latitude, ok := centroid(row); ok {
+				substitute := *row
+				substitute.Wgs84Longitude, substitute.Wgs84Latitude = longitude, latitude
+				substitute.Wgs84LongitudeAsString = strconv.FormatFloat(longitude, 'f', 6, 64)
+				substitute.Wgs84LatitudeAsString = strconv.FormatFloat(latitude, 'f', 6, 64)
+				row = &substitute
+				report.Substituted++
+				warnings.addRow(WarningGeometrySubstituted, i, row, "", "located by licence area centroid")
+				break
+			}
+			fallthrough
+		default:
+			if !empty {
+				report.Skipped++
+				warnings.addRow(WarningRowSkipped, i, row, "", "no coordinates")
+				continue
+			}
+			report.Empty++
+			warnings.addRow(WarningGeometryMissing, i, row, "", "no coordinates")
+		}
+		located.Rows = append(located.Rows, row)
+	}
+	report.Rows = len(located.Rows)
+	report.Warnings = warnings.result()
+	return located, report
+}
@@ -0,0 +1,66 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const geometryCSV = `Licence Number,WGS84 Longitude,WGS84 Latitude
+0000001/1,-1.0,51.0
+0000001/1,-3.0,53.0
+0000001/1,,
+0000002/1,,
+`
+
+func TestLocate(t *testing.T) {
+	collection := testCollection(t, geometryCSV)
+	for _, test := range []struct {
+		options *GeometryOptions
+		empty   bool
+		want    GeometryReport
+	}{
+		{nil, true, GeometryReport{Rows: 4, Located: 2, Empty: 2}},
+		{nil, false, GeometryReport{Rows: 2, Located: 2, Skipped: 2}},
+		{&GeometryOptions{Missing: MissingSkip}, true, GeometryReport{Rows: 2, Located: 2, Skipped: 2}},
+		{&GeometryOptions{Missing: MissingCentroid}, true, GeometryReport{Rows: 4, Located: 2, Substituted: 1, Empty: 1}},
+		{&GeometryOptions{Missing: MissingCentroid}, false, GeometryReport{Rows: 3, Located: 2, Substituted: 1, Skipped: 1}},
+		{&GeometryOptions{Missing: MissingCentroid, Centroid: func(*Row) (float64, float64, bool) {
+			return 0, 52, true
+		}}, true, GeometryReport{Rows: 4, Located: 2, Substituted: 2}},
+	} {
+		_, report := collection.locate(test.options, test.empty)
+		if n := len(report.Warnings); n != report.Substituted+report.Empty+report.Skipped {
+			t.Errorf("%+v, %v: %d warnings", test.options, test.empty, n)
+		}
+		report.Warnings = nil
+		if !reflect.DeepEqual(*report, test.want) {
+			t.Errorf("%+v, %v: got %+v, want %+v", test.options, test.empty, report, test.want)
+		}
+	}
+
+	located, _ := collection.locate(&GeometryOptions{Missing: MissingCentroid}, true)
+	if row := located.Rows[2]; row.Wgs84LongitudeAsString != "-2.000000" || row.Wgs84Latitude != 52 {
+		t.Errorf("wrong centroid %+v", row)
+	}
+	if collection.Rows[2].hasWgs84() {
+		t.Error("collection modified")
+	}
+}
+
+func TestWriteGeoJSONWithOptions(t *testing.T) {
+	collection := testCollection(t, geometryCSV)
+	b := new(bytes.Buffer)
+	report, err := collection.WriteGeoJSONWithOptions(b, &GeometryOptions{Missing: MissingSkip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fc geoJSONFeatureCollection
+	if err := json.Unmarshal(b.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 2 || report.Skipped != 2 {
+		t.Fatalf("%d features, report %+v", len(fc.Features), report)
+	}
+}
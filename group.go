@@ -0,0 +1,33 @@
+package wtrcsv
+
+// GroupBy returns the rows of the collection grouped by key, each group a
+// Collection with the same header and the rows in collection order.
+func (collection *Collection) GroupBy(keyFn KeyFn) map[string]*Collection {
+	groups := make(map[string]*Collection)
+	for _, row := range collection.Rows {
+		key := keyFn(row)
+		group, ok := groups[key]
+		if !ok {
+			group = collection.withRows(nil)
+			groups[key] = group
+		}
+		group.Rows = append(group.Rows, row)
+	}
+	return groups
+}
+
+// GroupByCompany groups the rows by licensee company (see KeyCompany).
+func (collection *Collection) GroupByCompany() map[string]*Collection {
+	return collection.GroupBy(KeyCompany)
+}
+
+// GroupByProductCode groups the rows by numerical product code (see
+// KeyProductCode).
+func (collection *Collection) GroupByProductCode() map[string]*Collection {
+	return collection.GroupBy(KeyProductCode)
+}
+
+// GroupByLicenceNumber groups the rows by licence number.
+func (collection *Collection) GroupByLicenceNumber() map[string]*Collection {
+	return collection.GroupBy(KeyLicenceNumber)
+}
@@ -0,0 +1,30 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Product Description 31
+0000001/1,Acme,301010
+0000002/1,Other,305010
+0000001/1,Acme,301010
+0000003/1,Acme,305010
+`)
+	companies := collection.GroupByCompany()
+	if len(companies) != 2 || licenceNumbers(companies["Acme"]) != "0000001/1 0000001/1 0000003/1" {
+		t.Fatalf("wrong groups %v", companies)
+	}
+	if len(companies["Other"].Header) != 3 {
+		t.Error("header not kept")
+	}
+	if codes := collection.GroupByProductCode(); licenceNumbers(codes["305010"]) != "0000002/1 0000003/1" {
+		t.Errorf("wrong product code group %v", codes)
+	}
+	if licences := collection.GroupByLicenceNumber(); len(licences) != 3 || len(licences["0000001/1"].Rows) != 2 {
+		t.Errorf("wrong licence groups %v", licences)
+	}
+	if nations := collection.GroupBy(KeyNation); len(nations) != 1 || len(nations[""].Rows) != 4 {
+		t.Errorf("wrong nation groups %v", nations)
+	}
+}
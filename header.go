@@ -0,0 +1,79 @@
+package wtrcsv
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// HeaderAliases maps csv headings, as normalised by aliasKey, to the
+// canonical headings of Row (see Headings), eg. for columns OFCOM has
+// renamed between releases. A heading that differs from a canonical heading
+// only in case or spacing needs no alias. See LoadHeaderAliases.
+var HeaderAliases = map[string]string{}
+
+// LoadHeaderAliases adds the aliases of a two column csv (alias, canonical
+// heading) to HeaderAliases. A header row is not expected.
+func LoadHeaderAliases(reader io.Reader) error {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "could not read header aliases")
+	}
+	for _, record := range records {
+		heading := strings.TrimSpace(record[1])
+		if !isHeading(heading) {
+			return errors.Errorf("alias %q of unknown column %q", record[0], heading)
+		}
+		HeaderAliases[aliasKey(record[0])] = heading
+	}
+	return nil
+}
+
+// headingKeys maps the canonical headings, as normalised by aliasKey, to
+// themselves.
+var headingKeys = func() map[string]string {
+	keys := make(map[string]string)
+	for _, heading := range Headings() {
+		keys[aliasKey(heading)] = heading
+	}
+	return keys
+}()
+
+// canonicalHeading returns the canonical heading of a csv heading, or the
+// heading unchanged if it is not a known column.
+func canonicalHeading(heading string) string {
+	if isHeading(heading) {
+		return heading
+	}
+	key := aliasKey(heading)
+	if canonical, ok := HeaderAliases[key]; ok {
+		return canonical
+	}
+	if canonical, ok := headingKeys[key]; ok {
+		return canonical
+	}
+	return heading
+}
+
+// canonicalHeader returns a copy of a csv header with its headings mapped
+// to their canonical headings. A heading is left unchanged if its canonical
+// heading is already in the header, so no column is lost.
+func canonicalHeader(header []string) []string {
+	present := make(map[string]bool, len(header))
+	for _, heading := range header {
+		present[heading] = true
+	}
+	canonical := make([]string, len(header))
+	for i, heading := range header {
+		canonical[i] = heading
+		if c := canonicalHeading(heading); c != heading && !present[c] {
+			canonical[i] = c
+			present[c] = true
+		}
+	}
+	return canonical
+}
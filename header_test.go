@@ -0,0 +1,32 @@
+package wtrcsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeaderAliases(t *testing.T) {
+	defer func() { HeaderAliases = map[string]string{} }()
+	if err := LoadHeaderAliases(strings.NewReader("Licensee Company, Licencee Company\nFreq,Frequency\n")); err != nil {
+		t.Fatal(err)
+	}
+	collection := testCollection(t, `LICENCE NUMBER,freq,Licensee Company,Antenna  Height,Notes
+0000001/1,7.5,Acme,10,x
+`)
+	want := []string{"Licence Number", "Frequency", "Licencee Company", "Antenna Height", "Notes"}
+	if strings.Join(collection.Header, "|") != strings.Join(want, "|") {
+		t.Errorf("got header %q", collection.Header)
+	}
+	row := collection.Rows[0]
+	if row.LicenceNumber != "0000001/1" || row.Frequency != "7.5" || row.LicenseeCompany != "Acme" || row.AntennaHeight != "10" {
+		t.Errorf("wrong row %+v", row)
+	}
+
+	// A canonical heading already present is not duplicated.
+	if got := canonicalHeader([]string{"ngr", "NGR"}); got[0] != "ngr" || got[1] != "NGR" {
+		t.Errorf("got %q", got)
+	}
+	if err := LoadHeaderAliases(strings.NewReader("a,No Such Column\n")); err == nil {
+		t.Error("no error for an unknown column")
+	}
+}
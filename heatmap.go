@@ -0,0 +1,143 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// The extent of the British National Grid, in metres.
+const (
+	gridWidth  = 700000
+	gridHeight = 1300000
+)
+
+// HeatmapOptions configures WriteHeatmapPNG.
+type HeatmapOptions struct {
+	CellSize int // metres, default 5000
+	Scale    int // pixels per cell, default 1
+	// Ramp are the colours from the fewest to the most licences in a cell.
+	// Empty cells are transparent. The default is yellow to red.
+	Ramp []color.Color
+	// Linear scales the colours by the count rather than its logarithm.
+	Linear bool
+}
+
+// defaultRamp is ColorBrewer's YlOrRd.
+var defaultRamp = []color.Color{
+	color.NRGBA{0xff, 0xff, 0xb2, 0xff},
+	color.NRGBA{0xfe, 0xcc, 0x5c, 0xff},
+	color.NRGBA{0xfd, 0x8d, 0x3c, 0xff},
+	color.NRGBA{0xf0, 0x3b, 0x20, 0xff},
+	color.NRGBA{0xbd, 0x00, 0x26, 0xff},
+}
+
+// rampColour interpolates a ramp at t in [0, 1].
+func rampColour(ramp []color.Color, t float64) color.NRGBA {
+	if len(ramp) == 1 {
+		return color.NRGBAModel.Convert(ramp[0]).(color.NRGBA)
+	}
+	t = math.Max(0, math.Min(1, t)) * float64(len(ramp)-1)
+	i := int(t)
+	if i == len(ramp)-1 {
+		i--
+	}
+	f := t - float64(i)
+	a := color.NRGBAModel.Convert(ramp[i]).(color.NRGBA)
+	b := color.NRGBAModel.Convert(ramp[i+1]).(color.NRGBA)
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*f))
+	}
+	return color.NRGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// heatmapGrid counts the rows in each cell of the British National Grid,
+// from the north west. Rows are located by rowOS.
+func (collection *Collection) heatmapGrid(cellSize int) ([][]int, *GeometryReport) {
+	columns := (gridWidth + cellSize - 1) / cellSize
+	rows := (gridHeight + cellSize - 1) / cellSize
+	grid := make([][]int, rows)
+	for i := range grid {
+		grid[i] = make([]int, columns)
+	}
+
+	report := &GeometryReport{}
+	var warnings warningList
+	for i, row := range collection.Rows {
+		e, n, ok := rowOS(row)
+		if !ok || e < 0 || e >= gridWidth || n < 0 || n >= gridHeight {
+			report.Skipped++
+			message := "no National Grid position"
+			if ok {
+				message = "outside the National Grid"
+			}
+			warnings.addRow(WarningRowSkipped, i, row, "", message)
+			continue
+		}
+		grid[rows-1-n/cellSize][e/cellSize]++
+		report.Located++
+	}
+	report.Rows = report.Located
+	report.Warnings = warnings.result()
+	return grid, report
+}
+
+// WriteHeatmapPNG writes a PNG of the density of licences on the British
+// National Grid (0-700km east, 0-1300km north), located by the OS columns
+// or else the NGR. It returns the counts of rows without a location, which
+// are left out. options may be nil.
+func (collection *Collection) WriteHeatmapPNG(writer io.Writer, options *HeatmapOptions) (*GeometryReport, error) {
+	cellSize, scale, ramp, linear := 5000, 1, defaultRamp, false
+	if options != nil {
+		if options.CellSize > 0 {
+			cellSize = options.CellSize
+		}
+		if options.Scale > 0 {
+			scale = options.Scale
+		}
+		if len(options.Ramp) > 0 {
+			ramp = options.Ramp
+		}
+		linear = options.Linear
+	}
+
+	grid, report := collection.heatmapGrid(cellSize)
+	max := 0
+	for _, cells := range grid {
+		for _, count := range cells {
+			if count > max {
+				max = count
+			}
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, len(grid[0])*scale, len(grid)*scale))
+	for y, cells := range grid {
+		for x, count := range cells {
+			if count == 0 {
+				continue
+			}
+			t := 1.0
+			switch {
+			case max == 1:
+			case linear:
+				t = float64(count-1) / float64(max-1)
+			default:
+				t = math.Log(float64(count)) / math.Log(float64(max))
+			}
+			c := rampColour(ramp, t)
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetNRGBA(x*scale+dx, y*scale+dy, c)
+				}
+			}
+		}
+	}
+	if err := png.Encode(writer, img); err != nil {
+		return nil, errors.Wrap(err, "could not write PNG")
+	}
+	return report, nil
+}
@@ -0,0 +1,57 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWriteHeatmapPNG(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR,OS Easting,OS Northing
+0000001/1,TQ 29400 81900,,
+0000002/1,TQ 29000 81000,,
+0000003/1,,325700,673500
+0000004/1,nonsense,,
+`)
+	b := new(bytes.Buffer)
+	ramp := []color.Color{color.Black, color.White}
+	report, err := collection.WriteHeatmapPNG(b, &HeatmapOptions{CellSize: 10000, Scale: 2, Ramp: ramp, Linear: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 3 || report.Located != 3 || report.Skipped != 1 || len(report.Warnings) != 1 {
+		t.Errorf("wrong report %+v", report)
+	}
+
+	img, err := png.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 140 || size.Y != 260 {
+		t.Fatalf("wrong size %v", size)
+	}
+	// TQ 29 81 is cell (52, 18) from the bottom, (52, 111) from the top.
+	for _, test := range []struct {
+		x, y int
+		want color.Color
+	}{
+		{52*2 + 1, 111*2 + 1, color.NRGBA{0xff, 0xff, 0xff, 0xff}}, // 2 licences
+		{32 * 2, 62 * 2, color.NRGBA{0, 0, 0, 0xff}},               // 1 licence
+		{0, 0, color.NRGBA{}},                                      // none
+	} {
+		if got := color.NRGBAModel.Convert(img.At(test.x, test.y)); got != test.want {
+			t.Errorf("(%d, %d): got %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+}
+
+func TestRampColour(t *testing.T) {
+	ramp := []color.Color{color.NRGBA{0, 0, 0, 255}, color.NRGBA{200, 100, 0, 255}}
+	if got := rampColour(ramp, 0.5); got != (color.NRGBA{100, 50, 0, 255}) {
+		t.Errorf("got %v", got)
+	}
+	if got := rampColour(defaultRamp, 1); got != defaultRamp[4] {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,191 @@
+package wtrcsv
+
+import (
+	"sort"
+	"strings"
+)
+
+// Index is maintained incrementally by UpdateIndexes.
+type Index interface {
+	Add(row *Row)
+	Remove(row *Row)
+}
+
+// KeyIndex is an exact index of rows by a key, optionally with a
+// BloomFilter of the keys checked before the exact lookup.
+type KeyIndex struct {
+	keyFn KeyFn
+	rows  map[string][]*Row
+	bloom *BloomFilter // nil for none
+}
+
+// KeyIndexOptions are the options of BuildKeyIndexWithOptions.
+type KeyIndexOptions struct {
+	// BloomFalsePositiveRate, if not 0, builds a BloomFilter of the keys
+	// with the false positive rate (eg. 0.01) so that Get returns at once
+	// for most keys not in the index. Rows removed leave their keys in the
+	// filter, and rows added increase its false positive rate.
+	BloomFalsePositiveRate float64
+}
+
+// NewKeyIndex returns an empty KeyIndex.
+func NewKeyIndex(keyFn KeyFn) *KeyIndex {
+	return &KeyIndex{keyFn: keyFn, rows: make(map[string][]*Row)}
+}
+
+// BuildKeyIndex returns a KeyIndex of every Row.
+func (collection *Collection) BuildKeyIndex(keyFn KeyFn) *KeyIndex {
+	return collection.BuildKeyIndexWithOptions(keyFn, nil)
+}
+
+// BuildKeyIndexWithOptions is as BuildKeyIndex with options, which may be
+// nil.
+func (collection *Collection) BuildKeyIndexWithOptions(keyFn KeyFn, options *KeyIndexOptions) *KeyIndex {
+	index := NewKeyIndex(keyFn)
+	if options != nil && options.BloomFalsePositiveRate != 0 {
+		index.bloom = NewBloomFilter(len(collection.Rows), options.BloomFalsePositiveRate)
+	}
+	for _, row := range collection.Rows {
+		index.Add(row)
+	}
+	return index
+}
+
+// Add implements Index.
+func (index *KeyIndex) Add(row *Row) {
+	key := index.keyFn(row)
+	index.rows[key] = append(index.rows[key], row)
+	if index.bloom != nil {
+		index.bloom.Add(key)
+	}
+}
+
+// Remove implements Index.
+func (index *KeyIndex) Remove(row *Row) {
+	key := index.keyFn(row)
+	rows := index.rows[key]
+	for i := range rows {
+		if rows[i] == row {
+			rows = append(rows[:i], rows[i+1:]...)
+			break
+		}
+	}
+	if len(rows) == 0 {
+		delete(index.rows, key)
+	} else {
+		index.rows[key] = rows
+	}
+}
+
+// Get returns the rows with the key, in the order they were added.
+func (index *KeyIndex) Get(key string) []*Row {
+	if index.bloom != nil && !index.bloom.MayContain(key) {
+		return nil
+	}
+	return index.rows[key]
+}
+
+// Len returns the number of distinct keys.
+func (index *KeyIndex) Len() int {
+	return len(index.rows)
+}
+
+// Keys returns the distinct keys, sorted.
+func (index *KeyIndex) Keys() []string {
+	keys := make([]string, 0, len(index.rows))
+	for key := range index.rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LicenceIndex is a KeyIndex of rows by licence number, ignoring case and
+// surrounding spaces.
+type LicenceIndex struct {
+	*KeyIndex
+}
+
+func normaliseLicenceNumber(licenceNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(licenceNumber))
+}
+
+func licenceKey(row *Row) string {
+	return normaliseLicenceNumber(row.LicenceNumber)
+}
+
+// licenceBloomFalsePositiveRate is the false positive rate of the
+// BloomFilter of a LicenceIndex.
+const licenceBloomFalsePositiveRate = 0.01
+
+// IndexByLicenceNumber returns a LicenceIndex of every Row, with a
+// BloomFilter of the licence numbers. A licence typically has many rows,
+// one per station and frequency.
+func (collection *Collection) IndexByLicenceNumber() *LicenceIndex {
+	options := &KeyIndexOptions{BloomFalsePositiveRate: licenceBloomFalsePositiveRate}
+	return &LicenceIndex{collection.BuildKeyIndexWithOptions(licenceKey, options)}
+}
+
+// GetByLicenceNumber returns the rows of a licence in the order they were
+// added, or nil if there are none. Most licence numbers not in the index
+// are rejected by its BloomFilter without the exact lookup.
+func (index *LicenceIndex) GetByLicenceNumber(licenceNumber string) []*Row {
+	key := normaliseLicenceNumber(licenceNumber)
+	if index.bloom != nil && !index.bloom.MayContain(key) {
+		return nil
+	}
+	return index.rows[key]
+}
+
+// allHeadings are the columns of a Row, for complete comparisons.
+var allHeadings = Headings()
+
+// UpdateIndexes swaps in the next snapshot of the register for current,
+// updating the indexes (which must have been built from current) by
+// removing the rows no longer present and adding the rows that are new,
+// rather than rebuilding them. Rows are compared by all of their columns.
+//
+// The returned Collection has the rows of next, in its order and with its
+// header, but unchanged rows are current's Row pointers so that they remain
+// the ones referenced by the indexes. It is returned with the number of
+// rows added to and removed from the indexes.
+func UpdateIndexes(current, next *Collection, indexes ...Index) (updated *Collection, added, removed int) {
+	unchanged := make(map[string][]*Row, len(current.Rows))
+	for _, row := range current.Rows {
+		key := recordKey(row.toRecord(allHeadings))
+		unchanged[key] = append(unchanged[key], row)
+	}
+
+	updated = next.withRows(make([]*Row, len(next.Rows)))
+	var additions []*Row
+	for i, row := range next.Rows {
+		key := recordKey(row.toRecord(allHeadings))
+		if rows := unchanged[key]; len(rows) > 0 {
+			updated.Rows[i] = rows[0]
+			unchanged[key] = rows[1:]
+			continue
+		}
+		updated.Rows[i] = row
+		additions = append(additions, row)
+	}
+
+	// Whatever remains in unchanged has gone.
+	for _, rows := range unchanged {
+		for _, row := range rows {
+			for _, index := range indexes {
+				index.Remove(row)
+			}
+			removed++
+		}
+	}
+	for _, row := range additions {
+		for _, index := range indexes {
+			index.Add(row)
+		}
+	}
+	return updated, len(additions), removed
+}
+
+func recordKey(record []string) string {
+	return strings.Join(record, "\x1f")
+}
@@ -0,0 +1,78 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestUpdateIndexes(t *testing.T) {
+	const header = "Licence Number,Frequency\n"
+	current := testCollection(t, header+`0000001/1,7.5
+0000001/1,7.6
+0000002/1,13.0
+`)
+	next := testCollection(t, header+`0000003/1,18.0
+0000002/1,13.0
+0000001/1,7.6
+`)
+	index := current.BuildKeyIndex(KeyLicenceNumber)
+
+	updated, added, removed := UpdateIndexes(current, next, index)
+	if added != 1 || removed != 1 {
+		t.Fatalf("wrong counts: added %v, removed %v", added, removed)
+	}
+	if updated.Rows[1] != current.Rows[2] || updated.Rows[0] != next.Rows[0] {
+		t.Fatal("unchanged rows should be the current rows")
+	}
+	if rows := index.Get("0000001/1"); len(rows) != 1 || rows[0].Frequency != "7.6" {
+		t.Fatalf("wrong index after update: %v", rows)
+	}
+	if rows := index.Get("0000003/1"); len(rows) != 1 || rows[0] != next.Rows[0] {
+		t.Fatal("addition not indexed")
+	}
+
+	// The updated index is the same as one rebuilt.
+	rebuilt := updated.BuildKeyIndex(KeyLicenceNumber)
+	if rebuilt.Len() != index.Len() {
+		t.Fatalf("index differs from rebuilt: %v, %v", index.Len(), rebuilt.Len())
+	}
+	for key, rows := range rebuilt.rows {
+		got := index.Get(key)
+		if len(got) != len(rows) {
+			t.Fatalf("%v: index differs from rebuilt", key)
+		}
+	}
+}
+
+func TestIndexByLicenceNumber(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency
+0000001/1,7.5
+es0000002/1,13.0
+0000001/1,7.6
+`)
+	index := collection.IndexByLicenceNumber()
+	if rows := index.GetByLicenceNumber(" 0000001/1"); len(rows) != 2 || rows[1].Frequency != "7.6" {
+		t.Fatalf("wrong rows %v", rows)
+	}
+	if rows := index.GetByLicenceNumber("ES0000002/1"); len(rows) != 1 {
+		t.Fatalf("case not ignored: %v", rows)
+	}
+	if rows := index.GetByLicenceNumber("0000003/1"); rows != nil {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+	if keys := index.Keys(); len(keys) != 2 || keys[1] != "ES0000002/1" {
+		t.Fatalf("wrong keys %v", keys)
+	}
+
+	// A licence number missing from the bloom filter is not looked up.
+	if index.bloom == nil {
+		t.Fatal("no bloom filter")
+	}
+	index.rows["0000004/1"] = collection.Rows[:1]
+	if rows := index.GetByLicenceNumber("0000004/1"); rows != nil {
+		t.Fatal("licence number not in the bloom filter looked up")
+	}
+	delete(index.rows, "0000004/1")
+
+	// LicenceIndex is maintained by UpdateIndexes.
+	var _ Index = index
+}
@@ -0,0 +1,110 @@
+// Package protowire encodes and decodes the Protocol Buffers wire format,
+// for the hand-written messages of wtr.proto.
+package protowire
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"math"
+)
+
+// Wire types.
+const (
+	Varint  = 0
+	Fixed64 = 1
+	Bytes   = 2
+	Fixed32 = 5
+)
+
+// AppendVarint appends a varint.
+func AppendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+// AppendTag appends a field tag.
+func AppendTag(b []byte, field, wire int) []byte {
+	return AppendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+// AppendString appends a string (or bytes, or message) field.
+func AppendString(b []byte, field int, s string) []byte {
+	b = AppendTag(b, field, Bytes)
+	b = AppendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// AppendInt64 appends an int64 (or int32, uint32, bool) field.
+func AppendInt64(b []byte, field int, v int64) []byte {
+	b = AppendTag(b, field, Varint)
+	return AppendVarint(b, uint64(v))
+}
+
+// AppendDouble appends a double field.
+func AppendDouble(b []byte, field int, v float64) []byte {
+	b = AppendTag(b, field, Fixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+	return append(b, buf[:]...)
+}
+
+// Field is a decoded field of a message. Only the value of its wire type
+// is set.
+type Field struct {
+	Number  int
+	Wire    int
+	Varint  uint64
+	Fixed64 uint64
+	Fixed32 uint32
+	Bytes   []byte
+}
+
+// Double returns the value of a double field.
+func (field *Field) Double() float64 {
+	return math.Float64frombits(field.Fixed64)
+}
+
+// Fields calls fn for each field of a message, in order.
+func Fields(b []byte, fn func(field *Field) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("bad field tag")
+		}
+		b = b[n:]
+		field := Field{Number: int(tag >> 3), Wire: int(tag & 7)}
+		switch field.Wire {
+		case Varint:
+			if field.Varint, n = binary.Uvarint(b); n <= 0 {
+				return errors.New("bad varint")
+			}
+			b = b[n:]
+		case Fixed64:
+			if len(b) < 8 {
+				return errors.New("truncated fixed64")
+			}
+			field.Fixed64 = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case Fixed32:
+			if len(b) < 4 {
+				return errors.New("truncated fixed32")
+			}
+			field.Fixed32 = binary.LittleEndian.Uint32(b)
+			b = b[4:]
+		case Bytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < length {
+				return errors.New("truncated bytes")
+			}
+			field.Bytes = b[n : n+int(length)]
+			b = b[n+int(length):]
+		default:
+			return errors.Errorf("unsupported wire type %d", field.Wire)
+		}
+		if err := fn(&field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
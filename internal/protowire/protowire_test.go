@@ -0,0 +1,30 @@
+package protowire
+
+import "testing"
+
+func TestFields(t *testing.T) {
+	var b []byte
+	b = AppendString(b, 1, "hello")
+	b = AppendInt64(b, 2, -1)
+	b = AppendDouble(b, 3, 7.5)
+	b = append(AppendTag(b, 4, Fixed32), 1, 0, 0, 0)
+
+	var got []Field
+	if err := Fields(b, func(field *Field) error {
+		got = append(got, *field)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 ||
+		string(got[0].Bytes) != "hello" ||
+		int64(got[1].Varint) != -1 ||
+		got[2].Double() != 7.5 ||
+		got[3].Fixed32 != 1 {
+		t.Fatalf("wrong fields %+v", got)
+	}
+
+	if err := Fields(b[:len(b)-1], func(*Field) error { return nil }); err == nil {
+		t.Fatal("expected error for truncated message")
+	}
+}
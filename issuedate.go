@@ -0,0 +1,45 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// issueDateLayouts are the layouts of the licence issue date, the first
+// being that of the register.
+var issueDateLayouts = []string{"02/01/2006", "2006-01-02", "02-Jan-2006", "02/01/2006 15:04:05"}
+
+// IssueDate returns the licence issue date of the Row, in UTC. The
+// register's format is DD/MM/YYYY; ISO 8601 dates are also accepted.
+func (row *Row) IssueDate() (time.Time, error) {
+	s := strings.TrimSpace(row.LicenceIssueDate)
+	if s == "" {
+		return time.Time{}, errors.New("no licence issue date")
+	}
+	for _, layout := range issueDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("bad licence issue date %q", row.LicenceIssueDate)
+}
+
+// FilterIssuedAfter returns a FilterFn keeping the rows issued after t, eg.
+// FilterIssuedAfter(time.Now().AddDate(-1, 0, 0)) for the last 12 months.
+// Rows without a valid issue date are dropped.
+func FilterIssuedAfter(t time.Time) FilterFn {
+	return func(row *Row) bool {
+		date, err := row.IssueDate()
+		return err == nil && date.After(t)
+	}
+}
+
+// FilterIssuedBetween returns a FilterFn keeping the rows issued on or after
+// from and before to. Rows without a valid issue date are dropped.
+func FilterIssuedBetween(from, to time.Time) FilterFn {
+	return func(row *Row) bool {
+		date, err := row.IssueDate()
+		return err == nil && !date.Before(from) && date.Before(to)
+	}
+}
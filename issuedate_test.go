@@ -0,0 +1,43 @@
+package wtrcsv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssueDate(t *testing.T) {
+	for s, want := range map[string]time.Time{
+		"05/03/2010":          time.Date(2010, 3, 5, 0, 0, 0, 0, time.UTC),
+		" 2008-11-20 ":        time.Date(2008, 11, 20, 0, 0, 0, 0, time.UTC),
+		"01-Feb-2018":         time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC),
+		"01/02/2018 12:30:00": time.Date(2018, 2, 1, 12, 30, 0, 0, time.UTC),
+	} {
+		row := Row{LicenceIssueDate: s}
+		if got, err := row.IssueDate(); err != nil || !got.Equal(want) {
+			t.Errorf("%q: got %v (%v), want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "31/02/2010", "2010"} {
+		row := Row{LicenceIssueDate: s}
+		if _, err := row.IssueDate(); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestFilterIssued(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licence issue date
+0000001/1,05/03/2010
+0000002/1,31/12/2009
+0000003/1,
+0000004/1,01/01/2011
+`)
+	jan2010 := time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2011 := time.Date(2011, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := licenceNumbers(collection.Filter(FilterIssuedAfter(jan2010))); got != "0000001/1 0000004/1" {
+		t.Errorf("got %s", got)
+	}
+	if got := licenceNumbers(collection.Filter(FilterIssuedBetween(jan2010, jan2011))); got != "0000001/1" {
+		t.Errorf("got %s", got)
+	}
+}
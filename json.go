@@ -0,0 +1,48 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// WriteJSON writes the rows as a JSON array of objects. The object field
+// names are the snake_case json tags of Row, which are stable.
+func (collection *Collection) WriteJSON(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	if err := w.WriteByte('['); err != nil {
+		return errors.Wrap(err, "could not write JSON")
+	}
+	for i, row := range collection.Rows {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return errors.Wrap(err, "could not write JSON")
+			}
+		}
+		b, err := json.Marshal(row)
+		if err != nil {
+			return errors.Wrapf(err, "could not encode licence %s", row.LicenceNumber)
+		}
+		if _, err := w.Write(b); err != nil {
+			return errors.Wrap(err, "could not write JSON")
+		}
+	}
+	if _, err := w.WriteString("]\n"); err != nil {
+		return errors.Wrap(err, "could not write JSON")
+	}
+	return errors.Wrap(w.Flush(), "could not write JSON")
+}
+
+// WriteNDJSON writes the rows as newline delimited JSON, one object per line,
+// with the same field names as WriteJSON.
+func (collection *Collection) WriteNDJSON(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(w) // Encode appends a newline
+	for _, row := range collection.Rows {
+		if err := encoder.Encode(row); err != nil {
+			return errors.Wrapf(err, "could not write licence %s", row.LicenceNumber)
+		}
+	}
+	return errors.Wrap(w.Flush(), "could not write NDJSON")
+}
@@ -0,0 +1,44 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Product Description 31,WGS84 Longitude,WGS84 Latitude
+0000001/1,301010,-0.1388,51.5215
+0000002/1,305010,,
+`)
+	b := new(bytes.Buffer)
+	if err := collection.WriteJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &objects); err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("wrong number of objects: %v", len(objects))
+	}
+	if objects[0]["licence_number"] != "0000001/1" || objects[0]["product_description_31"] != "301010" {
+		t.Fatalf("unexpected object: %v", objects[0])
+	}
+	if objects[0]["wgs84_latitude"] != 51.5215 {
+		t.Fatalf("missing latitude: %v", objects[0])
+	}
+	if _, ok := objects[1]["wgs84_latitude"]; ok {
+		t.Fatal("absent latitude should be omitted")
+	}
+
+	b.Reset()
+	if err := collection.WriteNDJSON(b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"licence_number":"0000002/1"`) {
+		t.Fatalf("unexpected NDJSON: %v", b.String())
+	}
+}
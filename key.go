@@ -0,0 +1,79 @@
+package wtrcsv
+
+import (
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// KeyFn returns a key for a Row, eg. for membership checks.
+type KeyFn func(row *Row) string
+
+// KeyLicenceNumber keys a Row by its licence number.
+func KeyLicenceNumber(row *Row) string {
+	return row.LicenceNumber
+}
+
+// KeyFrequency keys a Row by its frequency, so that eg. 7.5 GHz and 7500
+// MHz are the same key, or failing that its frequency and frequency type.
+func KeyFrequency(row *Row) string {
+	if f, ok := row.FrequencyHz(); ok {
+		return strconv.FormatInt(f.Hz(), 10)
+	}
+	return row.Frequency + " " + row.FrequencyType
+}
+
+// KeyNGR keys a Row by its site, ie. its NGR without spaces.
+func KeyNGR(row *Row) string {
+	return strings.ToUpper(strings.Replace(row.NGR, " ", "", -1))
+}
+
+// KeyCompany keys a Row by its licensee company.
+func KeyCompany(row *Row) string {
+	return row.LicenseeCompany
+}
+
+// KeyProductCode keys a Row by its numerical product code.
+func KeyProductCode(row *Row) string {
+	// Numerical product code is in Product Description 31
+	return row.ProductDescription31
+}
+
+// Key identifies a Row across snapshots of the register: its licence
+// number, frequency, NGR (without spaces) and azimuth, which together are
+// unique to a link end in practice.
+func (row *Row) Key() string {
+	return strings.Join([]string{
+		row.LicenceNumber,
+		row.Frequency,
+		KeyNGR(row),
+		row.AntennaAzimuth,
+	}, "\x1f")
+}
+
+// Hash is a hash of the content of the OFCOM columns of a Row, ie.
+// excluding any munged columns (eg. OS easting and northing) that are not
+// in the register, so it is stable across downloads of the same row.
+func (row *Row) Hash() uint64 {
+	h := fnv.New64a()
+	for _, value := range row.toRecord(standardHeader) {
+		io.WriteString(h, value)
+		h.Write([]byte{0x1f})
+	}
+	return h.Sum64()
+}
+
+// ChangedSince returns the rows that are new or modified relative to an
+// older snapshot of the register, in order. Rows are matched as by Diff.
+func (collection *Collection) ChangedSince(old *Collection) *Collection {
+	diff := collection.Diff(old)
+	changed := make(map[*Row]bool, len(diff.Added)+len(diff.Changed))
+	for _, row := range diff.Added {
+		changed[row] = true
+	}
+	for _, change := range diff.Changed {
+		changed[change.New] = true
+	}
+	return collection.Filter(func(row *Row) bool { return changed[row] })
+}
@@ -0,0 +1,50 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestChangedSince(t *testing.T) {
+	const header = "Licence Number,Frequency,NGR,Antenna AZIMUTH,Antenna ERP\n"
+	old := testCollection(t, header+`0000001/1,7.5,TQ 29400 81900,90,30
+0000002/1,13.0,SJ 84000 98000,270,20
+0000003/1,18.0,NZ 10000 20000,0,10
+`)
+	collection := testCollection(t, header+`0000001/1,7.5,TQ 29400 81900,90,30
+0000002/1,13.0,SJ 84000 98000,270,25
+0000004/1,23.0,SU 10000 20000,45,10
+`)
+	changed := collection.ChangedSince(old)
+	if len(changed.Rows) != 2 {
+		t.Fatalf("wrong number of changed rows: %v", len(changed.Rows))
+	}
+	if changed.Rows[0].LicenceNumber != "0000002/1" || changed.Rows[1].LicenceNumber != "0000004/1" {
+		t.Fatalf("wrong changed rows: %v, %v", changed.Rows[0].LicenceNumber, changed.Rows[1].LicenceNumber)
+	}
+	if len(collection.ChangedSince(collection).Rows) != 0 {
+		t.Fatal("collection changed relative to itself")
+	}
+}
+
+func TestRowKeyHash(t *testing.T) {
+	a := &Row{LicenceNumber: "0000001/1", Frequency: "7.5", NGR: "TQ 29400 81900", AntennaAzimuth: "90", AntennaErp: "30"}
+	b := *a
+	b.NGR = "tq2940081900"
+	b.OsEasting, b.OsNorthing = 529400, 181900 // munged columns are ignored
+	if a.Key() != b.Key() {
+		t.Errorf("keys %q and %q differ", a.Key(), b.Key())
+	}
+	c := *a
+	c.OsEasting = 529400
+	if a.Hash() != c.Hash() {
+		t.Error("hash depends on munged columns")
+	}
+	c.AntennaErp = "25"
+	if a.Hash() == c.Hash() || a.Key() != c.Key() {
+		t.Error("change of ERP not hashed")
+	}
+	c.AntennaAzimuth = "270"
+	if a.Key() == c.Key() {
+		t.Error("change of azimuth not keyed")
+	}
+}
@@ -0,0 +1,190 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MapOptions configures WriteLeafletHTML.
+type MapOptions struct {
+	Title string // default "WTR"
+	// GeometryOptions are the policy for rows without WGS84 coordinates.
+	// Rows without a geometry cannot be shown, so MissingEmpty skips them.
+	GeometryOptions
+}
+
+// mapFeature is the GeoJSON feature of a Row on the map, with only the
+// popup properties to keep the page small.
+type mapFeature struct {
+	Type       string           `json:"type"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties mapProperties    `json:"properties"`
+}
+
+type mapProperties struct {
+	LicenceNumber string `json:"licence"`
+	Licensee      string `json:"licensee"`
+	Frequency     string `json:"frequency"`
+	ERP           string `json:"erp"`
+	Location      string `json:"location,omitempty"`
+}
+
+// mapLayer is the features of a product code.
+type mapLayer struct {
+	Name     string       `json:"name"`
+	Colour   string       `json:"colour"`
+	Features []mapFeature `json:"features"`
+}
+
+// mapColours are the colours of the layers, in turn.
+var mapColours = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// joinValue joins a value and its unit, eg. "7.5 GHz", or "" if no value.
+func joinValue(value, unit string) string {
+	return strings.TrimSpace(strings.TrimSpace(value) + " " + strings.TrimSpace(unit))
+}
+
+// mapLayers groups the rows with WGS84 coordinates by product code.
+func (collection *Collection) mapLayers() []*mapLayer {
+	lookup := GetProductCodeLookup()
+	byCode := make(map[string]*mapLayer)
+	var codes []string
+	for _, row := range collection.Rows {
+		if !row.hasWgs84() {
+			continue
+		}
+		code := row.ProductDescription31
+		layer, ok := byCode[code]
+		if !ok {
+			name, ok := lookup[code]
+			if !ok {
+				name = row.ProductDescription32
+			}
+			if name == "" {
+				name = "Unknown"
+			}
+			if code != "" {
+				name = code + " " + name
+			}
+			layer = &mapLayer{Name: name}
+			byCode[code] = layer
+			codes = append(codes, code)
+		}
+		layer.Features = append(layer.Features, mapFeature{
+			Type: "Feature",
+			Geometry: &geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: json.RawMessage("[" + row.Wgs84LongitudeAsString + "," + row.Wgs84LatitudeAsString + "]"),
+			},
+			Properties: mapProperties{
+				LicenceNumber: row.LicenceNumber,
+				Licensee:      row.LicenseeDisplayName(),
+				Frequency:     row.DisplayFrequency(),
+				ERP:           joinValue(row.AntennaErp, row.AntennaErpType),
+				Location:      row.AntennaLocation,
+			},
+		})
+	}
+
+	sort.Strings(codes)
+	layers := make([]*mapLayer, len(codes))
+	for i, code := range codes {
+		layers[i] = byCode[code]
+		layers[i].Colour = mapColours[i%len(mapColours)]
+	}
+	return layers
+}
+
+// WriteLeafletHTML writes a single HTML page showing the rows with WGS84
+// coordinates on a Leaflet map, with a popup for each licence (licensee,
+// frequency and ERP) and a layer for each product code. Leaflet and the
+// OpenStreetMap tiles are loaded from the web when the page is viewed. It
+// returns the counts of rows without coordinates. options may be nil.
+func (collection *Collection) WriteLeafletHTML(writer io.Writer, options *MapOptions) (*GeometryReport, error) {
+	title := "WTR"
+	var geometryOptions *GeometryOptions
+	if options != nil {
+		if options.Title != "" {
+			title = options.Title
+		}
+		geometryOptions = &options.GeometryOptions
+	}
+	located, report := collection.locate(geometryOptions, false)
+	layers, err := json.Marshal(located.mapLayers())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode map layers")
+	}
+
+	w := bufio.NewWriter(writer)
+	err = leafletTemplate.Execute(w, struct {
+		Title  string
+		Layers template.JS
+	}{title, template.JS(layers)}) // json.Marshal escapes <, > and &
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write HTML")
+	}
+	return report, errors.Wrap(w.Flush(), "could not write HTML")
+}
+
+var leafletTemplate = template.Must(template.New("map").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://unpkg.com/leaflet@1.9.4/dist/leaflet.css">
+<script src="https://unpkg.com/leaflet@1.9.4/dist/leaflet.js"></script>
+<style>html, body, #map { height: 100%; margin: 0; }</style>
+</head>
+<body>
+<div id="map"></div>
+<script>
+var layers = {{.Layers}};
+var map = L.map("map");
+L.tileLayer("https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png", {
+  maxZoom: 19,
+  attribution: "&copy; OpenStreetMap contributors"
+}).addTo(map);
+
+function escape(s) {
+  var div = document.createElement("div");
+  div.textContent = s;
+  return div.innerHTML;
+}
+
+var overlays = {};
+var bounds = L.latLngBounds([]);
+layers.forEach(function (layer) {
+  var geojson = L.geoJSON({type: "FeatureCollection", features: layer.features}, {
+    pointToLayer: function (feature, latlng) {
+      return L.circleMarker(latlng, {radius: 5, color: layer.colour, weight: 1, fillOpacity: 0.7});
+    },
+    onEachFeature: function (feature, marker) {
+      var p = feature.properties;
+      var rows = [["Licence", p.licence], ["Licensee", p.licensee], ["Frequency", p.frequency], ["ERP", p.erp], ["Location", p.location]];
+      marker.bindPopup(rows.filter(function (r) { return r[1]; }).map(function (r) {
+        return "<b>" + r[0] + "</b> " + escape(r[1]);
+      }).join("<br>"));
+    }
+  }).addTo(map);
+  overlays['<span style="color:' + layer.colour + '">&#9679;</span> ' + escape(layer.name) + " (" + layer.features.length + ")"] = geojson;
+  bounds.extend(geojson.getBounds());
+});
+L.control.layers(null, overlays, {collapsed: false}).addTo(map);
+if (bounds.isValid()) {
+  map.fitBounds(bounds, {padding: [20, 20]});
+} else {
+  map.setView([54.5, -3], 6);
+}
+</script>
+</body>
+</html>
+`))
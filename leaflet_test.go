@@ -0,0 +1,45 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteLeafletHTML(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Frequency,Frequency Type,Antenna ERP,Antenna ERP type,Product Description 31,Product Description 32,WGS84 Longitude,WGS84 Latitude
+0000001/1,Acme </script>,7.5,GHz,30,dBW,301010,Fixed Links,-0.1388,51.5215
+0000002/1,Acme,450.1,MHz,10,W,408010,Business Radio,-3.1883,55.9533
+0000003/1,Other,18,GHz,,,301010,Fixed Links,-2.2426,53.4808
+0000004/1,Nowhere,18,GHz,,,301010,Fixed Links,,
+`)
+	b := new(bytes.Buffer)
+	report, err := collection.WriteLeafletHTML(b, &MapOptions{Title: "Fixed <links>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 3 || report.Located != 3 || report.Skipped != 1 || len(report.Warnings) != 1 {
+		t.Errorf("wrong report %+v", report)
+	}
+	page := b.String()
+	if !strings.Contains(page, "<title>Fixed &lt;links&gt;</title>") {
+		t.Error("title not escaped")
+	}
+	if strings.Count(page, "</script>") != 2 {
+		t.Error("row data not escaped")
+	}
+
+	start := strings.Index(page, "var layers = ") + len("var layers = ")
+	end := strings.Index(page[start:], ";\n") + start
+	var layers []mapLayer
+	if err := json.Unmarshal([]byte(page[start:end]), &layers); err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 2 || layers[0].Name != "301010 Fixed Links" || len(layers[0].Features) != 2 || len(layers[1].Features) != 1 {
+		t.Fatalf("wrong layers %+v", layers)
+	}
+	if p := layers[0].Features[0].Properties; p.Licensee != "Acme </script>" || p.Frequency != "7.5 GHz" || p.ERP != "30 dBW" {
+		t.Errorf("wrong properties %+v", p)
+	}
+}
@@ -0,0 +1,50 @@
+package wtrcsv
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// LicenseeAliases maps licensee names, as normalised by aliasKey, to the
+// name to display, eg. to merge the trading names and past names of a
+// company. See LoadLicenseeAliases.
+var LicenseeAliases = map[string]string{}
+
+// aliasKey normalises a name for LicenseeAliases: upper case with runs of
+// spaces collapsed.
+func aliasKey(name string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(name), " "))
+}
+
+// LoadLicenseeAliases adds the aliases of a two column csv (alias, display
+// name) to LicenseeAliases. A header row is not expected.
+func LoadLicenseeAliases(reader io.Reader) error {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "could not read licensee aliases")
+	}
+	for _, record := range records {
+		LicenseeAliases[aliasKey(record[0])] = strings.TrimSpace(record[1])
+	}
+	return nil
+}
+
+// LicenseeDisplayName returns the name of the holder of the licence: the
+// licensee company or, failing that, the licensee's first name and surname,
+// mapped by LicenseeAliases. Runs of spaces are collapsed. It is "" only if
+// all of the licensee columns are empty.
+func (row *Row) LicenseeDisplayName() string {
+	name := strings.Join(strings.Fields(row.LicenseeCompany), " ")
+	if name == "" {
+		name = strings.Join(strings.Fields(row.LicenseeFirstName+" "+row.LicenseeSurname), " ")
+	}
+	if alias, ok := LicenseeAliases[aliasKey(name)]; ok && name != "" {
+		return alias
+	}
+	return name
+}
@@ -0,0 +1,33 @@
+package wtrcsv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLicenseeDisplayName(t *testing.T) {
+	defer func(aliases map[string]string) { LicenseeAliases = aliases }(LicenseeAliases)
+	LicenseeAliases = map[string]string{}
+	if err := LoadLicenseeAliases(strings.NewReader("Acme Ltd,Acme Group\n\"ACME  LIMITED\", Acme Group\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLicenseeAliases(strings.NewReader("one,two,three\n")); err == nil {
+		t.Error("three columns accepted")
+	}
+
+	for _, test := range []struct {
+		row  Row
+		want string
+	}{
+		{Row{LicenseeCompany: "Other  Company ", LicenseeSurname: "Smith"}, "Other Company"},
+		{Row{LicenseeCompany: "acme ltd"}, "Acme Group"},
+		{Row{LicenseeCompany: "Acme Limited"}, "Acme Group"},
+		{Row{LicenseeFirstName: "Jane", LicenseeSurname: "Smith"}, "Jane Smith"},
+		{Row{LicenseeSurname: "Smith"}, "Smith"},
+		{Row{}, ""},
+	} {
+		if got := test.row.LicenseeDisplayName(); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.row, got, test.want)
+		}
+	}
+}
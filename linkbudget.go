@@ -0,0 +1,105 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"math"
+)
+
+// dipoleGainDBi is the gain of a half wave dipole, the reference of ERP.
+const dipoleGainDBi = 2.15
+
+// freeSpaceLossConstantDB is 20log10(4π/c) for frequencies in MHz and
+// distances in km.
+const freeSpaceLossConstantDB = 32.45
+
+// FreeSpacePathLossDB returns the free space path loss in dB of a path at a
+// frequency.
+func FreeSpacePathLossDB(frequencyMHz, pathKm float64) float64 {
+	return freeSpaceLossConstantDB + 20*math.Log10(pathKm) + 20*math.Log10(frequencyMHz)
+}
+
+// DirectionBudget is the link budget of one direction of a link, from the
+// transmitting end to the receiving end.
+type DirectionBudget struct {
+	FrequencyMHz float64 // of the transmitting end
+	EIRPdBW      float64 // the ERP of the transmitting end plus 2.15 dB
+	PathLossDB   float64 // free space
+	// RxGainDBi and RxFeedingLossDB are the Antenna Gain and Feeding Loss of
+	// the receiving end, 0 if unknown.
+	RxGainDBi       float64
+	RxFeedingLossDB float64
+	ReceiveLevelDBm float64
+	// FadeMarginDB is the Fade Margin of the transmitting end, if
+	// HasFadeMargin, and ThresholdDBm the receiver threshold it implies,
+	// the receive level less the fade margin.
+	FadeMarginDB  float64
+	HasFadeMargin bool
+	ThresholdDBm  float64
+}
+
+// MarginDB returns the fade margin of the direction over a receiver
+// threshold, eg. -70 dBm.
+func (budget *DirectionBudget) MarginDB(thresholdDBm float64) float64 {
+	return budget.ReceiveLevelDBm - thresholdDBm
+}
+
+// LinkBudget is the link budget of both directions of a link, over the
+// free space path loss of its length.
+type LinkBudget struct {
+	Link   *Link
+	PathKm float64 // great-circle, or on the National Grid if not located
+	AB, BA DirectionBudget
+}
+
+// FadeMarginDB returns the lesser fade margin of the directions that have
+// one. ok is false if neither has.
+func (budget *LinkBudget) FadeMarginDB() (db float64, ok bool) {
+	for _, direction := range []*DirectionBudget{&budget.AB, &budget.BA} {
+		if direction.HasFadeMargin && (!ok || direction.FadeMarginDB < db) {
+			db, ok = direction.FadeMarginDB, true
+		}
+	}
+	return db, ok
+}
+
+// Budget returns the link budget of the link from the Frequency, Antenna
+// ERP and Fade Margin of the transmitting end, and the Antenna Gain and
+// Feeding Loss of the receiving end, in each direction. The ERP includes
+// the gain and feeder loss of the transmitting end. It is an error if the
+// length of the link, or the frequency or ERP of either end, is unknown.
+func (link *Link) Budget() (*LinkBudget, error) {
+	km, ok := link.lengthKm()
+	if !ok || km <= 0 {
+		return nil, errors.Errorf("link %s has no length", link.LicenceNumber())
+	}
+	budget := &LinkBudget{Link: link, PathKm: km}
+	var err error
+	if budget.AB, err = directionBudget(link.A, link.B, km); err != nil {
+		return nil, errors.Wrapf(err, "link %s A-B", link.LicenceNumber())
+	}
+	if budget.BA, err = directionBudget(link.B, link.A, km); err != nil {
+		return nil, errors.Wrapf(err, "link %s B-A", link.LicenceNumber())
+	}
+	return budget, nil
+}
+
+func directionBudget(tx, rx *Row, km float64) (DirectionBudget, error) {
+	var budget DirectionBudget
+	var ok bool
+	if budget.FrequencyMHz, ok = frequencyMHz(tx); !ok || budget.FrequencyMHz <= 0 {
+		return budget, errors.New("no frequency")
+	}
+	erp, ok := erpDBW(tx)
+	if !ok {
+		return budget, errors.New("no ERP")
+	}
+	budget.EIRPdBW = erp + dipoleGainDBi
+	budget.PathLossDB = FreeSpacePathLossDB(budget.FrequencyMHz, km)
+	budget.RxGainDBi, _ = parseNumber(rx.AntennaGain)
+	budget.RxFeedingLossDB, _ = parseNumber(rx.FeedingLoss)
+	budget.ReceiveLevelDBm = budget.EIRPdBW + 30 - budget.PathLossDB + budget.RxGainDBi - budget.RxFeedingLossDB
+	if budget.FadeMarginDB, budget.HasFadeMargin = parseNumber(tx.FadeMargin); budget.HasFadeMargin {
+		budget.ThresholdDBm = budget.ReceiveLevelDBm - budget.FadeMarginDB
+	}
+	return budget, nil
+}
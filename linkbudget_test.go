@@ -0,0 +1,46 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinkBudget(t *testing.T) {
+	const csv = `Licence Number,NGR,Frequency,Frequency Type,Antenna ERP,Antenna ERP type,Antenna Gain,Feeding Loss,Fade Margin
+0000001/1,TQ 00000 00000,7500,MHz,10,dBW,38,2,30
+0000001/1,TQ 10000 00000,7661,MHz,40000,mW,36,1,
+0000002/1,SJ 00000 00000,13000,MHz,10,dBW,,,
+0000002/1,SJ 10000 00000,13266,MHz,,,,,
+`
+	links := testCollection(t, csv).Links()
+	budget, err := links.Links[0].Budget()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.PathKm != 10 {
+		t.Fatalf("path %vkm", budget.PathKm)
+	}
+	ab, ba := budget.AB, budget.BA
+	for _, test := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"A-B path loss", ab.PathLossDB, 20 + 20*math.Log10(7500) + 32.45},
+		{"A-B receive level", ab.ReceiveLevelDBm, 10 + 2.15 + 30 - ab.PathLossDB + 36 - 1},
+		{"A-B threshold", ab.ThresholdDBm, ab.ReceiveLevelDBm - 30},
+		{"A-B margin", ab.MarginDB(-70), ab.ReceiveLevelDBm + 70},
+		{"B-A EIRP", ba.EIRPdBW, 10*math.Log10(40) + 2.15},
+		{"B-A receive level", ba.ReceiveLevelDBm, ba.EIRPdBW + 30 - ba.PathLossDB + 38 - 2},
+	} {
+		if math.Abs(test.got-test.want) > 0.01 {
+			t.Errorf("%s %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if !ab.HasFadeMargin || ba.HasFadeMargin {
+		t.Errorf("fade margins %v %v", ab.HasFadeMargin, ba.HasFadeMargin)
+	}
+
+	if _, err := links.Links[1].Budget(); err == nil {
+		t.Error("budget without the ERP of the B end")
+	}
+}
@@ -0,0 +1,163 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// LinkCollection is the links paired from a Collection by PairLinks, with
+// the rows that could not be paired.
+type LinkCollection struct {
+	Links    []*Link
+	Unpaired []*Row
+}
+
+// Links pairs the rows of the collection into links, see
+// PairLinks.
+func (collection *Collection) Links() *LinkCollection {
+	links, unpaired := collection.PairLinks()
+	return &LinkCollection{Links: links, Unpaired: unpaired}
+}
+
+// LicenceNumber returns the licence number of the link.
+func (link *Link) LicenceNumber() string {
+	return link.A.LicenceNumber
+}
+
+// Band returns the name of the band of the link, that of either end, or ""
+// if neither is in one of Bands.
+func (link *Link) Band() string {
+	if band := link.A.Band(); band != "" {
+		return band
+	}
+	return link.B.Band()
+}
+
+// FrequencyMHz returns the frequency of the link, that of its A end or
+// else its B end.
+func (link *Link) FrequencyMHz() (mhz float64, ok bool) {
+	if mhz, ok = frequencyMHz(link.A); ok {
+		return mhz, true
+	}
+	return frequencyMHz(link.B)
+}
+
+// LinkFilterFn is a FilterFn of Links.
+type LinkFilterFn func(link *Link) bool
+
+// FilterLinkEither returns a LinkFilterFn keeping the links with either end
+// kept by all of filterFuncs, eg. FilterLinkEither(FilterWithinRadius(...))
+// for the links with an end within a radius.
+func FilterLinkEither(filterFuncs ...FilterFn) LinkFilterFn {
+	return func(link *Link) bool {
+		return matchesAll(link.A, filterFuncs) || matchesAll(link.B, filterFuncs)
+	}
+}
+
+// FilterLinkBoth returns a LinkFilterFn keeping the links with both ends
+// kept by all of filterFuncs.
+func FilterLinkBoth(filterFuncs ...FilterFn) LinkFilterFn {
+	return func(link *Link) bool {
+		return matchesAll(link.A, filterFuncs) && matchesAll(link.B, filterFuncs)
+	}
+}
+
+// FilterLinkBand returns a LinkFilterFn keeping the links in any of the
+// named bands, as FilterBand.
+func FilterLinkBand(names ...string) LinkFilterFn {
+	filter := FilterBand(names...)
+	return func(link *Link) bool {
+		return filter(link.A) || filter(link.B)
+	}
+}
+
+// Filter returns the links kept by every filterFunc, without the unpaired
+// rows. The Links are shared with the receiver.
+func (links *LinkCollection) Filter(filterFuncs ...LinkFilterFn) *LinkCollection {
+	filtered := &LinkCollection{Links: make([]*Link, 0)}
+	for _, link := range links.Links {
+		ok := true
+		for _, filterFunc := range filterFuncs {
+			if !filterFunc(link) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered.Links = append(filtered.Links, link)
+		}
+	}
+	return filtered
+}
+
+// Rows returns both ends of each link as a Collection with the header,
+// the A end then the B end of each link in order.
+func (links *LinkCollection) Rows(header []string) *Collection {
+	rows := make([]*Row, 0, 2*len(links.Links))
+	for _, link := range links.Links {
+		rows = append(rows, link.A, link.B)
+	}
+	return &Collection{Header: header, Rows: rows}
+}
+
+// WriteLinkPlanCSV writes the links as WriteLinkPlanCSV.
+func (links *LinkCollection) WriteLinkPlanCSV(writer io.Writer) error {
+	return WriteLinkPlanCSV(writer, links.Links)
+}
+
+// WriteGeoJSON writes the links as a GeoJSON FeatureCollection of
+// LineString features from the A end to the B end, with the licence
+// number, licensee, band, the frequency and frequency type of each
+// direction and the rain zone and availability, as WriteLinkPlanCSV, as
+// properties. Links without the coordinates of both ends have a null
+// geometry.
+func (links *LinkCollection) WriteGeoJSON(writer io.Writer) error {
+	w := bufio.NewWriter(writer)
+	if _, err := io.WriteString(w, `{"type":"FeatureCollection","features":[`); err != nil {
+		return errors.Wrap(err, "could not write GeoJSON")
+	}
+	for i, link := range links.Links {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return errors.Wrap(err, "could not write GeoJSON")
+			}
+		}
+		b, err := json.Marshal(link.geoJSONFeature())
+		if err != nil {
+			return errors.Wrapf(err, "could not encode licence %s", link.LicenceNumber())
+		}
+		if _, err := w.Write(b); err != nil {
+			return errors.Wrap(err, "could not write GeoJSON")
+		}
+	}
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return errors.Wrap(err, "could not write GeoJSON")
+	}
+	return errors.Wrap(w.Flush(), "could not write GeoJSON")
+}
+
+func (link *Link) geoJSONFeature() *geoJSONFeature {
+	a, b := link.A, link.B
+	feature := geoJSONFeature{Type: "Feature", Properties: map[string]interface{}{
+		"Licence Number": link.LicenceNumber(),
+		"Licensee":       a.LicenseeDisplayName(),
+		"Band":           link.Band(),
+		"Frequency A-B":  a.Frequency,
+		"Frequency B-A":  b.Frequency,
+		"Frequency Type": a.FrequencyType,
+		// The ends may use different units.
+		"Frequency Type B-A": b.FrequencyType,
+	}}
+	zone, availability := linkRain(link)
+	feature.Properties["Rain Zone"] = zone
+	feature.Properties["Rain Availability"] = availability
+	latA, lonA, okA := rowLatLon(a)
+	latB, lonB, okB := rowLatLon(b)
+	if okA && okB {
+		coordinates, _ := json.Marshal([][]float64{{lonA, latA}, {lonB, latB}})
+		feature.Geometry = &geoJSONGeometry{Type: "LineString", Coordinates: coordinates}
+	}
+	return &feature
+}
@@ -0,0 +1,52 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestLinkCollection(t *testing.T) {
+	collection := testCollection(t, linksTestCSV)
+	links := collection.Links()
+	if len(links.Links) != 3 || len(links.Unpaired) != 2 {
+		t.Fatalf("%d links, %d unpaired", len(links.Links), len(links.Unpaired))
+	}
+
+	filtered := links.Filter(FilterLinkBand("13 GHz", "18 GHz"), FilterLinkEither(func(row *Row) bool { return row.AntennaLocation == "North" }))
+	if len(filtered.Links) != 1 || filtered.Links[0].LicenceNumber() != "0000003/1" || filtered.Unpaired != nil {
+		t.Fatalf("filtered %d links", len(filtered.Links))
+	}
+	if n := len(links.Filter(FilterLinkBoth(func(row *Row) bool { return row.AntennaLocation == "North" })).Links); n != 0 {
+		t.Fatalf("%d links with both ends North", n)
+	}
+	if rows := filtered.Rows(collection.Header); licenceNumbers(rows) != "0000003/1 0000003/1" {
+		t.Fatalf("rows %v", licenceNumbers(rows))
+	}
+
+	var b bytes.Buffer
+	if err := links.WriteGeoJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var featureCollection geoJSONFeatureCollection
+	if err := json.Unmarshal(b.Bytes(), &featureCollection); err != nil {
+		t.Fatal(err)
+	}
+	features := featureCollection.Features
+	if len(features) != 3 || features[0].Geometry == nil || features[0].Geometry.Type != "LineString" {
+		t.Fatalf("features %+v", features)
+	}
+	if string(features[0].Geometry.Coordinates) != "[[0,51.5],[0.1,51.5]]" || features[0].Properties["Band"] != "7.5 GHz" {
+		t.Fatalf("feature %s %v", features[0].Geometry.Coordinates, features[0].Properties)
+	}
+}
+
+func TestPairLinksBand(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR,Frequency,Frequency Type
+0000001/1,TQ 00000 00000,7500,MHz
+0000001/1,TQ 10000 00000,23000,MHz
+`)
+	if links, unpaired := collection.PairLinks(); len(links) != 0 || len(unpaired) != 2 {
+		t.Fatalf("paired ends in different bands: %d links", len(links))
+	}
+}
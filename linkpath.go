@@ -0,0 +1,88 @@
+package wtrcsv
+
+import (
+	"math"
+	"sort"
+)
+
+// PathLengthKm returns the great-circle length of the link between the
+// WGS84 locations of its ends. ok is false if either end is not located.
+func (link *Link) PathLengthKm() (km float64, ok bool) {
+	latA, lonA, okA := rowLatLon(link.A)
+	latB, lonB, okB := rowLatLon(link.B)
+	if !okA || !okB {
+		return 0, false
+	}
+	return distanceKm(latA, lonA, latB, lonB), true
+}
+
+// PathLengthOSGBKm returns the length of the link on the British National
+// Grid, the straight line between the grid references of its ends. ok is
+// false if either end has none.
+func (link *Link) PathLengthOSGBKm() (km float64, ok bool) {
+	eA, nA, okA := rowOS(link.A)
+	eB, nB, okB := rowOS(link.B)
+	if !okA || !okB {
+		return 0, false
+	}
+	return math.Hypot(float64(eB-eA), float64(nB-nA)) / 1000, true
+}
+
+// lengthKm returns the great-circle length of the link, or its length on
+// the National Grid if the ends are not located.
+func (link *Link) lengthKm() (float64, bool) {
+	if km, ok := link.PathLengthKm(); ok {
+		return km, true
+	}
+	return link.PathLengthOSGBKm()
+}
+
+// Azimuths returns the forward (A to B) and back (B to A) azimuths of the
+// link in degrees clockwise from north: the great-circle bearings between
+// the WGS84 locations of the ends, otherwise the grid bearings between
+// their grid references, which are relative to grid north. ok is false if
+// the ends cannot be located either way.
+func (link *Link) Azimuths() (forward, back float64, ok bool) {
+	latA, lonA, okA := rowLatLon(link.A)
+	latB, lonB, okB := rowLatLon(link.B)
+	if okA && okB {
+		return bearingDeg(latA, lonA, latB, lonB), bearingDeg(latB, lonB, latA, lonA), true
+	}
+	eA, nA, okA := rowOS(link.A)
+	eB, nB, okB := rowOS(link.B)
+	if !okA || !okB {
+		return 0, 0, false
+	}
+	forward = math.Mod(degrees(math.Atan2(float64(eB-eA), float64(nB-nA)))+360, 360)
+	return forward, math.Mod(forward+180, 360), true
+}
+
+// FilterLinkLengthBetween returns a LinkFilterFn keeping the links whose
+// length is in [minKm, maxKm]: the great-circle length, or the length on
+// the National Grid if the ends are not located. Links whose length is
+// unknown are dropped.
+func FilterLinkLengthBetween(minKm, maxKm float64) LinkFilterFn {
+	return func(link *Link) bool {
+		km, ok := link.lengthKm()
+		return ok && km >= minKm && km <= maxKm
+	}
+}
+
+// LengthHistogramByBand returns a histogram of the lengths of the links,
+// as FilterLinkLengthBetween, in each band (see Link.Band) with buckets
+// equal width buckets. Links whose length is unknown are not counted.
+func (links *LinkCollection) LengthHistogramByBand(buckets int) map[string][]HistogramBucket {
+	lengths := make(map[string][]float64)
+	for _, link := range links.Links {
+		if km, ok := link.lengthKm(); ok {
+			band := link.Band()
+			lengths[band] = append(lengths[band], km)
+		}
+	}
+	histograms := make(map[string][]HistogramBucket, len(lengths))
+	for band, values := range lengths {
+		sort.Float64s(values)
+		histograms[band] = histogram(values, buckets)
+	}
+	return histograms
+}
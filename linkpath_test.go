@@ -0,0 +1,40 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinkPath(t *testing.T) {
+	links := testCollection(t, linksTestCSV).Links()
+	for i, test := range []struct {
+		km, osgbKm    float64
+		located       bool
+		forward, back float64
+	}{
+		{6.92, 10, true, 90, 270},
+		{0, 10, false, 90, 270},
+		{0, 10, false, 0, 180},
+	} {
+		link := links.Links[i]
+		km, ok := link.PathLengthKm()
+		if ok != test.located || math.Abs(km-test.km) > 0.01 {
+			t.Errorf("link %d: great-circle length %v %v", i, km, ok)
+		}
+		if km, ok := link.PathLengthOSGBKm(); !ok || km != test.osgbKm {
+			t.Errorf("link %d: grid length %v %v", i, km, ok)
+		}
+		forward, back, ok := link.Azimuths()
+		if !ok || math.Abs(forward-test.forward) > 0.1 || math.Abs(back-test.back) > 0.1 {
+			t.Errorf("link %d: azimuths %v %v %v", i, forward, back, ok)
+		}
+	}
+
+	if n := len(links.Filter(FilterLinkLengthBetween(5, 8)).Links); n != 1 {
+		t.Errorf("%d links 5-8km", n)
+	}
+	histograms := links.LengthHistogramByBand(2)
+	if len(histograms) != 3 || histograms["18 GHz"][0].Count != 1 || histograms["7.5 GHz"][0].High != histograms["7.5 GHz"][0].Low {
+		t.Errorf("histograms %v", histograms)
+	}
+}
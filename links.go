@@ -0,0 +1,147 @@
+package wtrcsv
+
+import (
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+)
+
+// Link is a point-to-point fixed link derived from the rows of its two ends.
+// Each row carries the transmit frequency of its end.
+type Link struct {
+	A *Row
+	B *Row
+}
+
+// PairingToleranceDeg is the largest error in degrees between an end's
+// azimuth and the direction of the other end for two rows to be paired.
+var PairingToleranceDeg = 10.0
+
+// PairLinks pairs the rows of the collection into Links. Rows are paired
+// within a licence number where they are at different sites, in the same
+// band (if both are in one of Bands), and their antennas point at each
+// other: by the bearing between the sites if both are located, otherwise
+// by reciprocal azimuths. A licence with exactly two such rows is paired
+// even without azimuths. Rows that could not be paired are returned
+// separately.
+func (collection *Collection) PairLinks() (links []*Link, unpaired []*Row) {
+	var order []string
+	groups := make(map[string][]*Row)
+	for _, row := range collection.Rows {
+		if _, ok := groups[row.LicenceNumber]; !ok {
+			order = append(order, row.LicenceNumber)
+		}
+		groups[row.LicenceNumber] = append(groups[row.LicenceNumber], row)
+	}
+
+	for _, licenceNumber := range order {
+		rows := groups[licenceNumber]
+		if len(rows) == 2 && KeyNGR(rows[0]) != KeyNGR(rows[1]) && sameBand(rows[0], rows[1]) {
+			if _, ok := pairingError(rows[0], rows[1]); ok || !hasAzimuths(rows[0], rows[1]) {
+				links = append(links, &Link{rows[0], rows[1]})
+				continue
+			}
+		}
+
+		paired := make([]bool, len(rows))
+		for i, a := range rows {
+			if paired[i] {
+				continue
+			}
+			best, bestError := -1, math.Inf(1)
+			for j := i + 1; j < len(rows); j++ {
+				if paired[j] || KeyNGR(a) == KeyNGR(rows[j]) || !sameBand(a, rows[j]) {
+					continue
+				}
+				if e, ok := pairingError(a, rows[j]); ok && e < bestError {
+					best, bestError = j, e
+				}
+			}
+			if best >= 0 {
+				paired[i], paired[best] = true, true
+				links = append(links, &Link{a, rows[best]})
+			}
+		}
+		for i, row := range rows {
+			if !paired[i] {
+				unpaired = append(unpaired, row)
+			}
+		}
+	}
+	return links, unpaired
+}
+
+// sameBand reports whether two rows could be the ends of a link by their
+// frequencies: in the same band, or either in none of Bands.
+func sameBand(a, b *Row) bool {
+	bandA, bandB := a.Band(), b.Band()
+	return bandA == "" || bandB == "" || bandA == bandB
+}
+
+func hasAzimuths(a, b *Row) bool {
+	_, okA := azimuthDeg(a)
+	_, okB := azimuthDeg(b)
+	return okA && okB
+}
+
+// pairingError is the error in degrees of the two ends pointing at each
+// other, and whether it is within PairingToleranceDeg.
+func pairingError(a, b *Row) (float64, bool) {
+	azA, okA := azimuthDeg(a)
+	azB, okB := azimuthDeg(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	var e float64
+	latA, lonA, okA := rowLatLon(a)
+	latB, lonB, okB := rowLatLon(b)
+	if okA && okB {
+		e = math.Max(angleBetween(azA, bearingDeg(latA, lonA, latB, lonB)),
+			angleBetween(azB, bearingDeg(latB, lonB, latA, lonA)))
+	} else {
+		e = 180 - angleBetween(azA, azB)
+	}
+	return e, e <= PairingToleranceDeg
+}
+
+// linkPlanHeader is the header of WriteLinkPlanCSV.
+var linkPlanHeader = []string{
+	"Licence Number", "Licensee",
+	"A NGR", "A Location", "A Antenna Height",
+	"B NGR", "B Location", "B Antenna Height",
+	"Frequency A-B", "Frequency B-A", "Frequency Type", "Frequency Type B-A",
+	"Channel Width", "Channel Width type",
+	"Rain Zone", "Rain Availability",
+}
+
+// WriteLinkPlanCSV writes the links in the transmission plan layout that
+// link planners exchange: one line per link with both ends, the frequency
+// of each direction, the bandwidth and the licensee, with the rain zone and
+// the availability due to rain with the fade margin of the LinkBudget of
+// the link, where known. Frequency Type is the unit of Frequency A-B, and
+// Frequency Type B-A that of Frequency B-A, as the ends may differ.
+func WriteLinkPlanCSV(writer io.Writer, links []*Link) error {
+	w := csv.NewWriter(writer)
+	if err := w.Write(linkPlanHeader); err != nil {
+		return errors.Wrap(err, "could not write CSV header")
+	}
+	for _, link := range links {
+		a, b := link.A, link.B
+		zone, availability := linkRain(link)
+		record := []string{
+			a.LicenceNumber, a.LicenseeDisplayName(),
+			a.NGR, a.AntennaLocation, a.AntennaHeight,
+			b.NGR, b.AntennaLocation, b.AntennaHeight,
+			a.Frequency, b.Frequency, a.FrequencyType, b.FrequencyType,
+			a.ChannelWidth, a.ChannelWidthType,
+			zone, availability,
+		}
+		if err := w.Write(record); err != nil {
+			return errors.Wrap(err, "could not write CSV row")
+		}
+	}
+	w.Flush()
+	return errors.Wrap(w.Error(), "could not write CSV")
+}
@@ -0,0 +1,56 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const linksTestCSV = `Licence Number,NGR,Frequency,Frequency Type,Antenna AZIMUTH,Antenna Location,Licencee Company,Licencee First Name,Licencee Surname,WGS84 Longitude,WGS84 Latitude
+0000001/1,TQ 00000 00000,7500,MHz,90,West,Company One,,,0.0,51.5
+0000001/1,TQ 10000 00000,7661,MHz,270,East,Company One,,,0.1,51.5
+0000002/1,SJ 00000 00000,13000,MHz,,A,,Jo,Bloggs,,
+0000002/1,SJ 10000 00000,13266,MHz,,B,,Jo,Bloggs,,
+0000003/1,NZ 00000 00000,18000,MHz,0,Hub,Company Three,,,,
+0000003/1,NZ 00000 10000,18010,MHz,180,North,Company Three,,,,
+0000003/1,NZ 10000 00000,18020,MHz,90,Hub-East,Company Three,,,,
+0000004/1,SU 00000 00000,23000,MHz,0,Alone,Company Four,,,,
+`
+
+func TestPairLinks(t *testing.T) {
+	collection := testCollection(t, linksTestCSV)
+
+	links, unpaired := collection.PairLinks()
+	if len(links) != 3 {
+		t.Fatalf("wrong number of links: %v", len(links))
+	}
+	if links[2].A.AntennaLocation != "Hub" || links[2].B.AntennaLocation != "North" {
+		t.Fatalf("wrong hub pairing: %v - %v", links[2].A.AntennaLocation, links[2].B.AntennaLocation)
+	}
+	if len(unpaired) != 2 {
+		t.Fatalf("wrong number of unpaired rows: %v", len(unpaired))
+	}
+
+	b := new(bytes.Buffer)
+	if err := WriteLinkPlanCSV(b, links); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("wrong number of lines: %v", len(lines))
+	}
+	if lines[2] != "0000002/1,Jo Bloggs,SJ 00000 00000,A,,SJ 10000 00000,B,,13000,13266,MHz,MHz,,,," {
+		t.Fatalf("unexpected line: %v", lines[2])
+	}
+
+	// Each direction keeps the unit of its end.
+	b.Reset()
+	mixed := &Link{A: &Row{LicenceNumber: "0000005/1", Frequency: "7.5", FrequencyType: "GHz"},
+		B: &Row{LicenceNumber: "0000005/1", Frequency: "7661", FrequencyType: "MHz"}}
+	if err := WriteLinkPlanCSV(b, []*Link{mixed}); err != nil {
+		t.Fatal(err)
+	}
+	if line := strings.Split(b.String(), "\n")[1]; !strings.Contains(line, ",7.5,7661,GHz,MHz,") {
+		t.Fatalf("unexpected line: %v", line)
+	}
+}
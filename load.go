@@ -0,0 +1,110 @@
+package wtrcsv
+
+import (
+	"compress/gzip"
+	"context"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// LoadErrors aggregates the errors of the files that failed to load.
+type LoadErrors []error
+
+func (errs LoadErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// LoadMany reads the csv files concurrently, with at most GOMAXPROCS files
+// being parsed at once. See LoadManyLimit.
+func LoadMany(ctx context.Context, paths []string) ([]*Collection, error) {
+	return LoadManyLimit(ctx, paths, runtime.GOMAXPROCS(0))
+}
+
+// LoadManyLimit reads the csv files with at most limit files being parsed at
+// once. Files named *.gz are decompressed.
+//
+// The returned collections are in the order of paths. If any file fails to
+// load, its collection is nil and the error is a LoadErrors with one error
+// per failed file; the other files are still loaded unless ctx is
+// cancelled.
+func LoadManyLimit(ctx context.Context, paths []string, limit int) ([]*Collection, error) {
+	if limit < 1 {
+		limit = 1
+	}
+	collections := make([]*Collection, len(paths))
+	errs := make([]error, len(paths))
+
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = errors.Wrapf(ctx.Err(), "could not load \"%s\"", path)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			collections[i], errs[i] = loadFile(ctx, path)
+		}(i, path)
+	}
+	wg.Wait()
+
+	var loadErrors LoadErrors
+	for _, err := range errs {
+		if err != nil {
+			loadErrors = append(loadErrors, err)
+		}
+	}
+	if loadErrors != nil {
+		return collections, loadErrors
+	}
+	return collections, nil
+}
+
+func loadFile(ctx context.Context, path string) (*Collection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open csv file: \"%s\"", path)
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decompress \"%s\"", path)
+		}
+		defer r.Close()
+		reader = r
+	}
+	collection, err := readCSV(&contextReader{ctx, reader})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not load \"%s\"", path)
+	}
+	return collection, nil
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
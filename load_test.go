@@ -0,0 +1,49 @@
+package wtrcsv
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMany(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var paths []string
+	for i, text := range []string{
+		"Licence Number\n0000001/1\n",
+		"Licence Number\n0000001/1\n0000002/1\n",
+		"Licence Number,OS Easting\n0000001/1,not a number\n",
+	} {
+		path := filepath.Join(dir, string('a'+rune(i))+".csv")
+		if err := ioutil.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	paths = append(paths, filepath.Join(dir, "missing.csv"))
+
+	collections, err := LoadManyLimit(context.Background(), paths, 2)
+	loadErrors, ok := err.(LoadErrors)
+	if !ok || len(loadErrors) != 2 {
+		t.Fatalf("expected two load errors: %v", err)
+	}
+	if len(collections[0].Rows) != 1 || len(collections[1].Rows) != 2 {
+		t.Fatal("files loaded incorrectly")
+	}
+	if collections[2] != nil || collections[3] != nil {
+		t.Fatal("failed files should have nil collections")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadMany(ctx, paths[:2]); err == nil {
+		t.Fatal("expected error from cancelled context")
+	}
+}
@@ -0,0 +1,54 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+)
+
+// Logger is where the diagnostics of the package are written, eg. an
+// adapter to a structured logger. *log.Logger is a Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// stdLogger is the default Logger, the standard log package.
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) {
+	log.Output(3, fmt.Sprintf(format, v...))
+}
+
+// loggerValue holds a Logger in an atomic.Value, which requires the same
+// concrete type for every store.
+type loggerValue struct {
+	Logger
+}
+
+var logger atomic.Value
+
+func init() {
+	logger.Store(loggerValue{stdLogger{}})
+}
+
+// SetLogger sets the Logger of the package, nil for the standard log
+// package. It is used by the functions that exit on an error, eg. ReadCSV,
+// which log the error before exiting, and by the server package.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = stdLogger{}
+	}
+	logger.Store(loggerValue{l})
+}
+
+// Logf logs through the Logger set by SetLogger.
+func Logf(format string, v ...interface{}) {
+	logger.Load().(loggerValue).Printf(format, v...)
+}
+
+// fatalf logs through the Logger, then exits, as log.Fatalf.
+func fatalf(format string, v ...interface{}) {
+	Logf(format, v...)
+	os.Exit(1)
+}
@@ -0,0 +1,27 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	var b bytes.Buffer
+	SetLogger(log.New(&b, "wtr: ", 0))
+	defer SetLogger(nil)
+	Logf("could not %s", "write")
+	if b.String() != "wtr: could not write\n" {
+		t.Fatalf("logged %q", b.String())
+	}
+
+	SetLogger(nil)
+	var std bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+	Logf("to the standard logger")
+	if !bytes.HasSuffix(std.Bytes(), []byte("to the standard logger\n")) || b.Len() != len("wtr: could not write\n") {
+		t.Fatalf("logged %q to the standard logger", std.String())
+	}
+}
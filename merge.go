@@ -0,0 +1,91 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+)
+
+// MergeConflict is a row discarded by Merge as its key (see Row.Key) is
+// that of a row kept, but its OFCOM columns differ.
+type MergeConflict struct {
+	Kept, Discarded *Row
+	Changes         []FieldChange // from the kept row to the discarded one
+}
+
+// MergeReport is the result of Merge or MergeCSVs.
+type MergeReport struct {
+	Rows       int // rows merged
+	Duplicates int // rows discarded as the same as a row kept
+	Conflicts  []MergeConflict
+}
+
+// String summarises the report, eg. "100 rows, 3 duplicates, 2 conflicts".
+func (report *MergeReport) String() string {
+	return fmt.Sprintf("%d rows, %d duplicates, %d conflicts", report.Rows, report.Duplicates, len(report.Conflicts))
+}
+
+// Merge returns the rows of the collection followed by those of other,
+// one row per key (see Row.Key), as the set operations, the first. The
+// header is that of the collection followed by the columns of other's
+// header not in it, so no column of either is lost. Rows discarded that
+// differ from the row kept are reported as conflicts.
+//
+// The rows are shared with the collection and other, see Clone.
+func (collection *Collection) Merge(other *Collection) (*Collection, *MergeReport) {
+	report := &MergeReport{}
+	return collection.merge(report, other), report
+}
+
+// merge is Merge of any number of others, adding to report.
+func (collection *Collection) merge(report *MergeReport, others ...*Collection) *Collection {
+	kept := make(map[string]*Row, len(collection.Rows))
+	merged := collection.withRows(make([]*Row, 0, len(collection.Rows)))
+	merged.Header = append([]string{}, collection.Header...)
+	present := make(map[string]bool, len(merged.Header))
+	for _, heading := range merged.Header {
+		present[heading] = true
+	}
+	for _, c := range append([]*Collection{collection}, others...) {
+		for _, heading := range c.Header {
+			if !present[heading] {
+				present[heading] = true
+				merged.Header = append(merged.Header, heading)
+			}
+		}
+		for _, row := range c.Rows {
+			report.Rows++
+			key := row.Key()
+			first, ok := kept[key]
+			if !ok {
+				kept[key] = row
+				merged.Rows = append(merged.Rows, row)
+				continue
+			}
+			if changes := fieldChanges(first, row); len(changes) > 0 {
+				report.Conflicts = append(report.Conflicts, MergeConflict{first, row, changes})
+				continue
+			}
+			report.Duplicates++
+		}
+	}
+	return merged
+}
+
+// MergeCSVs reads csvs, eg. partial extracts by licence class, and merges
+// them in order as Merge.
+func MergeCSVs(readers ...io.Reader) (*Collection, *MergeReport, error) {
+	collections := make([]*Collection, len(readers))
+	for i, reader := range readers {
+		collection, err := readCSV(reader)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not read csv %d", i+1)
+		}
+		collections[i] = collection
+	}
+	report := &MergeReport{}
+	if len(collections) == 0 {
+		return &Collection{Rows: []*Row{}}, report, nil
+	}
+	return collections[0].merge(report, collections[1:]...), report, nil
+}
@@ -0,0 +1,47 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeCSVs(t *testing.T) {
+	pointToPoint := "Licence Number,Frequency,NGR,Antenna AZIMUTH,Antenna ERP\n" +
+		"0000001/1,7.5,TQ 29400 81900,90,30\n" +
+		"0000002/1,13.0,SJ 84000 98000,270,20\n"
+	other := "Licence Number,Frequency,NGR,Antenna AZIMUTH,Licencee Company\n" +
+		"0000002/1,13.0,SJ 84000 98000,270,\n" + // as 0000002/1 but the ERP
+		"0000003/1,18.0,NZ 10000 20000,0,Acme\n" +
+		"0000003/1,18.0,NZ 10000 20000,0,Acme\n"
+	merged, report, err := MergeCSVs(strings.NewReader(pointToPoint), strings.NewReader(other))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Licence Number", "Frequency", "NGR", "Antenna AZIMUTH", "Antenna ERP", "Licencee Company"}
+	if !reflect.DeepEqual(merged.Header, want) {
+		t.Fatalf("header %q, want %q", merged.Header, want)
+	}
+	if got := licenceNumbers(merged); got != "0000001/1 0000002/1 0000003/1" {
+		t.Fatalf("merged %v", got)
+	}
+	if report.String() != "5 rows, 1 duplicates, 1 conflicts" {
+		t.Fatalf("report %v", report)
+	}
+	conflict := report.Conflicts[0]
+	if conflict.Kept != merged.Rows[1] || len(conflict.Changes) != 1 || conflict.Changes[0] != (FieldChange{"Antenna ERP", "20", ""}) {
+		t.Fatalf("conflict %+v", conflict)
+	}
+
+	if _, _, err := MergeCSVs(strings.NewReader(pointToPoint), strings.NewReader("Licence Number\n\"0000001/1\n")); err == nil {
+		t.Fatal("merged invalid csv")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	collection := testCollection(t, "Licence Number\n0000001/1\n")
+	merged, report := collection.Merge(collection)
+	if len(merged.Rows) != 1 || report.Duplicates != 1 || len(report.Conflicts) != 0 {
+		t.Fatalf("merged %v rows, report %v", len(merged.Rows), report)
+	}
+}
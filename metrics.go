@@ -0,0 +1,59 @@
+package wtrcsv
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives measurements of the package, eg. to be exported to
+// Prometheus. Its methods may be called concurrently.
+type Metrics interface {
+	// RowsParsed is called at the end of a read of a csv with the number
+	// of rows read, including a read ending in an error.
+	RowsParsed(n int)
+	// ParseErrors is called at the end of a read of a csv with the number
+	// of records that could not be read or converted, if any.
+	ParseErrors(n int)
+	// FilterDuration is called after a filter of a collection, eg. by
+	// Filter or FilterEach, with its duration.
+	FilterDuration(d time.Duration)
+	// RowsEmitted is called after a filter of a collection with the number
+	// of rows matching.
+	RowsEmitted(n int)
+}
+
+// metricsValue holds a Metrics in an atomic.Value, which requires the same
+// concrete type for every store.
+type metricsValue struct {
+	Metrics
+}
+
+var metrics atomic.Value
+
+func init() {
+	metrics.Store(metricsValue{})
+}
+
+// SetMetrics sets the Metrics of the package, nil for none.
+func SetMetrics(m Metrics) {
+	metrics.Store(metricsValue{m})
+}
+
+// currentMetrics returns the Metrics set by SetMetrics, or nil.
+func currentMetrics() Metrics {
+	return metrics.Load().(metricsValue).Metrics
+}
+
+// filterMetrics returns a function recording a filter, started now, with
+// the number of rows matching, or nil if there is no Metrics.
+func filterMetrics() func(emitted int) {
+	m := currentMetrics()
+	if m == nil {
+		return nil
+	}
+	start := time.Now()
+	return func(emitted int) {
+		m.FilterDuration(time.Since(start))
+		m.RowsEmitted(emitted)
+	}
+}
@@ -0,0 +1,75 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testMetrics totals the measurements.
+type testMetrics struct {
+	mu                                    sync.Mutex
+	parsed, parseErrors, filters, emitted int
+}
+
+func (m *testMetrics) RowsParsed(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parsed += n
+}
+
+func (m *testMetrics) ParseErrors(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parseErrors += n
+}
+
+func (m *testMetrics) FilterDuration(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.filters++
+}
+
+func (m *testMetrics) RowsEmitted(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emitted += n
+}
+
+func TestMetrics(t *testing.T) {
+	m := &testMetrics{}
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	const csv = "Licence Number,OS Easting\n0000001/1,1\n0000002/1,x\n0000003/1,3\n"
+	collection, _, err := ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(csv), nil); err == nil {
+		t.Fatal("read invalid csv")
+	}
+	if m.parsed != 3 || m.parseErrors != 2 {
+		t.Fatalf("%d rows parsed, %d parse errors", m.parsed, m.parseErrors)
+	}
+
+	first := func(row *Row) bool { return row.LicenceNumber == "0000001/1" }
+	collection.Filter(first)
+	collection.FilterParallel(2, first)
+	collection.FilterEach(func(*Row) error { return nil })
+	if m.filters != 3 || m.emitted != 4 {
+		t.Fatalf("%d filters, %d rows emitted", m.filters, m.emitted)
+	}
+
+	// A filter stopped by its callback is recorded with the rows emitted.
+	stop := errors.New("stop")
+	if err := collection.FilterEach(func(*Row) error { return stop }); err != stop {
+		t.Fatalf("unexpected error %v", err)
+	}
+	collection.Clone().FilterInPlace(first)
+	if m.filters != 5 || m.emitted != 6 {
+		t.Fatalf("%d filters, %d rows emitted", m.filters, m.emitted)
+	}
+}
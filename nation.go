@@ -0,0 +1,153 @@
+package wtrcsv
+
+import (
+	"strings"
+)
+
+// Nation is the UK nation, or the Crown Dependencies, of a station.
+type Nation string
+
+const (
+	NationUnknown           Nation = ""
+	NationEngland           Nation = "England"
+	NationScotland          Nation = "Scotland"
+	NationWales             Nation = "Wales"
+	NationNorthernIreland   Nation = "Northern Ireland"
+	NationCrownDependencies Nation = "Crown Dependencies"
+)
+
+// Nations are the nations in display order.
+var Nations = []Nation{
+	NationEngland, NationScotland, NationWales, NationNorthernIreland, NationCrownDependencies,
+}
+
+// scotlandBorder is the England-Scotland border simplified to a few km as
+// (easting, northing) points from west to east, starting in the Solway
+// Firth and ending in the North Sea north of Berwick.
+var scotlandBorder = [][2]float64{
+	{100000, 545000}, {300000, 552000}, {325000, 565000}, {333000, 567000},
+	{340000, 576000}, {350000, 578000}, {360000, 584000}, {370000, 600000},
+	{375000, 606000}, {385000, 615000}, {390000, 625000}, {394000, 645000},
+	{399000, 655000}, {402000, 660000}, {700000, 662000},
+}
+
+// walesBorder is the England-Wales border simplified to a few km as
+// (northing, easting) points from the Severn estuary to the Dee estuary.
+var walesBorder = [][2]float64{
+	{165000, 356000}, {190000, 356000}, {200000, 355000}, {212000, 353000},
+	{222000, 338000}, {235000, 330000}, {245000, 326000}, {255000, 327000},
+	{262000, 333000}, {272000, 330000}, {280000, 324000}, {290000, 325000},
+	{300000, 327000}, {310000, 326000}, {320000, 325000}, {330000, 327000},
+	{338000, 340000}, {345000, 342000}, {360000, 338000}, {370000, 337000},
+	{383000, 331000}, {400000, 330000},
+}
+
+// interpolate returns y at x along a polyline of (x, y) points in x order,
+// extending the end segments.
+func interpolate(points [][2]float64, x float64) float64 {
+	i := 1
+	for i < len(points)-1 && points[i][0] < x {
+		i++
+	}
+	p, q := points[i-1], points[i]
+	return p[1] + (q[1]-p[1])*(x-p[0])/(q[0]-p[0])
+}
+
+// osgbNation classifies a British National Grid position. The borders are
+// accurate to a few km.
+func osgbNation(easting, northing int) Nation {
+	e, n := float64(easting), float64(northing)
+	switch {
+	case northing >= 700000:
+		return NationScotland
+	case northing >= 500000:
+		if n > interpolate(scotlandBorder, e) {
+			return NationScotland
+		}
+		return NationEngland
+	case easting >= 200000 && easting < 300000 && northing >= 400000:
+		return NationCrownDependencies // the Isle of Man, square SC
+	case easting < 200000:
+		if northing >= 100000 {
+			return NationWales // Pembrokeshire
+		}
+		return NationEngland // Cornwall and the Scilly Isles
+	case easting < 300000:
+		if northing >= 165000 && northing < 400000 {
+			return NationWales // north of the Bristol Channel
+		}
+		return NationEngland
+	case easting < 400000 && northing >= 165000 && northing < 400000:
+		if e < interpolate(walesBorder, n) {
+			return NationWales
+		}
+		return NationEngland
+	}
+	return NationEngland
+}
+
+// Nation returns the nation of the station of a Row. It is derived from
+// the NGR, which is an Irish Grid reference (a single letter) in Northern
+// Ireland and a square W.. in the Channel Islands, or failing that the OS
+// columns. Without either, WGS84 or latitude and longitude columns
+// identify only Northern Ireland and the Crown Dependencies.
+func (row *Row) Nation() Nation {
+	ngr := strings.ToUpper(strings.Replace(row.NGR, " ", "", -1))
+	if len(ngr) > 1 && len(ngr)%2 == 1 && ngr[0] >= 'A' && ngr[0] <= 'Z' && ngr[0] != 'I' && isDigits(ngr[1:]) {
+		return NationNorthernIreland
+	}
+	if len(ngr) >= 2 && ngr[0] == 'W' && ngr[1] >= 'A' && ngr[1] <= 'Z' && isDigits(ngr[2:]) {
+		return NationCrownDependencies
+	}
+	if e, n, ok := rowOS(row); ok {
+		return osgbNation(e, n)
+	}
+
+	lat, lon, ok := rowLatLon(row)
+	switch {
+	case !ok:
+		return NationUnknown
+	case lat >= 53.9 && lat <= 55.4 && lon >= -8.2 && lon <= -5.4:
+		return NationNorthernIreland
+	case lat >= 54.0 && lat <= 54.45 && lon >= -4.85 && lon <= -4.3:
+		return NationCrownDependencies // the Isle of Man
+	case lat >= 49.1 && lat <= 49.8 && lon >= -2.8 && lon <= -1.9:
+		return NationCrownDependencies // the Channel Islands
+	}
+	return NationUnknown
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// KeyNation keys a Row by its nation, eg. to count licences by nation.
+func KeyNation(row *Row) string {
+	return string(row.Nation())
+}
+
+// FilterNations returns a FilterFn keeping the rows of any of the nations.
+func FilterNations(nations ...Nation) FilterFn {
+	lookup := make(map[Nation]bool, len(nations))
+	for _, nation := range nations {
+		lookup[nation] = true
+	}
+	return func(row *Row) bool {
+		return lookup[row.Nation()]
+	}
+}
+
+// CountByNation returns the number of rows of each nation, including
+// NationUnknown.
+func (collection *Collection) CountByNation() map[Nation]int {
+	counts := make(map[Nation]int)
+	for _, row := range collection.Rows {
+		counts[row.Nation()]++
+	}
+	return counts
+}
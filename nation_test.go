@@ -0,0 +1,59 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestNation(t *testing.T) {
+	for _, test := range []struct {
+		row  Row
+		want Nation
+	}{
+		{Row{NGR: "TQ 29400 81900"}, NationEngland},           // London
+		{Row{NGR: "NT 25700 73500"}, NationScotland},          // Edinburgh
+		{Row{NGR: "NT 99000 52000"}, NationEngland},           // Berwick-upon-Tweed
+		{Row{NGR: "NY 40000 56000"}, NationEngland},           // Carlisle
+		{Row{NGR: "NY 36000 84000"}, NationScotland},          // Langholm
+		{Row{NGR: "NX 97000 18000"}, NationEngland},           // Whitehaven
+		{Row{NGR: "NX 97000 76000"}, NationScotland},          // Dumfries
+		{Row{NGR: "HU 47000 41000"}, NationScotland},          // Lerwick
+		{Row{NGR: "ST 18000 76000"}, NationWales},             // Cardiff
+		{Row{NGR: "ST 58000 72000"}, NationEngland},           // Bristol
+		{Row{NGR: "SJ 40000 66000"}, NationEngland},           // Chester
+		{Row{NGR: "SJ 33000 50000"}, NationWales},             // Wrexham
+		{Row{NGR: "SO 32300 24200"}, NationWales},             // Hay-on-Wye
+		{Row{NGR: "SO 50000 24000"}, NationEngland},           // Hereford
+		{Row{NGR: "SS 65000 93000"}, NationWales},             // Swansea
+		{Row{NGR: "SS 56000 33000"}, NationEngland},           // Barnstaple
+		{Row{NGR: "SM 95000 15000"}, NationWales},             // Milford Haven
+		{Row{NGR: "SW 47000 30000"}, NationEngland},           // Penzance
+		{Row{NGR: "SH 58000 72000"}, NationWales},             // Bangor
+		{Row{NGR: "SC 38000 75000"}, NationCrownDependencies}, // Douglas
+		{Row{NGR: "WV 65000 48000"}, NationCrownDependencies}, // Jersey
+		{Row{NGR: "J 33000 74000"}, NationNorthernIreland},    // Belfast
+		{Row{OsEasting: 325700, OsNorthing: 673500}, NationScotland},
+		{Row{Wgs84LongitudeAsString: "-5.93", Wgs84Longitude: -5.93, Wgs84LatitudeAsString: "54.6", Wgs84Latitude: 54.6}, NationNorthernIreland},
+		{Row{Wgs84LongitudeAsString: "-0.12", Wgs84Longitude: -0.12, Wgs84LatitudeAsString: "51.5", Wgs84Latitude: 51.5}, NationUnknown},
+		{Row{}, NationUnknown},
+	} {
+		if got := test.row.Nation(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.row.NGR, got, test.want)
+		}
+	}
+}
+
+func TestFilterNations(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR
+0000001/1,TQ 29400 81900
+0000002/1,NT 25700 73500
+0000003/1,J 33000 74000
+0000004/1,
+`)
+	if got := licenceNumbers(collection.Filter(FilterNations(NationScotland, NationNorthernIreland))); got != "0000002/1 0000003/1" {
+		t.Errorf("got %s", got)
+	}
+	counts := collection.CountByNation()
+	if counts[NationEngland] != 1 || counts[NationUnknown] != 1 || len(counts) != 4 {
+		t.Errorf("wrong counts %v", counts)
+	}
+}
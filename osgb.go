@@ -0,0 +1,60 @@
+package wtrcsv
+
+import (
+	"strings"
+)
+
+// parseNGR converts an Ordnance Survey National Grid Reference (eg.
+// "TQ 29400 81900", with 0 to 5 digits each for easting and northing) to
+// eastings and northings in metres. A reference with fewer digits is the
+// south west corner of its square.
+func parseNGR(ngr string) (easting, northing int, ok bool) {
+	s := strings.ToUpper(strings.Replace(ngr, " ", "", -1))
+	if len(s) < 2 || len(s)%2 != 0 || len(s) > 12 {
+		return 0, 0, false
+	}
+
+	// The letters are a 500km square then a 100km square, each a 5x5 grid
+	// of letters without I, from the top left.
+	l1, l2 := int(s[0])-'A', int(s[1])-'A'
+	if l1 < 0 || l1 > 25 || l2 < 0 || l2 > 25 || l1 == 'I'-'A' || l2 == 'I'-'A' {
+		return 0, 0, false
+	}
+	if l1 > 'I'-'A' {
+		l1--
+	}
+	if l2 > 'I'-'A' {
+		l2--
+	}
+	e100km := ((l1-2)%5+5)%5*5 + l2%5
+	n100km := 19 - l1/5*5 - l2/5
+	if e100km < 0 || e100km > 6 || n100km < 0 || n100km > 12 {
+		return 0, 0, false // outside the grid
+	}
+
+	digits := s[2:]
+	half := len(digits) / 2
+	e, n := 0, 0
+	for i := 0; i < half; i++ {
+		de, dn := digits[i], digits[half+i]
+		if de < '0' || de > '9' || dn < '0' || dn > '9' {
+			return 0, 0, false
+		}
+		e = e*10 + int(de-'0')
+		n = n*10 + int(dn-'0')
+	}
+	for i := half; i < 5; i++ {
+		e *= 10
+		n *= 10
+	}
+	return e100km*100000 + e, n100km*100000 + n, true
+}
+
+// rowOS returns the location of a Row on the British National Grid, from
+// the OS Easting and OS Northing columns if present, otherwise from the NGR.
+func rowOS(row *Row) (easting, northing int, ok bool) {
+	if row.OsEasting != 0 || row.OsNorthing != 0 {
+		return row.OsEasting, row.OsNorthing, true
+	}
+	return parseNGR(row.NGR)
+}
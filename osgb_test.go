@@ -0,0 +1,28 @@
+package wtrcsv
+
+import "testing"
+
+func TestParseNGR(t *testing.T) {
+	for _, test := range []struct {
+		ngr               string
+		easting, northing int
+		ok                bool
+	}{
+		{"TQ 29400 81900", 529400, 181900, true},
+		{"tq2940081900", 529400, 181900, true},
+		{"NT 25700 73500", 325700, 673500, true},
+		{"SV 00000 00000", 0, 0, true},
+		{"HU 45000 40000", 445000, 1140000, true},
+		{"TQ 294 819", 529400, 181900, true},
+		{"TQ", 500000, 100000, true},
+		{"TQ 2940 81900", 0, 0, false},
+		{"TI 29400 81900", 0, 0, false},
+		{"TQ 2940X 81900", 0, 0, false},
+		{"", 0, 0, false},
+	} {
+		e, n, ok := parseNGR(test.ngr)
+		if e != test.easting || n != test.northing || ok != test.ok {
+			t.Errorf("%q: got %d, %d, %v; want %d, %d, %v", test.ngr, e, n, ok, test.easting, test.northing, test.ok)
+		}
+	}
+}
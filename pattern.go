@@ -0,0 +1,57 @@
+package wtrcsv
+
+import (
+	"sort"
+)
+
+// AntennaPattern gives the angular discrimination of an antenna, ie. the
+// attenuation in dB relative to boresight, at an angle off its azimuth.
+type AntennaPattern interface {
+	DiscriminationDB(offAxisDeg float64) float64
+}
+
+// ConePattern has no discrimination within half of BeamwidthDeg of the
+// azimuth and OffBeamDiscriminationDB outside it.
+type ConePattern struct {
+	BeamwidthDeg            float64
+	OffBeamDiscriminationDB float64
+}
+
+// DiscriminationDB implements AntennaPattern.
+func (pattern ConePattern) DiscriminationDB(offAxisDeg float64) float64 {
+	if offAxisDeg <= pattern.BeamwidthDeg/2 {
+		return 0
+	}
+	return pattern.OffBeamDiscriminationDB
+}
+
+// EnvelopePoint is a point of a radiation pattern envelope.
+type EnvelopePoint struct {
+	OffAxisDeg       float64
+	DiscriminationDB float64
+}
+
+// EnvelopePattern is a radiation pattern envelope (eg. an ETSI EN 302 217
+// antenna class) given as points in increasing angle. Discrimination is
+// interpolated linearly between the points and held constant beyond the
+// first and last points.
+type EnvelopePattern []EnvelopePoint
+
+// DiscriminationDB implements AntennaPattern.
+func (pattern EnvelopePattern) DiscriminationDB(offAxisDeg float64) float64 {
+	if len(pattern) == 0 {
+		return 0
+	}
+	i := sort.Search(len(pattern), func(i int) bool {
+		return pattern[i].OffAxisDeg >= offAxisDeg
+	})
+	if i == 0 {
+		return pattern[0].DiscriminationDB
+	}
+	if i == len(pattern) {
+		return pattern[len(pattern)-1].DiscriminationDB
+	}
+	p0, p1 := pattern[i-1], pattern[i]
+	fraction := (offAxisDeg - p0.OffAxisDeg) / (p1.OffAxisDeg - p0.OffAxisDeg)
+	return p0.DiscriminationDB + fraction*(p1.DiscriminationDB-p0.DiscriminationDB)
+}
@@ -0,0 +1,40 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestPatterns(t *testing.T) {
+	cone := ConePattern{BeamwidthDeg: 10, OffBeamDiscriminationDB: 25}
+	if cone.DiscriminationDB(5) != 0 || cone.DiscriminationDB(6) != 25 {
+		t.Fatal("wrong cone discrimination")
+	}
+
+	envelope := EnvelopePattern{{5, 0}, {10, 20}, {90, 40}}
+	for _, tc := range []struct{ angle, want float64 }{
+		{0, 0}, {5, 0}, {7.5, 10}, {50, 30}, {180, 40},
+	} {
+		if got := envelope.DiscriminationDB(tc.angle); got != tc.want {
+			t.Fatalf("envelope at %v: want %v, got %v", tc.angle, tc.want, got)
+		}
+	}
+}
+
+func TestCheckCoordinationPattern(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Frequency Type,Antenna AZIMUTH,WGS84 Longitude,WGS84 Latitude
+0000001/1,7500,MHz,240,0.1,51.5
+`)
+	// 30 degrees off both azimuths.
+	proposal := Proposal{Latitude: 51.5, Longitude: 0, FrequencyMHz: 7500, AzimuthDeg: 60,
+		Pattern: EnvelopePattern{{0, 0}, {30, 15}}}
+	options := &CoordinationOptions{PatternFor: func(row *Row) AntennaPattern {
+		return EnvelopePattern{{0, 0}, {30, 20}}
+	}}
+	conflicts := collection.CheckCoordination(proposal, options)
+	if len(conflicts) != 1 {
+		t.Fatalf("wrong number of conflicts: %v", len(conflicts))
+	}
+	if d := conflicts[0].DiscriminationDB; d < 34.9 || d > 35.1 {
+		t.Fatalf("wrong discrimination: %v", d)
+	}
+}
@@ -0,0 +1,143 @@
+package wtrcsv
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"math"
+)
+
+// polygon is a GeoJSON polygon: an exterior ring then any holes, each a
+// list of [longitude, latitude] positions.
+type polygon struct {
+	rings [][][2]float64
+	bbox  BBox
+}
+
+func newPolygon(rings [][][]float64) (polygon, error) {
+	if len(rings) == 0 {
+		return polygon{}, errors.New("polygon has no rings")
+	}
+	p := polygon{bbox: BBox{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}}
+	for _, ring := range rings {
+		if len(ring) < 4 {
+			return polygon{}, errors.New("polygon ring has fewer than four positions")
+		}
+		positions := make([][2]float64, len(ring))
+		for i, position := range ring {
+			if len(position) < 2 {
+				return polygon{}, errors.New("position has fewer than two coordinates")
+			}
+			positions[i] = [2]float64{position[0], position[1]}
+			p.bbox = p.bbox.union(pointBBox(position[0], position[1]))
+		}
+		p.rings = append(p.rings, positions)
+	}
+	return p, nil
+}
+
+// contains reports whether a point is inside the polygon by the even-odd
+// rule over all of its rings, so that a point in a hole is outside.
+func (p polygon) contains(x, y float64) bool {
+	if !p.bbox.Contains(x, y) {
+		return false
+	}
+	inside := false
+	for _, ring := range p.rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			xi, yi := ring[i][0], ring[i][1]
+			xj, yj := ring[j][0], ring[j][1]
+			if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// geoJSONPolygons returns the polygons of a Polygon or MultiPolygon
+// geometry, or of every feature of a Feature or FeatureCollection.
+func geoJSONPolygons(b []byte) ([]polygon, error) {
+	var object struct {
+		Type        string            `json:"type"`
+		Coordinates json.RawMessage   `json:"coordinates"`
+		Geometry    json.RawMessage   `json:"geometry"`
+		Features    []json.RawMessage `json:"features"`
+		Geometries  []json.RawMessage `json:"geometries"`
+	}
+	if err := json.Unmarshal(b, &object); err != nil {
+		return nil, errors.Wrap(err, "could not decode GeoJSON")
+	}
+
+	var polygons []polygon
+	switch object.Type {
+	case "Polygon":
+		var rings [][][]float64
+		if err := json.Unmarshal(object.Coordinates, &rings); err != nil {
+			return nil, errors.Wrap(err, "could not decode Polygon coordinates")
+		}
+		p, err := newPolygon(rings)
+		if err != nil {
+			return nil, err
+		}
+		polygons = append(polygons, p)
+	case "MultiPolygon":
+		var multi [][][][]float64
+		if err := json.Unmarshal(object.Coordinates, &multi); err != nil {
+			return nil, errors.Wrap(err, "could not decode MultiPolygon coordinates")
+		}
+		for i, rings := range multi {
+			p, err := newPolygon(rings)
+			if err != nil {
+				return nil, errors.Wrapf(err, "polygon %d", i)
+			}
+			polygons = append(polygons, p)
+		}
+	case "Feature":
+		if len(object.Geometry) == 0 || string(object.Geometry) == "null" {
+			return nil, nil
+		}
+		return geoJSONPolygons(object.Geometry)
+	case "FeatureCollection", "GeometryCollection":
+		members := object.Features
+		if object.Type == "GeometryCollection" {
+			members = object.Geometries
+		}
+		for i, member := range members {
+			p, err := geoJSONPolygons(member)
+			if err != nil {
+				return nil, errors.Wrapf(err, "member %d", i)
+			}
+			polygons = append(polygons, p...)
+		}
+	default:
+		return nil, errors.Errorf("GeoJSON type \"%s\" is not a polygon", object.Type)
+	}
+	return polygons, nil
+}
+
+// FilterWithinPolygon returns a FilterFn keeping the rows located (as
+// FilterBoundingBox) inside a GeoJSON Polygon or MultiPolygon geometry, in
+// WGS84 longitude and latitude. Holes are excluded. A Feature or
+// FeatureCollection may be given for the union of its polygons; other
+// geometries within it are an error.
+func FilterWithinPolygon(geometry []byte) (FilterFn, error) {
+	polygons, err := geoJSONPolygons(geometry)
+	if err != nil {
+		return nil, err
+	}
+	if len(polygons) == 0 {
+		return nil, errors.New("GeoJSON has no polygons")
+	}
+	return func(row *Row) bool {
+		lat, lon, ok := rowLatLon(row)
+		if !ok {
+			return false
+		}
+		for _, p := range polygons {
+			if p.contains(lon, lat) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
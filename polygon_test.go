@@ -0,0 +1,51 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+const polygonCSV = `Licence Number,WGS84 Longitude,WGS84 Latitude
+0000001/1,0.5,0.5
+0000002/1,2.5,2.5
+0000003/1,5.5,5.5
+0000004/1,8,8
+0000005/1,,
+`
+
+func TestFilterWithinPolygon(t *testing.T) {
+	collection := testCollection(t, polygonCSV)
+	// A square from 0 to 4 with a hole from 2 to 3, and a square from 5
+	// to 6.
+	square := `[[0,0],[4,0],[4,4],[0,4],[0,0]]`
+	hole := `[[2,2],[3,2],[3,3],[2,3],[2,2]]`
+	other := `[[5,5],[6,5],[6,6],[5,6],[5,5]]`
+	for _, test := range []struct {
+		geometry, want string
+	}{
+		{`{"type":"Polygon","coordinates":[` + square + `]}`, "0000001/1 0000002/1"},
+		{`{"type":"Polygon","coordinates":[` + square + `,` + hole + `]}`, "0000001/1"},
+		{`{"type":"MultiPolygon","coordinates":[[` + square + `,` + hole + `],[` + other + `]]}`, "0000001/1 0000003/1"},
+		{`{"type":"FeatureCollection","features":[` +
+			`{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":[` + other + `]}},` +
+			`{"type":"Feature","properties":{},"geometry":null}]}`, "0000003/1"},
+	} {
+		filter, err := FilterWithinPolygon([]byte(test.geometry))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := licenceNumbers(collection.Filter(filter)); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.geometry, got, test.want)
+		}
+	}
+
+	for _, geometry := range []string{
+		`{"type":"Point","coordinates":[0,0]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,1],[0,0]]]}`,
+		`{"type":"Feature","geometry":null}`,
+		`nonsense`,
+	} {
+		if _, err := FilterWithinPolygon([]byte(geometry)); err == nil {
+			t.Errorf("%s accepted", geometry)
+		}
+	}
+}
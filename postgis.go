@@ -0,0 +1,156 @@
+package wtrcsv
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// PostGISOptions control WritePostGIS.
+type PostGISOptions struct {
+	Table string // default "licences"
+	// Columns are the headings to write, by default the collection's header.
+	Columns     []string
+	CreateTable bool // create the table and spatial indexes if needed
+	// OSGB adds a geom_27700 column (British National Grid) populated from
+	// the OS eastings and northings as well as the WGS84 geom column.
+	OSGB bool
+	// GeometryOptions are the policy for rows without WGS84 coordinates.
+	// Substituted centroids are WGS84 only.
+	GeometryOptions
+}
+
+const (
+	postgisGeometry         = "geom"
+	postgisGeometryOSGB     = "geom_27700"
+	postgisGeometryType     = "geometry(Point,4326)"
+	postgisGeometryTypeOSGB = "geometry(Point,27700)"
+)
+
+func (options *PostGISOptions) loader(header []string) *SQLLoader {
+	loader := SQLLoader{Dialect: DialectPostgres, Columns: header}
+	if options != nil {
+		loader.Table = options.Table
+		if options.Columns != nil {
+			loader.Columns = options.Columns
+		}
+	}
+	return &loader
+}
+
+// postgisStatements returns the DDL (if creating the table) and the COPY
+// statement.
+func postgisStatements(loader *SQLLoader, osgb, create bool) (ddl []string, copyStatement string) {
+	d := loader.Dialect
+	table := d.QuoteIdentifier(loader.table())
+	geometries := []string{postgisGeometry}
+	if osgb {
+		geometries = append(geometries, postgisGeometryOSGB)
+	}
+
+	if create {
+		ddl = loader.DDL(loader.Columns)
+		types := map[string]string{postgisGeometry: postgisGeometryType, postgisGeometryOSGB: postgisGeometryTypeOSGB}
+		for _, geometry := range geometries {
+			ddl = append(ddl,
+				"ALTER TABLE "+table+" ADD COLUMN IF NOT EXISTS "+d.QuoteIdentifier(geometry)+" "+types[geometry],
+				"CREATE INDEX IF NOT EXISTS "+d.QuoteIdentifier(loader.table()+"_"+geometry)+
+					" ON "+table+" USING GIST ("+d.QuoteIdentifier(geometry)+")")
+		}
+	}
+
+	names := make([]string, 0, len(loader.Columns)+len(geometries))
+	for _, heading := range loader.Columns {
+		names = append(names, d.QuoteIdentifier(loader.columnName(heading)))
+	}
+	for _, geometry := range geometries {
+		names = append(names, d.QuoteIdentifier(geometry))
+	}
+	copyStatement = "COPY " + table + " (" + strings.Join(names, ", ") + ") FROM STDIN"
+	return ddl, copyStatement
+}
+
+// ewktPoint returns a point as EWKT, or nil (NULL) if the row has no such
+// location.
+func ewktPoint(row *Row, osgb bool) interface{} {
+	if osgb {
+		if row.OsEasting == 0 && row.OsNorthing == 0 {
+			return nil
+		}
+		return "SRID=27700;POINT(" + strconv.Itoa(row.OsEasting) + " " + strconv.Itoa(row.OsNorthing) + ")"
+	}
+	if !row.hasWgs84() {
+		return nil
+	}
+	return "SRID=4326;POINT(" + strconv.FormatFloat(row.Wgs84Longitude, 'f', -1, 64) + " " +
+		strconv.FormatFloat(row.Wgs84Latitude, 'f', -1, 64) + ")"
+}
+
+// WritePostGIS writes the collection to a PostGIS table with a
+// geometry(Point,4326) column from the WGS84 columns (NULL where absent),
+// and optionally a geometry(Point,27700) column from the OS eastings and
+// northings. Rows are streamed with COPY ... FROM STDIN using the prepared
+// statement convention of github.com/lib/pq, so db must use that driver
+// (or one implementing the same convention). It returns the counts of rows
+// without coordinates. options may be nil.
+func (collection *Collection) WritePostGIS(db *sql.DB, options *PostGISOptions) (*GeometryReport, error) {
+	loader := options.loader(collection.Header)
+	osgb := options != nil && options.OSGB
+	create := options != nil && options.CreateTable
+	ddl, copyStatement := postgisStatements(loader, osgb, create)
+	var geometryOptions *GeometryOptions
+	if options != nil {
+		geometryOptions = &options.GeometryOptions
+	}
+	located, report := collection.locate(geometryOptions, true)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not begin transaction")
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	for _, statement := range ddl {
+		if _, err := tx.Exec(statement); err != nil {
+			return nil, errors.Wrapf(err, "could not execute \"%s\"", statement)
+		}
+	}
+
+	stmt, err := tx.Prepare(copyStatement)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not prepare COPY")
+	}
+	values := make([]interface{}, 0, len(loader.Columns)+2)
+	encoder := newRowEncoder(loader.Columns, located.computed)
+	for _, row := range located.Rows {
+		values = values[:0]
+		for j, value := range encoder.encode(row) {
+			if _, numerical := numericalColumns[loader.Columns[j]]; numerical && value == "" {
+				values = append(values, nil)
+			} else {
+				values = append(values, value)
+			}
+		}
+		values = append(values, ewktPoint(row, false))
+		if osgb {
+			values = append(values, ewktPoint(row, true))
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			stmt.Close()
+			return nil, errors.Wrapf(err, "could not copy licence %s", row.LicenceNumber)
+		}
+	}
+	// An Exec without arguments completes the COPY.
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return nil, errors.Wrap(err, "could not complete COPY")
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not close COPY")
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "could not commit")
+	}
+	return report, nil
+}
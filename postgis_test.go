@@ -0,0 +1,33 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestPostGISStatements(t *testing.T) {
+	options := &PostGISOptions{Table: "wtr", Columns: []string{"Licence Number"}, OSGB: true}
+	ddl, copyStatement := postgisStatements(options.loader(nil), true, true)
+	if copyStatement != `COPY "wtr" ("licence_number", "geom", "geom_27700") FROM STDIN` {
+		t.Fatalf("unexpected COPY: %v", copyStatement)
+	}
+	if len(ddl) != 5 || ddl[1] != `ALTER TABLE "wtr" ADD COLUMN IF NOT EXISTS "geom" geometry(Point,4326)` {
+		t.Fatalf("unexpected DDL: %v", ddl)
+	}
+	if ddl[4] != `CREATE INDEX IF NOT EXISTS "wtr_geom_27700" ON "wtr" USING GIST ("geom_27700")` {
+		t.Fatalf("unexpected index: %v", ddl[4])
+	}
+}
+
+func TestEWKTPoint(t *testing.T) {
+	row := &Row{Wgs84LongitudeAsString: "-0.1388", Wgs84LatitudeAsString: "51.5215",
+		Wgs84Longitude: -0.1388, Wgs84Latitude: 51.5215, OsEasting: 529400, OsNorthing: 181900}
+	if p := ewktPoint(row, false); p != "SRID=4326;POINT(-0.1388 51.5215)" {
+		t.Fatalf("unexpected WGS84 point: %v", p)
+	}
+	if p := ewktPoint(row, true); p != "SRID=27700;POINT(529400 181900)" {
+		t.Fatalf("unexpected OSGB point: %v", p)
+	}
+	if p := ewktPoint(&Row{}, false); p != nil {
+		t.Fatalf("missing location should be NULL: %v", p)
+	}
+}
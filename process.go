@@ -0,0 +1,44 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"io"
+)
+
+// ProcessCSV reads a csv, calling fn with the rows in order chunkSize at a
+// time, the last chunk perhaps fewer, rather than collecting them, eg. for
+// a bulk database load in bounded memory. It stops at the first error from
+// fn and returns it.
+//
+// The slice given to fn is reused for the next chunk, so must not be kept,
+// but the rows may be.
+func ProcessCSV(reader io.Reader, chunkSize int, fn func(rows []*Row) error) error {
+	_, _, err := ProcessCSVWithOptions(reader, chunkSize, nil, fn)
+	return err
+}
+
+// ProcessCSVWithOptions is as ProcessCSV with the options of
+// ReadCSVWithOptions, and returns the header of the rows and a ReadReport.
+// The report is returned whenever the header was read, even with an error.
+func ProcessCSVWithOptions(reader io.Reader, chunkSize int, options *ReadOptions, fn func(rows []*Row) error) ([]string, *ReadReport, error) {
+	if chunkSize < 1 {
+		return nil, nil, errors.Errorf("invalid chunk size %d", chunkSize)
+	}
+	chunk := make([]*Row, 0, chunkSize)
+	header, report, err := decodeCSV(reader, options, func(row *Row) error {
+		chunk = append(chunk, row)
+		if len(chunk) < chunkSize {
+			return nil
+		}
+		err := fn(chunk)
+		for i := range chunk {
+			chunk[i] = nil // so that the rows may be freed
+		}
+		chunk = chunk[:0]
+		return err
+	})
+	if err == nil && len(chunk) > 0 {
+		err = fn(chunk)
+	}
+	return header, report, err
+}
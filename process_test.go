@@ -0,0 +1,49 @@
+package wtrcsv
+
+import (
+	"github.com/pkg/errors"
+	"strings"
+	"testing"
+)
+
+const processCSV = "Licence Number,Frequency\n" +
+	"0000001/1,7.5\n0000002/1,13.0\n0000003/1,18.0\n0000004/1,23.0\n0000005/1,38.0\n"
+
+func TestProcessCSV(t *testing.T) {
+	for _, concurrency := range []int{0, 4} {
+		var chunks []string
+		header, _, err := ProcessCSVWithOptions(strings.NewReader(processCSV), 2, &ReadOptions{Concurrency: concurrency}, func(rows []*Row) error {
+			chunks = append(chunks, licenceNumbers(&Collection{Rows: rows}))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(header) != 2 {
+			t.Fatalf("header %q", header)
+		}
+		want := []string{"0000001/1 0000002/1", "0000003/1 0000004/1", "0000005/1"}
+		if strings.Join(chunks, ", ") != strings.Join(want, ", ") {
+			t.Fatalf("concurrency %d: chunks %q, want %q", concurrency, chunks, want)
+		}
+	}
+}
+
+func TestProcessCSVError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ProcessCSV(strings.NewReader(processCSV), 2, func(rows []*Row) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("error %v after %d calls", err, calls)
+	}
+
+	if err := ProcessCSV(strings.NewReader(processCSV), 0, func(rows []*Row) error { return nil }); err == nil {
+		t.Fatal("processed in chunks of 0")
+	}
+	if err := ProcessCSV(strings.NewReader("Licence Number\n\"0000001/1\n"), 2, func(rows []*Row) error { return nil }); err == nil {
+		t.Fatal("processed invalid csv")
+	}
+}
@@ -0,0 +1,71 @@
+package wtrcsv
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// Progress is the progress of a read or write of a csv, given to the
+// Progress function of ReadOptions or CSVOptions every progressInterval
+// rows and once at the end.
+type Progress struct {
+	Rows  int   // rows read or written so far
+	Bytes int64 // bytes read from the reader or written to the writer so far
+	// TotalRows is the number of rows to write, or 0 if unknown, as it is
+	// when reading.
+	TotalRows int
+	// TotalBytes is the size of the csv to read, or 0 if unknown, as it is
+	// when writing.
+	TotalBytes int64
+}
+
+// progressInterval is the number of rows between calls of a Progress
+// function.
+const progressInterval = 10000
+
+// Percent returns the percentage done, by bytes if TotalBytes is known,
+// otherwise by rows. ok is false if neither is known.
+func (progress Progress) Percent() (percent float64, ok bool) {
+	switch {
+	case progress.TotalBytes > 0:
+		return 100 * float64(progress.Bytes) / float64(progress.TotalBytes), true
+	case progress.TotalRows > 0:
+		return 100 * float64(progress.Rows) / float64(progress.TotalRows), true
+	}
+	return 0, false
+}
+
+// countingReader counts the bytes read through it. n may be loaded while
+// reading, by another goroutine.
+type countingReader struct {
+	n int64
+	r io.Reader
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// readerSize returns the number of bytes left to read from a reader, if it
+// is a regular file or has a Len method, as strings.Reader and
+// bytes.Reader do.
+func readerSize(reader io.Reader) int64 {
+	switch r := reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0
+		}
+		return info.Size() - offset
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// progressCSV returns a csv of n rows.
+func progressCSV(n int) string {
+	var b strings.Builder
+	b.WriteString("Licence Number,Frequency\n")
+	for i := 0; i < n; i++ {
+		b.WriteString("0000001/1,7.5\n")
+	}
+	return b.String()
+}
+
+func TestReadProgress(t *testing.T) {
+	csv := progressCSV(25000)
+	for _, concurrency := range []int{0, 4} {
+		var calls []Progress
+		options := &ReadOptions{Concurrency: concurrency, Progress: func(progress Progress) {
+			calls = append(calls, progress)
+		}}
+		if _, _, err := ReadCSVWithOptions(strings.NewReader(csv), options); err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 3 || calls[0].Rows != 10000 || calls[1].Rows != 20000 || calls[2].Rows != 25000 {
+			t.Fatalf("concurrency %d: progress %+v", concurrency, calls)
+		}
+		last := calls[2]
+		if percent, ok := last.Percent(); !ok || percent != 100 || last.TotalBytes != int64(len(csv)) {
+			t.Fatalf("concurrency %d: last progress %+v", concurrency, last)
+		}
+	}
+}
+
+func TestReadProgressFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "wtrcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	csv := progressCSV(10)
+	if _, err := f.WriteString(csv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	var last Progress
+	if _, _, err := ReadCSVWithOptions(f, &ReadOptions{Progress: func(progress Progress) { last = progress }}); err != nil {
+		t.Fatal(err)
+	}
+	if last.Rows != 10 || last.Bytes != int64(len(csv)) || last.TotalBytes != int64(len(csv)) {
+		t.Fatalf("progress %+v", last)
+	}
+}
+
+func TestWriteProgress(t *testing.T) {
+	collection := testCollection(t, progressCSV(15000))
+	var calls []Progress
+	var buf bytes.Buffer
+	if err := collection.WriteCSVWithOptions(&buf, &CSVOptions{Progress: func(progress Progress) {
+		calls = append(calls, progress)
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || calls[0].Rows != 10000 || calls[1].Rows != 15000 {
+		t.Fatalf("progress %+v", calls)
+	}
+	last := calls[1]
+	if percent, ok := last.Percent(); !ok || percent != 100 || last.Bytes != int64(buf.Len()) {
+		t.Fatalf("last progress %+v", last)
+	}
+	if _, ok := (Progress{Rows: 1}).Percent(); ok {
+		t.Fatal("percentage of unknown total")
+	}
+}
@@ -0,0 +1,265 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv/internal/protowire"
+	"io"
+	"strconv"
+)
+
+// The Row and Header messages of wtr.proto are encoded here directly in the
+// Protocol Buffers wire format, so no generated code or runtime is needed.
+
+const protoVersion = 1
+
+const (
+	protoFieldOsEasting  = 49
+	protoFieldOsNorthing = 50
+)
+
+// protoStringFields are the string fields of the Row message by number.
+var protoStringFields = [...]func(row *Row) *string{
+	1:  func(row *Row) *string { return &row.LicenceNumber },
+	2:  func(row *Row) *string { return &row.LicenceIssueDate },
+	3:  func(row *Row) *string { return &row.SidLatNS },
+	4:  func(row *Row) *string { return &row.SidLatDeg },
+	5:  func(row *Row) *string { return &row.SidLatMin },
+	6:  func(row *Row) *string { return &row.SidLatSec },
+	7:  func(row *Row) *string { return &row.SidLongEW },
+	8:  func(row *Row) *string { return &row.SidLongDeg },
+	9:  func(row *Row) *string { return &row.SidLongMin },
+	10: func(row *Row) *string { return &row.SidLongSec },
+	11: func(row *Row) *string { return &row.NGR },
+	12: func(row *Row) *string { return &row.Frequency },
+	13: func(row *Row) *string { return &row.FrequencyType },
+	14: func(row *Row) *string { return &row.StationType },
+	15: func(row *Row) *string { return &row.ChannelWidth },
+	16: func(row *Row) *string { return &row.ChannelWidthType },
+	17: func(row *Row) *string { return &row.HeightAboveSeaLevel },
+	18: func(row *Row) *string { return &row.AntennaErp },
+	19: func(row *Row) *string { return &row.AntennaErpType },
+	20: func(row *Row) *string { return &row.AntennaType },
+	21: func(row *Row) *string { return &row.AntennaGain },
+	22: func(row *Row) *string { return &row.AntennaAzimuth },
+	23: func(row *Row) *string { return &row.HorizontalElements },
+	24: func(row *Row) *string { return &row.VerticalElements },
+	25: func(row *Row) *string { return &row.AntennaHeight },
+	26: func(row *Row) *string { return &row.AntennaLocation },
+	27: func(row *Row) *string { return &row.EflUpperLower },
+	28: func(row *Row) *string { return &row.AntennaDirection },
+	29: func(row *Row) *string { return &row.AntennaElevation },
+	30: func(row *Row) *string { return &row.AntennaPolarisation },
+	31: func(row *Row) *string { return &row.AntennaName },
+	32: func(row *Row) *string { return &row.FeedingLoss },
+	33: func(row *Row) *string { return &row.FadeMargin },
+	34: func(row *Row) *string { return &row.EmissionCode },
+	35: func(row *Row) *string { return &row.ApCommentIntern },
+	36: func(row *Row) *string { return &row.Vector },
+	37: func(row *Row) *string { return &row.LicenseeSurname },
+	38: func(row *Row) *string { return &row.LicenseeFirstName },
+	39: func(row *Row) *string { return &row.LicenseeCompany },
+	40: func(row *Row) *string { return &row.Status },
+	41: func(row *Row) *string { return &row.Tradeable },
+	42: func(row *Row) *string { return &row.Publishable },
+	43: func(row *Row) *string { return &row.ProductCode },
+	44: func(row *Row) *string { return &row.ProductDescription },
+	45: func(row *Row) *string { return &row.ProductDescription31 },
+	46: func(row *Row) *string { return &row.ProductDescription32 },
+	47: func(row *Row) *string { return &row.Wgs84LongitudeAsString },
+	48: func(row *Row) *string { return &row.Wgs84LatitudeAsString },
+}
+
+// MarshalRow encodes a Row as a wtr.proto Row message. Empty fields are
+// omitted, as in proto3.
+func MarshalRow(row *Row) []byte {
+	b := make([]byte, 0, 256)
+	for field, get := range protoStringFields {
+		if get == nil {
+			continue
+		}
+		if s := *get(row); s != "" {
+			b = protowire.AppendString(b, field, s)
+		}
+	}
+	if row.OsEasting != 0 {
+		b = protowire.AppendInt64(b, protoFieldOsEasting, int64(row.OsEasting))
+	}
+	if row.OsNorthing != 0 {
+		b = protowire.AppendInt64(b, protoFieldOsNorthing, int64(row.OsNorthing))
+	}
+	return b
+}
+
+// UnmarshalRow decodes a wtr.proto Row message. Unknown fields are ignored
+// so that messages from newer versions of the schema can be read.
+func UnmarshalRow(b []byte) (*Row, error) {
+	var row Row
+	err := protowire.Fields(b, func(field *protowire.Field) error {
+		switch {
+		case field.Number < len(protoStringFields) && protoStringFields[field.Number] != nil:
+			if field.Wire != protowire.Bytes {
+				return errors.Errorf("field %d: wrong wire type", field.Number)
+			}
+			*protoStringFields[field.Number](&row) = string(field.Bytes)
+		case field.Number == protoFieldOsEasting:
+			row.OsEasting = int(int64(field.Varint))
+		case field.Number == protoFieldOsNorthing:
+			row.OsNorthing = int(int64(field.Varint))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode row")
+	}
+
+	// The WGS84 values are converted from the persistent representation.
+	if row.Wgs84LongitudeAsString != "" {
+		if row.Wgs84Longitude, err = strconv.ParseFloat(row.Wgs84LongitudeAsString, 64); err != nil {
+			return nil, errors.Wrap(err, "could not convert WGS84 longitude")
+		}
+	}
+	if row.Wgs84LatitudeAsString != "" {
+		if row.Wgs84Latitude, err = strconv.ParseFloat(row.Wgs84LatitudeAsString, 64); err != nil {
+			return nil, errors.Wrap(err, "could not convert WGS84 latitude")
+		}
+	}
+	return &row, nil
+}
+
+func marshalHeader(header []string) []byte {
+	b := protowire.AppendInt64(nil, 1, protoVersion)
+	for _, column := range header {
+		b = protowire.AppendString(b, 2, column)
+	}
+	return b
+}
+
+func unmarshalHeader(b []byte) (version int, header []string, err error) {
+	err = protowire.Fields(b, func(field *protowire.Field) error {
+		switch field.Number {
+		case 1:
+			version = int(field.Varint)
+		case 2:
+			header = append(header, string(field.Bytes))
+		}
+		return nil
+	})
+	return version, header, errors.Wrap(err, "could not decode header")
+}
+
+// ProtoWriter writes a length-prefixed snapshot stream (see wtr.proto).
+type ProtoWriter struct {
+	w *bufio.Writer
+}
+
+// NewProtoWriter writes the Header message and returns a ProtoWriter for the
+// rows.
+func NewProtoWriter(writer io.Writer, header []string) (*ProtoWriter, error) {
+	w := &ProtoWriter{bufio.NewWriter(writer)}
+	return w, w.writeMessage(marshalHeader(header))
+}
+
+func (w *ProtoWriter) writeMessage(b []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(b)))
+	if _, err := w.w.Write(buf[:n]); err != nil {
+		return errors.Wrap(err, "could not write message length")
+	}
+	_, err := w.w.Write(b)
+	return errors.Wrap(err, "could not write message")
+}
+
+// WriteRow writes a Row message.
+func (w *ProtoWriter) WriteRow(row *Row) error {
+	return w.writeMessage(MarshalRow(row))
+}
+
+// Flush writes any buffered data.
+func (w *ProtoWriter) Flush() error {
+	return errors.Wrap(w.w.Flush(), "could not flush")
+}
+
+// ProtoReader reads a snapshot stream written by ProtoWriter.
+type ProtoReader struct {
+	r      *bufio.Reader
+	Header []string
+	buf    []byte
+}
+
+// NewProtoReader reads the Header message and returns a ProtoReader for the
+// rows.
+func NewProtoReader(reader io.Reader) (*ProtoReader, error) {
+	r := &ProtoReader{r: bufio.NewReader(reader)}
+	b, err := r.readMessage()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read header")
+	}
+	version, header, err := unmarshalHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if version > protoVersion {
+		return nil, errors.Errorf("unsupported stream version %d", version)
+	}
+	r.Header = header
+	return r, nil
+}
+
+func (r *ProtoReader) readMessage() ([]byte, error) {
+	length, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err // io.EOF at the end of the stream
+	}
+	if uint64(cap(r.buf)) < length {
+		r.buf = make([]byte, length)
+	}
+	r.buf = r.buf[:length]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return nil, errors.Wrap(err, "could not read message")
+	}
+	return r.buf, nil
+}
+
+// ReadRow reads the next Row. It returns io.EOF after the last row.
+func (r *ProtoReader) ReadRow() (*Row, error) {
+	b, err := r.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalRow(b)
+}
+
+// WriteProto writes the collection as a snapshot stream.
+func (collection *Collection) WriteProto(writer io.Writer) error {
+	w, err := NewProtoWriter(writer, collection.Header)
+	if err != nil {
+		return err
+	}
+	for _, row := range collection.Rows {
+		if err := w.WriteRow(row); err != nil {
+			return errors.Wrapf(err, "licence %s", row.LicenceNumber)
+		}
+	}
+	return w.Flush()
+}
+
+// ReadProto reads a snapshot stream into a Collection.
+func ReadProto(reader io.Reader) (*Collection, error) {
+	r, err := NewProtoReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	collection := Collection{Header: r.Header, Rows: make([]*Row, 0)}
+	for {
+		row, err := r.ReadRow()
+		if err == io.EOF {
+			return &collection, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "row %d", len(collection.Rows)+1)
+		}
+		collection.Rows = append(collection.Rows, row)
+	}
+}
@@ -0,0 +1,50 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"github.com/recombinant/go-wtrcsv/internal/protowire"
+	"testing"
+)
+
+func TestProto(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Product Description 32,WGS84 Longitude,WGS84 Latitude,OS Easting,OS Northing
+0000001/1,7.5,Fixed Links,-0.138800,51.5215,529400,181900
+0000002/1,,,,,,
+`)
+	b := new(bytes.Buffer)
+	if err := collection.WriteProto(b); err != nil {
+		t.Fatal(err)
+	}
+	collection2, err := ReadProto(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameHeader(collection.Header, collection2.Header) || len(collection2.Rows) != 2 {
+		t.Fatalf("wrong collection: %v, %v rows", collection2.Header, len(collection2.Rows))
+	}
+	for i := range collection.Rows {
+		if *collection.Rows[i] != *collection2.Rows[i] {
+			t.Fatalf("row %d not round-tripped: %+v", i, collection2.Rows[i])
+		}
+	}
+}
+
+func TestUnmarshalRowUnknownFields(t *testing.T) {
+	b := MarshalRow(&Row{LicenceNumber: "0000001/1"})
+	// Fields from a newer schema: a string, a varint and a fixed64.
+	b = protowire.AppendString(b, 99, "future")
+	b = protowire.AppendInt64(b, 100, 42)
+	b = append(protowire.AppendTag(b, 101, protowire.Fixed64), 1, 2, 3, 4, 5, 6, 7, 8)
+
+	row, err := UnmarshalRow(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row.LicenceNumber != "0000001/1" {
+		t.Fatalf("wrong row: %+v", row)
+	}
+
+	if _, err := UnmarshalRow(b[:len(b)-3]); err == nil {
+		t.Fatal("expected error for truncated message")
+	}
+}
@@ -0,0 +1,211 @@
+package wtrcsv
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+// rainRates are the rain rates (mm/h) exceeded for 0.01% of an average year
+// in each ITU-R P.837-1 rain climatic zone.
+var rainRates = map[string]float64{
+	"A": 8, "B": 12, "C": 15, "D": 19, "E": 22, "F": 28, "G": 30, "H": 32,
+	"J": 35, "K": 42, "L": 60, "M": 63, "N": 95, "P": 145, "Q": 115,
+}
+
+// rainZone is a WGS84 box of an ITU-R P.837-1 rain climatic zone.
+type rainZone struct {
+	zone                           string
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// rainZones are a coarse reading of the P.837-1 map of the British Isles,
+// looked up in order: the wetter zone F of the west, then zone E.
+var rainZones = []rainZone{
+	{"F", 51.3, 59, -11, -4},     // Ireland, west Wales and western Scotland
+	{"F", 49.8, 51.3, -11, -3.5}, // south-west England and south Ireland
+	{"E", 49, 61, -11, 2},        // the rest, with the Channel Islands and Shetland
+}
+
+// RainZoneLookup returns the ITU-R rain climatic zone of a WGS84 location,
+// or "" outside the British Isles. The default is the coarse table of
+// rainZones; replace it for finer detail.
+var RainZoneLookup = func(lat, lon float64) string {
+	for _, zone := range rainZones {
+		if lat >= zone.minLat && lat < zone.maxLat && lon >= zone.minLon && lon < zone.maxLon {
+			return zone.zone
+		}
+	}
+	return ""
+}
+
+// RainRate returns the rain rate (mm/h) exceeded for 0.01% of the time in
+// an ITU-R rain zone.
+func RainRate(zone string) (float64, bool) {
+	rate, ok := rainRates[zone]
+	return rate, ok
+}
+
+// rainCoefficient are ITU-R P.838 regression coefficients for the specific
+// attenuation γ = k R^α of horizontally polarised signals (the worse case).
+type rainCoefficient struct {
+	frequencyGHz, k, alpha float64
+}
+
+var rainCoefficients = []rainCoefficient{
+	{1, 0.0000387, 0.912}, {2, 0.000154, 0.963}, {4, 0.000650, 1.121},
+	{6, 0.00175, 1.308}, {7, 0.00301, 1.332}, {8, 0.00454, 1.327},
+	{10, 0.0101, 1.276}, {12, 0.0188, 1.217}, {15, 0.0367, 1.154},
+	{20, 0.0751, 1.099}, {25, 0.124, 1.061}, {30, 0.187, 1.021},
+	{35, 0.263, 0.979}, {40, 0.350, 0.939}, {45, 0.442, 0.903},
+	{50, 0.536, 0.873}, {60, 0.707, 0.826}, {70, 0.851, 0.793},
+	{80, 0.975, 0.769},
+}
+
+// rainKAlpha interpolates the coefficients: k logarithmically and α
+// linearly against the logarithm of frequency. Frequencies outside 1-80GHz
+// use the nearest coefficients.
+func rainKAlpha(frequencyGHz float64) (k, alpha float64) {
+	cs := rainCoefficients
+	i := sort.Search(len(cs), func(i int) bool { return cs[i].frequencyGHz >= frequencyGHz })
+	if i < len(cs) && (i == 0 || cs[i].frequencyGHz == frequencyGHz) {
+		return cs[i].k, cs[i].alpha
+	}
+	if i == len(cs) {
+		return cs[len(cs)-1].k, cs[len(cs)-1].alpha
+	}
+	c0, c1 := cs[i-1], cs[i]
+	fraction := math.Log(frequencyGHz/c0.frequencyGHz) / math.Log(c1.frequencyGHz/c0.frequencyGHz)
+	k = math.Exp(math.Log(c0.k) + fraction*math.Log(c1.k/c0.k))
+	alpha = c0.alpha + fraction*(c1.alpha-c0.alpha)
+	return k, alpha
+}
+
+// RainEstimate is an estimate of the availability of a link limited by rain
+// fading, following the ITU-R P.530 prediction method.
+type RainEstimate struct {
+	Zone                string
+	RainRate            float64 // mm/h exceeded for 0.01% of the time
+	SpecificAttenuation float64 // dB/km at RainRate
+	Attenuation001      float64 // path attenuation (dB) exceeded for 0.01% of the time
+	Outage              float64 // percentage of time the fade margin is exceeded
+	Availability        float64 // percentage, 100 - Outage
+}
+
+// minOutage and maxOutage are the range of time percentages of the P.530
+// method; estimates are clamped to it.
+const minOutage, maxOutage = 0.001, 1.0
+
+// EstimateRainAvailability estimates the availability due to rain of a link
+// at a WGS84 location (eg. the midpoint of the path) with the frequency,
+// path length and fade margin. The outage is clamped to 0.001-1% of the
+// time, the range of the prediction method. The rain rate of zone E is
+// assumed outside the zones of RainZoneLookup.
+func EstimateRainAvailability(lat, lon, frequencyGHz, pathKm, fadeMarginDB float64) RainEstimate {
+	estimate := RainEstimate{Zone: RainZoneLookup(lat, lon)}
+	rate, ok := RainRate(estimate.Zone)
+	if !ok {
+		rate = rainRates["E"]
+	}
+	estimate.RainRate = rate
+
+	k, alpha := rainKAlpha(frequencyGHz)
+	estimate.SpecificAttenuation = k * math.Pow(rate, alpha)
+
+	// Effective path length.
+	d0 := 35 * math.Exp(-0.015*math.Min(rate, 100))
+	r := 1 / (1 + pathKm/d0)
+	estimate.Attenuation001 = estimate.SpecificAttenuation * pathKm * r
+
+	estimate.Outage = rainOutage(estimate.Attenuation001, fadeMarginDB)
+	estimate.Availability = 100 - estimate.Outage
+	return estimate
+}
+
+// rainOutage returns the time percentage p for which the attenuation exceeds
+// the fade margin, solving A_p / A_0.01 = 0.12 p^-(0.546 + 0.043 log10 p).
+func rainOutage(attenuation001, fadeMarginDB float64) float64 {
+	if attenuation001 <= 0 {
+		return minOutage
+	}
+	ratio := func(p float64) float64 {
+		return 0.12 * math.Pow(p, -(0.546+0.043*math.Log10(p)))
+	}
+	target := fadeMarginDB / attenuation001
+	if target >= ratio(minOutage) {
+		return minOutage
+	}
+	if target <= ratio(maxOutage) {
+		return maxOutage
+	}
+
+	// The ratio decreases with p; bisect on log p.
+	lo, hi := math.Log(minOutage), math.Log(maxOutage)
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if ratio(math.Exp(mid)) > target {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return math.Exp((lo + hi) / 2)
+}
+
+// midpoint returns the WGS84 location of the middle of the path of a link.
+func (link *Link) midpoint() (lat, lon float64, ok bool) {
+	latA, lonA, okA := rowLatLon(link.A)
+	latB, lonB, okB := rowLatLon(link.B)
+	if !okA || !okB {
+		return 0, 0, false
+	}
+	lat, lon = intermediatePoint(latA, lonA, latB, lonB, 0.5)
+	return lat, lon, true
+}
+
+// RainZone returns the ITU-R rain zone of the midpoint of the path of the
+// link, by RainZoneLookup. ok is false if either end is not located or the
+// midpoint is in no zone.
+func (link *Link) RainZone() (zone string, ok bool) {
+	lat, lon, ok := link.midpoint()
+	if !ok {
+		return "", false
+	}
+	zone = RainZoneLookup(lat, lon)
+	return zone, zone != ""
+}
+
+// RainAvailability estimates the availability due to rain of the link with
+// a fade margin, eg. that of its LinkBudget, by EstimateRainAvailability at
+// the midpoint of its path with its great-circle length and frequency. ok
+// is false if either end is not located or the link has no frequency.
+func (link *Link) RainAvailability(fadeMarginDB float64) (estimate RainEstimate, ok bool) {
+	lat, lon, ok := link.midpoint()
+	if !ok {
+		return RainEstimate{}, false
+	}
+	km, _ := link.PathLengthKm()
+	mhz, ok := link.FrequencyMHz()
+	if !ok {
+		return RainEstimate{}, false
+	}
+	return EstimateRainAvailability(lat, lon, mhz/1000, km, fadeMarginDB), true
+}
+
+// linkRain returns the rain zone of a link and its availability with the
+// fade margin of its LinkBudget, "" for those unknown, as exported.
+func linkRain(link *Link) (zone, availability string) {
+	zone, _ = link.RainZone()
+	budget, err := link.Budget()
+	if err != nil {
+		return zone, ""
+	}
+	fadeMargin, ok := budget.FadeMarginDB()
+	if !ok {
+		return zone, ""
+	}
+	if estimate, ok := link.RainAvailability(fadeMargin); ok {
+		availability = strconv.FormatFloat(estimate.Availability, 'f', 4, 64)
+	}
+	return zone, availability
+}
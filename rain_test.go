@@ -0,0 +1,101 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRainKAlpha(t *testing.T) {
+	k, alpha := rainKAlpha(20)
+	if k != 0.0751 || alpha != 1.099 {
+		t.Fatalf("wrong tabulated coefficients: %v, %v", k, alpha)
+	}
+	k, alpha = rainKAlpha(22)
+	if k <= 0.0751 || k >= 0.124 || alpha >= 1.099 || alpha <= 1.061 {
+		t.Fatalf("wrong interpolated coefficients: %v, %v", k, alpha)
+	}
+}
+
+func TestRainZoneLookup(t *testing.T) {
+	for _, test := range []struct {
+		lat, lon float64
+		zone     string
+	}{
+		{51.5, -0.1, "E"},   // London
+		{55.95, -3.19, "E"}, // Edinburgh
+		{56.8, -5.1, "F"},   // Fort William
+		{50.26, -5.05, "F"}, // Truro
+		{53.35, -6.26, "F"}, // Dublin
+		{48.86, 2.35, ""},   // Paris
+	} {
+		if zone := RainZoneLookup(test.lat, test.lon); zone != test.zone {
+			t.Errorf("%v, %v: zone %q, want %q", test.lat, test.lon, zone, test.zone)
+		}
+	}
+}
+
+func TestEstimateRainAvailability(t *testing.T) {
+	// 10km at 23GHz in zone E.
+	estimate := EstimateRainAvailability(51.5, -0.1, 23, 10, 40)
+	if estimate.Zone != "E" || estimate.RainRate != 22 {
+		t.Fatalf("wrong zone: %+v", estimate)
+	}
+	if math.Abs(estimate.SpecificAttenuation-2.7) > 0.3 {
+		t.Fatalf("wrong specific attenuation: %v", estimate.SpecificAttenuation)
+	}
+
+	// At the 0.01% attenuation the outage is 0.01%.
+	estimate = EstimateRainAvailability(51.5, -0.1, 23, 10, estimate.Attenuation001)
+	if math.Abs(estimate.Outage-0.01) > 0.0005 || math.Abs(estimate.Availability-99.99) > 0.0005 {
+		t.Fatalf("wrong outage: %v", estimate.Outage)
+	}
+
+	// More fade margin, less outage, clamped to the method's range.
+	if e := EstimateRainAvailability(51.5, -0.1, 23, 10, 20); e.Outage <= estimate.Outage {
+		t.Fatalf("outage should increase with less margin: %v", e.Outage)
+	}
+	if e := EstimateRainAvailability(51.5, -0.1, 23, 10, 1000); e.Outage != 0.001 {
+		t.Fatalf("outage should be clamped: %v", e.Outage)
+	}
+}
+
+func TestLinkRain(t *testing.T) {
+	const csv = `Licence Number,NGR,Frequency,Frequency Type,Antenna ERP,Antenna ERP type,Fade Margin,WGS84 Longitude,WGS84 Latitude
+0000001/1,TQ 00000 00000,23000,MHz,10,dBW,40,-0.2,51.5
+0000001/1,TQ 10000 00000,23100,MHz,10,dBW,45,0.0,51.5
+`
+	links := testCollection(t, csv).Links()
+	link := links.Links[0]
+	if zone, ok := link.RainZone(); !ok || zone != "E" {
+		t.Fatalf("zone %q %v", zone, ok)
+	}
+	estimate, ok := link.RainAvailability(40)
+	km, _ := link.PathLengthKm()
+	if want := EstimateRainAvailability(51.5, -0.1, 23, km, 40); !ok || math.Abs(estimate.Availability-want.Availability) > 1e-6 {
+		t.Fatalf("availability %+v, want %+v", estimate, want)
+	}
+
+	var b bytes.Buffer
+	if err := links.WriteLinkPlanCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "," + strconv.FormatFloat(estimate.Availability, 'f', 4, 64) + "\n"
+	if !strings.HasSuffix(b.String(), ",E"+want) {
+		t.Fatalf("wrote %q, want the zone and availability %q", b.String(), want)
+	}
+	b.Reset()
+	if err := links.WriteGeoJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"Rain Zone":"E"`) {
+		t.Fatalf("wrote %s", b.String())
+	}
+
+	unlocated := testCollection(t, linksTestCSV).Links().Links[1]
+	if _, ok := unlocated.RainAvailability(40); ok {
+		t.Fatal("availability of a link that is not located")
+	}
+}
@@ -0,0 +1,423 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ReadOptions are the options of ReadCSVWithOptions. A nil *ReadOptions is
+// the default.
+type ReadOptions struct {
+	// FailOnSchemaDrift makes it an error for the header to have unknown
+	// columns or to lack any of the columns of the original OFCOM csv (see
+	// SchemaReport).
+	FailOnSchemaDrift bool
+	// StrictHeader makes it an error for the header not to be exactly that
+	// of the original OFCOM csv, the same headings in the same order,
+	// without aliases or munged columns.
+	StrictHeader bool
+	// Lenient skips the records that cannot be read or converted, listing
+	// them in ReadReport.RowErrors, rather than failing.
+	Lenient bool
+	// Columns are the headings of the only columns to read, eg. to save
+	// time and memory when an analysis needs just a few. The other columns
+	// are left empty and out of the header of the Collection. It is an
+	// error for a heading not to be one of Headings. Nil reads every
+	// column.
+	Columns []string
+	// Intern makes the rows share the storage of equal values of the
+	// columns of InternColumns, which repeat thousands of times in the
+	// register, to save memory.
+	Intern bool
+	// Concurrency is the number of goroutines converting records to Rows,
+	// while one reads the csv. The order of the rows is kept. 0 or 1 reads
+	// on the calling goroutine alone. Each goroutine interns separately.
+	Concurrency int
+	// Comma is the delimiter, eg. ';' or '\t'. 0 is a comma.
+	Comma rune
+	// DetectDelimiter sets the delimiter to whichever of comma, semicolon,
+	// tab or pipe is most common outside quotes in the header, rather than
+	// Comma.
+	DetectDelimiter bool
+	// Progress, if not nil, is called with the progress of the read every
+	// 10000 rows and once at the end, eg. to drive a progress bar. The
+	// size of the csv is known if the reader is a file or has a Len
+	// method.
+	Progress func(progress Progress)
+}
+
+// delimiters are the candidates of ReadOptions.DetectDelimiter, in order
+// of preference.
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// detectDelimiter returns the delimiter of the first line of a csv, without
+// consuming it.
+func detectDelimiter(r *bufio.Reader) rune {
+	b, _ := r.Peek(r.Size())
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	counts := make(map[rune]int)
+	quoted := false
+	for _, c := range string(b) {
+		if c == '"' {
+			quoted = !quoted
+		} else if !quoted {
+			counts[c]++
+		}
+	}
+	best := delimiters[0]
+	for _, delimiter := range delimiters[1:] {
+		if counts[delimiter] > counts[best] {
+			best = delimiter
+		}
+	}
+	return best
+}
+
+// InternColumns are the headings of the columns interned by
+// ReadOptions.Intern.
+var InternColumns = []string{
+	"Frequency Type", "Station Type", "Channel Width type", "Antenna ERP type",
+	"Antenna Type", "Antenna Location", "EFL_UPPER_LOWER", "Antenna Polarisation",
+	"Antenna Name", "Emission Code", "Licencee Surname", "Licencee First Name",
+	"Licencee Company", "Status", "Tradeable", "Publishable", "Product Code",
+	"Product Description", "Product Description 31", "Product Description 32",
+}
+
+// ReadReport describes the csv read by ReadCSVWithOptions.
+type ReadReport struct {
+	Schema    *SchemaReport
+	RowErrors []RowError // the records skipped by ReadOptions.Lenient
+}
+
+// RowError is a record of the csv that could not be read or converted.
+type RowError struct {
+	// Line is the line of the csv the record starts on. Blank lines, which
+	// are skipped, are only counted before an error reading the csv.
+	Line   int
+	Column string   // the heading of the column at fault, if known
+	Record []string // the raw record, if it could be read, eg. to quarantine
+	Err    error
+}
+
+func (e RowError) Error() string {
+	s := "line " + strconv.Itoa(e.Line)
+	if e.Column != "" {
+		s += " \"" + e.Column + "\""
+	}
+	return s + ": " + e.Err.Error()
+}
+
+// ReadCSVWithOptions is as ReadCSV but returns an error rather than exiting,
+// along with a ReadReport. The report is returned whenever the header was
+// read, even with an error.
+func ReadCSVWithOptions(reader io.Reader, options *ReadOptions) (*Collection, *ReadReport, error) {
+	collection := &Collection{Rows: []*Row{}}
+	header, report, err := decodeCSV(reader, options, func(row *Row) error {
+		collection.Rows = append(collection.Rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, report, err
+	}
+	collection.Header = header
+	return collection, report, nil
+}
+
+// decodeCSV reads a csv with the options, calling emit with each Row in
+// order, and returns the header of the rows. It stops at the first error
+// from emit and returns it.
+func decodeCSV(reader io.Reader, options *ReadOptions, emit func(row *Row) error) ([]string, *ReadReport, error) {
+	if options == nil {
+		options = &ReadOptions{}
+	}
+	var counter *countingReader
+	var progress Progress
+	if options.Progress != nil {
+		progress.TotalBytes = readerSize(reader)
+		counter = &countingReader{r: reader}
+		reader = counter
+	}
+	br := bufio.NewReader(reader)
+	r := csv.NewReader(br)
+	r.ReuseRecord = true
+	if options.Comma != 0 {
+		r.Comma = options.Comma
+	}
+	if options.DetectDelimiter {
+		r.Comma = detectDelimiter(br)
+	}
+	record, err := r.Read()
+	if err == io.EOF {
+		report := &ReadReport{Schema: CheckSchema(nil)}
+		if options.StrictHeader {
+			return nil, report, checkStrictHeader(nil)
+		}
+		return nil, report, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not read from reader")
+	}
+	report := &ReadReport{Schema: CheckSchema(record)}
+	if options.StrictHeader {
+		if err := checkStrictHeader(record); err != nil {
+			return nil, report, err
+		}
+	}
+	if options.FailOnSchemaDrift && !report.Schema.OK() {
+		return nil, report, errors.Errorf("schema drift: %v", report.Schema)
+	}
+
+	var keep map[string]bool
+	if options.Columns != nil {
+		keep = make(map[string]bool, len(options.Columns))
+		for _, heading := range options.Columns {
+			if !isHeading(canonicalHeading(heading)) {
+				return nil, report, errors.Errorf("unknown column %q", heading)
+			}
+			keep[canonicalHeading(heading)] = true
+		}
+	}
+	var intern map[string]bool
+	if options.Intern {
+		intern = make(map[string]bool, len(InternColumns))
+		for _, heading := range InternColumns {
+			intern[heading] = true
+		}
+	}
+	header := canonicalHeader(record)
+	columns := header
+	if keep != nil {
+		var projected []string
+		for _, heading := range header {
+			if keep[heading] {
+				projected = append(projected, heading)
+			}
+		}
+		header = projected
+	}
+
+	records := &recordReader{r: r, lenient: options.Lenient, line: 1 + recordLines(record)}
+	rows, failed := 0, 0
+	finish := func(record *decodeRecord) error {
+		err := record.err
+		if err == nil {
+			rows++
+			if err := emit(record.row); err != nil {
+				return err
+			}
+			if counter != nil {
+				if progress.Rows++; progress.Rows%progressInterval == 0 {
+					progress.Bytes = atomic.LoadInt64(&counter.n)
+					options.Progress(progress)
+				}
+			}
+			return nil
+		}
+		failed++
+		if !options.Lenient {
+			return errors.Wrapf(err, "row %d", record.i)
+		}
+		rowError := RowError{Line: record.line, Record: copyRecord(record.record), Err: err}
+		if e, ok := err.(*columnError); ok {
+			rowError.Column, rowError.Err = e.heading, e.err
+		}
+		report.RowErrors = append(report.RowErrors, rowError)
+		return nil
+	}
+	newDecoder := func() *rowDecoder { return newRowDecoder(columns, keep, intern) }
+	if options.Concurrency > 1 {
+		r.ReuseRecord = false
+		err = decodeParallel(records, options.Concurrency, newDecoder, finish)
+	} else {
+		err = decodeSerial(records, newDecoder(), finish)
+	}
+	if m := currentMetrics(); m != nil {
+		m.RowsParsed(rows)
+		if failed += records.failed; failed > 0 {
+			m.ParseErrors(failed)
+		}
+	}
+	if err != nil {
+		return nil, report, err
+	}
+	if counter != nil {
+		progress.Bytes = atomic.LoadInt64(&counter.n)
+		options.Progress(progress)
+	}
+	return header, report, nil
+}
+
+// decodeRecord is a record of a csv being decoded.
+type decodeRecord struct {
+	i      int // the number of the record, from 1 after the header
+	line   int // the line the record starts on
+	record []string
+	row    *Row
+	err    error // an error reading or decoding the record
+}
+
+// recordReader reads the records of a csv after the header.
+type recordReader struct {
+	r       *csv.Reader
+	lenient bool
+	i, line int
+	failed  int // records that could not be read, other than lenient ones
+}
+
+// next reads the next record. ok is false at the end of the csv. An error
+// is returned unless it is an error reading a record that is lenient.
+func (records *recordReader) next() (record decodeRecord, ok bool, err error) {
+	fields, err := records.r.Read()
+	if err == io.EOF {
+		return decodeRecord{}, false, nil
+	}
+	records.i++
+	record = decodeRecord{i: records.i, line: records.line, record: fields}
+	if fields != nil {
+		records.line += recordLines(fields)
+	}
+	if err != nil {
+		parseError, ok := err.(*csv.ParseError)
+		if !records.lenient || !ok {
+			if ok {
+				records.failed++
+			}
+			return decodeRecord{}, false, errors.Wrap(err, "could not read from reader")
+		}
+		if fields == nil {
+			record.line, records.line = parseError.StartLine, parseError.Line+1
+		}
+		record.err = parseError.Err
+	}
+	return record, true, nil
+}
+
+// decodeSerial decodes the records in turn, calling finish with each.
+func decodeSerial(records *recordReader, decoder *rowDecoder, finish func(*decodeRecord) error) error {
+	for {
+		record, ok, err := records.next()
+		if !ok {
+			return err
+		}
+		if record.err == nil {
+			record.row, record.err = decoder.decode(record.record)
+		}
+		if err := finish(&record); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeBatchSize is the number of records decoded by a worker at a time.
+const decodeBatchSize = 1024
+
+// decodeBatch is a batch of records decoded by a worker.
+type decodeBatch struct {
+	records []decodeRecord
+	done    chan struct{}
+}
+
+// decodeParallel reads the records on the calling goroutine and decodes them
+// on n workers, each with its own rowDecoder, calling finish with each in
+// order on another goroutine.
+func decodeParallel(records *recordReader, n int, newDecoder func() *rowDecoder, finish func(*decodeRecord) error) error {
+	work := make(chan *decodeBatch)
+	pending := make(chan *decodeBatch, 2*n)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			decoder := newDecoder()
+			for batch := range work {
+				for i := range batch.records {
+					record := &batch.records[i]
+					if record.err == nil {
+						record.row, record.err = decoder.decode(record.record)
+					}
+				}
+				close(batch.done)
+			}
+		}()
+	}
+
+	// The batches are finished in the order they were read.
+	finished := make(chan error, 1)
+	go func() {
+		var err error
+		for batch := range pending {
+			<-batch.done
+			for i := 0; err == nil && i < len(batch.records); i++ {
+				if err = finish(&batch.records[i]); err != nil {
+					close(stop)
+				}
+			}
+		}
+		finished <- err
+	}()
+
+	var err error
+read:
+	for {
+		batch := &decodeBatch{records: make([]decodeRecord, 0, decodeBatchSize), done: make(chan struct{})}
+		for len(batch.records) < decodeBatchSize {
+			var record decodeRecord
+			var ok bool
+			if record, ok, err = records.next(); !ok {
+				break
+			}
+			batch.records = append(batch.records, record)
+		}
+		if len(batch.records) == 0 {
+			break
+		}
+		select {
+		case <-stop:
+			break read
+		default:
+		}
+		select {
+		case pending <- batch:
+		case <-stop:
+			break read
+		}
+		work <- batch
+		if err != nil || len(batch.records) < decodeBatchSize {
+			break
+		}
+	}
+	close(work)
+	close(pending)
+	wg.Wait()
+	if finishErr := <-finished; finishErr != nil {
+		return finishErr
+	}
+	return err
+}
+
+// copyRecord returns a copy of a record, which is reused by the reader.
+func copyRecord(record []string) []string {
+	if record == nil {
+		return nil
+	}
+	return append([]string{}, record...)
+}
+
+// recordLines returns the number of lines of the csv a record spans.
+func recordLines(record []string) int {
+	n := 1
+	for _, field := range record {
+		n += strings.Count(field, "\n")
+	}
+	return n
+}
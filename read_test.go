@@ -0,0 +1,191 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestReadCSVWithOptions(t *testing.T) {
+	const csv = "Licence Number,Frequency,Notes\n0000001/1,7.5,x\n"
+	collection, report, err := ReadCSVWithOptions(strings.NewReader(csv), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Rows) != 1 || collection.Rows[0].Frequency != "7.5" {
+		t.Errorf("wrong rows %v", collection.Rows)
+	}
+	if report.Schema.OK() || len(report.Schema.Missing) != len(standardHeader)-2 {
+		t.Errorf("wrong report %v", report.Schema)
+	}
+
+	_, report, err = ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{FailOnSchemaDrift: true})
+	if err == nil || report == nil || !strings.HasPrefix(err.Error(), "schema drift: ") {
+		t.Errorf("got %v", err)
+	}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(strings.Join(standardHeader, ",")+"\n"), &ReadOptions{FailOnSchemaDrift: true}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadCSVLenient(t *testing.T) {
+	const csv = `Licence Number,OS Easting,AP_COMMENT_INTERN
+0000001/1,529400,"two
+lines"
+0000002/1,x,
+0000003/1
+0000004/1,1,a "bare" quote
+0000005/1,2,
+`
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(csv), nil); err == nil {
+		t.Fatal("no error without Lenient")
+	}
+	collection, report, err := ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := licenceNumbers(collection); got != "0000001/1 0000005/1" {
+		t.Errorf("got rows %s", got)
+	}
+	var got []string
+	for _, e := range report.RowErrors {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		`line 4 "OS Easting": strconv.Atoi: parsing "x": invalid syntax`,
+		`line 5: wrong number of fields`,
+		`line 6: bare " in non-quoted-field`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors\n%s", strings.Join(got, "\n"))
+	}
+	if record := report.RowErrors[1].Record; len(record) != 1 || record[0] != "0000003/1" {
+		t.Errorf("wrong record %q", record)
+	}
+}
+
+func TestReadCSVStrictHeader(t *testing.T) {
+	options := &ReadOptions{StrictHeader: true}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(strings.Join(standardHeader, ",")+"\n"), options); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader("Licence Number,Frequency\n"), options); err == nil {
+		t.Error("no error for a partial header")
+	}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(""), options); err == nil {
+		t.Error("no error for an empty csv")
+	}
+}
+
+func TestReadCSVColumns(t *testing.T) {
+	const csv = "Licence Number,Licencee Company,Frequency,OS Easting,Notes\n0000001/1,Acme,7.5,529400,x\n"
+	options := &ReadOptions{Columns: []string{"frequency", "Licence Number", "OS Easting"}}
+	collection, _, err := ReadCSVWithOptions(strings.NewReader(csv), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(collection.Header, ","); got != "Licence Number,Frequency,OS Easting" {
+		t.Errorf("got header %s", got)
+	}
+	row := collection.Rows[0]
+	if row.LicenceNumber != "0000001/1" || row.Frequency != "7.5" || row.OsEasting != 529400 || row.LicenseeCompany != "" {
+		t.Errorf("wrong row %+v", row)
+	}
+
+	options.Columns = []string{"Colour"}
+	if _, _, err := ReadCSVWithOptions(strings.NewReader(csv), options); err == nil {
+		t.Error("no error for an unknown column")
+	}
+}
+
+func TestReadCSVIntern(t *testing.T) {
+	const csv = "Licence Number,Licencee Company\n0000001/1,Acme\n0000002/1,Acme\n"
+	for _, intern := range []bool{false, true} {
+		collection, _, err := ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Intern: intern})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, b := collection.Rows[0], collection.Rows[1]
+		if a.LicenseeCompany != "Acme" || b.LicenseeCompany != "Acme" || b.LicenceNumber != "0000002/1" {
+			t.Fatalf("wrong rows %+v %+v", a, b)
+		}
+		if got := stringData(a.LicenseeCompany) == stringData(b.LicenseeCompany); got != intern {
+			t.Errorf("intern %v: shared storage %v", intern, got)
+		}
+	}
+}
+
+// stringData returns the address of the bytes of a string.
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestReadCSVConcurrency(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Licence Number,OS Easting,Licencee Company\n")
+	for i := 0; i < 5000; i++ {
+		switch i % 1000 {
+		case 7:
+			fmt.Fprintf(&b, "%07d/1,bad,Acme\n", i)
+		case 8:
+			fmt.Fprintf(&b, "%07d/1\n", i)
+		default:
+			fmt.Fprintf(&b, "%07d/1,%d,Acme\n", i, i)
+		}
+	}
+	csv := b.String()
+
+	want, wantReport, err := ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{2, 8} {
+		got, report, err := ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Lenient: true, Concurrency: n, Intern: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if licenceNumbers(got) != licenceNumbers(want) || !reflect.DeepEqual(got.Rows, want.Rows) {
+			t.Errorf("concurrency %d: wrong rows", n)
+		}
+		if !reflect.DeepEqual(report.RowErrors, wantReport.RowErrors) {
+			t.Errorf("concurrency %d: got errors %v, want %v", n, report.RowErrors, wantReport.RowErrors)
+		}
+
+		_, _, err = ReadCSVWithOptions(strings.NewReader(csv), &ReadOptions{Concurrency: n})
+		if err == nil || !strings.HasPrefix(err.Error(), "row 8: ") {
+			t.Errorf("concurrency %d: got error %v", n, err)
+		}
+	}
+}
+
+func TestReadCSVDelimiter(t *testing.T) {
+	for _, test := range []struct {
+		csv   string
+		comma rune
+	}{
+		{"Licence Number\tLicencee Company\n0000001/1\tAcme, Ltd\n", '\t'},
+		{"Licence Number;Licencee Company;\"Notes, etc\"\n0000001/1;Acme, Ltd;x\n", ';'},
+		{"Licence Number|Licencee Company\n0000001/1|Acme, Ltd\n", '|'},
+		{"Licence Number,Licencee Company\n0000001/1,\"Acme, Ltd\"\n", ','},
+	} {
+		detected, _, err := ReadCSVWithOptions(strings.NewReader(test.csv), &ReadOptions{DetectDelimiter: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		given, _, err := ReadCSVWithOptions(strings.NewReader(test.csv), &ReadOptions{Comma: test.comma})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, collection := range []*Collection{detected, given} {
+			if len(collection.Rows) != 1 || collection.Rows[0].LicenseeCompany != "Acme, Ltd" {
+				t.Errorf("%q: wrong rows %v", test.comma, collection.Rows)
+			}
+		}
+	}
+	if got := detectDelimiter(bufio.NewReader(strings.NewReader("one column"))); got != ',' {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,198 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"html"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReportOptions configures WriteReportMarkdown and WriteReportHTML.
+type ReportOptions struct {
+	Title        string // default "WTR report"
+	TopCompanies int    // companies listed, default 10
+}
+
+// reportTable is a table of a report.
+type reportTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// report is the content of a report, rendered as Markdown or HTML.
+type report struct {
+	Title    string
+	Rows     int
+	Licences int
+	Tables   []reportTable
+	BandSVG  string // band occupancy chart
+}
+
+func (collection *Collection) report(options *ReportOptions) *report {
+	o := ReportOptions{Title: "WTR report", TopCompanies: 10}
+	if options != nil {
+		if options.Title != "" {
+			o.Title = options.Title
+		}
+		if options.TopCompanies > 0 {
+			o.TopCompanies = options.TopCompanies
+		}
+	}
+	summary := collection.Summary()
+	r := &report{Title: o.Title, Rows: summary.Rows, Licences: summary.Licences}
+
+	lookup := GetProductCodeLookup()
+	codes := reportTable{Title: "Product codes", Columns: []string{"Code", "Product", "Rows", "Licences"}}
+	for _, count := range summary.ByProductCode {
+		codes.Rows = append(codes.Rows, []string{reportKey(count.Key), lookup[count.Key], strconv.Itoa(count.Rows), strconv.Itoa(count.Licences)})
+	}
+	companies := reportTable{Title: "Top companies", Columns: []string{"Company", "Rows", "Licences"}}
+	for i, count := range summary.ByCompany {
+		if i == o.TopCompanies {
+			break
+		}
+		companies.Rows = append(companies.Rows, []string{reportKey(count.Key), strconv.Itoa(count.Rows), strconv.Itoa(count.Licences)})
+	}
+	status := reportTable{Title: "Status", Columns: []string{"Status", "Rows", "Licences"}}
+	for _, count := range summary.ByStatus {
+		status.Rows = append(status.Rows, []string{reportKey(count.Key), strconv.Itoa(count.Rows), strconv.Itoa(count.Licences)})
+	}
+	stats := reportTable{Title: "Engineering values", Columns: []string{"Field", "Rows", "Min", "Median", "Max", "Mean"}}
+	for _, field := range []NumericField{FieldFrequency, FieldERP, FieldAntennaHeight, FieldAntennaGain} {
+		s := collection.Stats(field, 0)
+		if s.Count == 0 {
+			continue
+		}
+		stats.Rows = append(stats.Rows, []string{string(field), strconv.Itoa(s.Count),
+			formatReportValue(field, s.Min), formatReportValue(field, s.Median),
+			formatReportValue(field, s.Max), formatReportValue(field, s.Mean)})
+	}
+	r.Tables = []reportTable{codes, companies, status, stats}
+	r.BandSVG = bandChartSVG(collection.summaryCounts(KeyBand))
+	return r
+}
+
+func reportKey(key string) string {
+	if key == "" {
+		return "(none)"
+	}
+	return key
+}
+
+// formatReportValue formats a value of the field, frequencies in MHz as by
+// FormatFrequency.
+func formatReportValue(field NumericField, v float64) string {
+	if field == FieldFrequency {
+		return FormatFrequency(v * 1e6)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// bandChartSVG draws a bar chart of the rows per band of Bands, in the
+// order of Bands, omitting rows in none of them.
+func bandChartSVG(counts []SummaryCount) string {
+	rows := make(map[string]int, len(counts))
+	max := 0
+	for _, count := range counts {
+		rows[count.Key] = count.Rows
+		if count.Key != "" && count.Rows > max {
+			max = count.Rows
+		}
+	}
+	const barWidth, gap, height, labelHeight = 48, 12, 160, 36
+	var bars []string
+	for _, band := range Bands {
+		if rows[band.Name] == 0 {
+			continue
+		}
+		x := gap + len(bars)*(barWidth+gap)
+		h := rows[band.Name] * height / max
+		bars = append(bars, fmt.Sprintf(
+			`<rect x="%d" y="%d" width="%d" height="%d" fill="#1f77b4"/>`+
+				`<text x="%d" y="%d" text-anchor="middle">%s</text>`+
+				`<text x="%d" y="%d" text-anchor="middle">%d</text>`,
+			x, gap+height-h, barWidth, h,
+			x+barWidth/2, gap+height+16, html.EscapeString(band.Name),
+			x+barWidth/2, gap+height+32, rows[band.Name]))
+	}
+	width := gap + len(bars)*(barWidth+gap)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">%s</svg>`,
+		width, gap+height+labelHeight, strings.Join(bars, ""))
+}
+
+// markdownCell escapes a value for a Markdown table.
+func markdownCell(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(strings.Replace(s, "\r", " ", -1), "\n", " ", -1)
+}
+
+// WriteReportMarkdown writes a report of the collection as Markdown:
+// counts by product code, the top companies, status and the engineering
+// values, with a chart of the band occupancy as an embedded SVG image.
+// options may be nil.
+func (collection *Collection) WriteReportMarkdown(writer io.Writer, options *ReportOptions) error {
+	r := collection.report(options)
+	w := bufio.NewWriter(writer)
+	fmt.Fprintf(w, "# %s\n\n%d rows, %d licences.\n", markdownCell(r.Title), r.Rows, r.Licences)
+	for _, table := range r.Tables {
+		fmt.Fprintf(w, "\n## %s\n\n", table.Title)
+		fmt.Fprintf(w, "| %s |\n|%s\n", strings.Join(table.Columns, " | "), strings.Repeat(" --- |", len(table.Columns)))
+		for _, row := range table.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = markdownCell(cell)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	fmt.Fprintf(w, "\n## Band occupancy\n\n![Rows per band](data:image/svg+xml;base64,%s)\n",
+		base64.StdEncoding.EncodeToString([]byte(r.BandSVG)))
+	return errors.Wrap(w.Flush(), "could not write report")
+}
+
+// WriteReportHTML writes the report of WriteReportMarkdown as a standalone
+// HTML page with the chart inline. options may be nil.
+func (collection *Collection) WriteReportHTML(writer io.Writer, options *ReportOptions) error {
+	r := collection.report(options)
+	w := bufio.NewWriter(writer)
+	err := reportTemplate.Execute(w, struct {
+		*report
+		Chart template.HTML
+	}{r, template.HTML(r.BandSVG)}) // the chart escapes its labels
+	if err != nil {
+		return errors.Wrap(err, "could not write report")
+	}
+	return errors.Wrap(w.Flush(), "could not write report")
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Rows}} rows, {{.Licences}} licences.</p>
+{{range .Tables}}<h2>{{.Title}}</h2>
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<h2>Band occupancy</h2>
+{{.Chart}}
+</body>
+</html>
+`))
@@ -0,0 +1,39 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Status,Product Description 31,Frequency,Frequency Type,Antenna Height
+0000001/1,Acme | Co,Live,301010,7.5,GHz,10
+0000002/1,<Other>,Live,301010,450,MHz,20
+0000003/1,Acme | Co,Cancelled,305010,38,GHz,
+`)
+	buf := new(bytes.Buffer)
+	if err := collection.WriteReportMarkdown(buf, &ReportOptions{Title: "Test", TopCompanies: 1}); err != nil {
+		t.Fatal(err)
+	}
+	md := buf.String()
+	for _, s := range []string{"# Test\n", "3 rows, 3 licences.", `| Acme \| Co | 2 | 2 |`, "| 301010 | Fixed Links | 2 | 2 |", "| frequency_mhz | 3 | 450 MHz | 7.5 GHz | 38 GHz |", "data:image/svg+xml;base64,"} {
+		if !strings.Contains(md, s) {
+			t.Errorf("no %q in\n%s", s, md)
+		}
+	}
+	if strings.Contains(md, "<Other>") {
+		t.Error("more than the top company listed")
+	}
+
+	buf.Reset()
+	if err := collection.WriteReportHTML(buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, s := range []string{"<title>WTR report</title>", "&lt;Other&gt;", "<svg ", ">7.5 GHz</text>", ">38 GHz</text>"} {
+		if !strings.Contains(page, s) {
+			t.Errorf("no %q in\n%s", s, page)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// rowColumn is a Row field with a csv tag, eg.
+//
+//	LicenceNumber string `csv:"Licence Number"`
+//
+// The tag is the heading of the column, optionally followed by ",munged"
+// for the columns that are not present in the original OFCOM csv. The
+// field is a string, or an int which is written as "0" when zero.
+type rowColumn struct {
+	heading string
+	index   int
+	kind    reflect.Kind
+	munged  bool
+}
+
+// rowColumns are the columns of Row in field order, parsed once from its
+// csv tags. It is the source of truth for reading and writing csv.
+var rowColumns = csvColumns(reflect.TypeOf(Row{}))
+
+// rowColumnIndex maps a heading to its element of rowColumns.
+var rowColumnIndex = func() map[string]int {
+	index := make(map[string]int, len(rowColumns))
+	for i, column := range rowColumns {
+		index[column.heading] = i
+	}
+	return index
+}()
+
+// csvColumns returns the columns of the fields of a struct type with csv
+// tags. It panics on a field of an unsupported kind or a repeated heading.
+func csvColumns(t reflect.Type) []rowColumn {
+	var columns []rowColumn
+	seen := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("csv")
+		if !ok || tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		column := rowColumn{heading: parts[0], index: i, kind: field.Type.Kind()}
+		for _, option := range parts[1:] {
+			switch option {
+			case "munged":
+				column.munged = true
+			default:
+				panic("wtrcsv: unknown csv tag option " + option + " of " + field.Name)
+			}
+		}
+		if column.kind != reflect.String && column.kind != reflect.Int {
+			panic("wtrcsv: unsupported csv field " + field.Name)
+		}
+		if seen[column.heading] {
+			panic("wtrcsv: repeated csv heading " + column.heading)
+		}
+		seen[column.heading] = true
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// get returns the value of the column's field as it is written to a csv.
+func (column rowColumn) get(field reflect.Value) string {
+	if column.kind == reflect.Int {
+		return strconv.Itoa(int(field.Int()))
+	}
+	return field.String()
+}
+
+// set sets the column's field from a csv value. An empty value leaves an
+// int as zero.
+func (column rowColumn) set(field reflect.Value, value string) error {
+	if column.kind != reflect.Int {
+		field.SetString(value)
+		return nil
+	}
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return &columnError{column.heading, err}
+	}
+	field.SetInt(int64(n))
+	return nil
+}
+
+// rowDecoder converts the records of a csv to Rows by the positions of the
+// columns in its header, rather than by a map of the columns.
+type rowDecoder struct {
+	columns []int // per header column, its index in rowColumns or -1
+	// copy copies the values, which otherwise share the storage of the
+	// whole record, so that the values not kept can be freed.
+	copy bool
+	// interned are the header columns whose values are shared through pool.
+	interned []bool
+	pool     map[string]string
+}
+
+// newRowDecoder returns a rowDecoder for a canonical header, converting
+// only the columns of keep if it is not nil, and interning the values of
+// the columns of intern.
+func newRowDecoder(header []string, keep, intern map[string]bool) *rowDecoder {
+	decoder := &rowDecoder{
+		columns:  make([]int, len(header)),
+		copy:     keep != nil || len(intern) > 0,
+		interned: make([]bool, len(header)),
+	}
+	for j, heading := range header {
+		decoder.columns[j] = -1
+		if i, ok := rowColumnIndex[heading]; ok && (keep == nil || keep[heading]) {
+			decoder.columns[j] = i
+			decoder.interned[j] = intern[heading]
+		}
+	}
+	if len(intern) > 0 {
+		decoder.pool = make(map[string]string)
+	}
+	return decoder
+}
+
+func (decoder *rowDecoder) decode(record []string) (*Row, error) {
+	var row Row
+	value := reflect.ValueOf(&row).Elem()
+	for j, i := range decoder.columns {
+		if i < 0 {
+			continue
+		}
+		field := record[j]
+		switch {
+		case field == "":
+		case decoder.interned[j]:
+			interned, ok := decoder.pool[field]
+			if !ok {
+				interned = string([]byte(field))
+				decoder.pool[interned] = interned
+			}
+			field = interned
+		case decoder.copy:
+			field = string([]byte(field))
+		}
+		column := rowColumns[i]
+		if err := column.set(value.Field(column.index), field); err != nil {
+			return nil, err
+		}
+	}
+	if err := row.convertWgs84(); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// rowEncoder converts Rows to the records of a csv by the positions of the
+// columns in its header, rather than through a map of the columns.
+type rowEncoder struct {
+	columns  []int                   // per header column, its index in rowColumns or -1
+	computed []func(row *Row) string // per header column, if computed
+	record   []string
+}
+
+func newRowEncoder(header []string, computed []computedColumn) *rowEncoder {
+	encoder := &rowEncoder{
+		columns:  make([]int, len(header)),
+		computed: make([]func(row *Row) string, len(header)),
+		record:   make([]string, len(header)),
+	}
+	for j, heading := range header {
+		encoder.columns[j] = -1
+		if i, ok := rowColumnIndex[heading]; ok {
+			encoder.columns[j] = i
+			continue
+		}
+		for _, column := range computed {
+			if column.heading == heading {
+				encoder.computed[j] = column.fn
+			}
+		}
+	}
+	return encoder
+}
+
+// encode returns the record of a row, "" for the headings that are neither
+// columns of Row nor computed. The record is reused by the next call.
+func (encoder *rowEncoder) encode(row *Row) []string {
+	value := reflect.ValueOf(row).Elem()
+	for j, i := range encoder.columns {
+		switch {
+		case i >= 0:
+			column := rowColumns[i]
+			encoder.record[j] = column.get(value.Field(column.index))
+		case encoder.computed[j] != nil:
+			encoder.record[j] = encoder.computed[j](row)
+		default:
+			encoder.record[j] = ""
+		}
+	}
+	return encoder.record
+}
+
+// columnError is an error converting the value of a column.
+type columnError struct {
+	heading string
+	err     error
+}
+
+func (e *columnError) Error() string {
+	return "could not convert " + e.heading + ": " + e.err.Error()
+}
+
+// IsOFCOMField reports whether a field of Row, by its Go name, is one of
+// the columns of the original OFCOM csv, rather than a munged column or a
+// field converted from one.
+func IsOFCOMField(fieldName string) bool {
+	field, ok := reflect.TypeOf(Row{}).FieldByName(fieldName)
+	if !ok {
+		return false
+	}
+	for _, column := range rowColumns {
+		if column.index == field.Index[0] {
+			return !column.munged
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRowColumns(t *testing.T) {
+	if len(rowColumns) != len(standardHeader)+len(mungedHeader) {
+		t.Fatalf("%d columns, want %d", len(rowColumns), len(standardHeader)+len(mungedHeader))
+	}
+	for _, heading := range mungedHeader {
+		i, ok := rowColumnIndex[heading]
+		if !ok || !rowColumns[i].munged {
+			t.Errorf("%s is not a munged column", heading)
+		}
+	}
+	for _, test := range []struct {
+		field string
+		want  bool
+	}{
+		{"LicenceNumber", true},
+		{"ProductDescription32", true},
+		{"OsEasting", false},
+		{"Wgs84LatitudeAsString", false},
+		{"Wgs84Latitude", false},
+		{"NoSuchField", false},
+	} {
+		if got := IsOFCOMField(test.field); got != test.want {
+			t.Errorf("IsOFCOMField(%s) = %v", test.field, got)
+		}
+	}
+}
+
+func TestRowColumnsRoundTrip(t *testing.T) {
+	// Every field with a csv tag survives toMap and parseRow.
+	var row Row
+	value := reflect.ValueOf(&row).Elem()
+	for i, column := range rowColumns {
+		if err := column.set(value.Field(column.index), strconv.Itoa(i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := parseRow(row.toMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	row.Wgs84Longitude, row.Wgs84Latitude = 47, 48
+	if !reflect.DeepEqual(*got, row) {
+		t.Errorf("got %+v, want %+v", *got, row)
+	}
+	if got.Get("Licence Number") != "1" || got.Get("OS Northing") != "50" || got.Get("unknown") != "" {
+		t.Errorf("wrong Get %q %q", got.Get("Licence Number"), got.Get("OS Northing"))
+	}
+
+	if _, err := parseRow(map[string]string{HeadingOsEasting: "x"}); err == nil {
+		t.Error("no error for a bad easting")
+	}
+}
@@ -0,0 +1,325 @@
+package wtrcsv
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// BBox is a bounding box. X is longitude or easting, Y latitude or northing.
+type BBox struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+func pointBBox(x, y float64) BBox {
+	return BBox{x, y, x, y}
+}
+
+// Contains reports whether a point is in the box (inclusive).
+func (b BBox) Contains(x, y float64) bool {
+	return x >= b.MinX && x <= b.MaxX && y >= b.MinY && y <= b.MaxY
+}
+
+func (b BBox) intersects(o BBox) bool {
+	return b.MinX <= o.MaxX && o.MinX <= b.MaxX && b.MinY <= o.MaxY && o.MinY <= b.MaxY
+}
+
+func (b BBox) union(o BBox) BBox {
+	return BBox{math.Min(b.MinX, o.MinX), math.Min(b.MinY, o.MinY), math.Max(b.MaxX, o.MaxX), math.Max(b.MaxY, o.MaxY)}
+}
+
+func (b BBox) area() float64 {
+	return (b.MaxX - b.MinX) * (b.MaxY - b.MinY)
+}
+
+func (b BBox) centre() (float64, float64) {
+	return (b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2
+}
+
+// rtreeMaxEntries is the capacity of a node.
+const rtreeMaxEntries = 16
+
+type rtreeEntry struct {
+	bbox  BBox
+	child *rtreeNode // nil in a leaf
+	row   *Row
+}
+
+type rtreeNode struct {
+	leaf    bool
+	entries []rtreeEntry
+}
+
+func (node *rtreeNode) bbox() BBox {
+	b := node.entries[0].bbox
+	for _, e := range node.entries[1:] {
+		b = b.union(e.bbox)
+	}
+	return b
+}
+
+// SpatialIndex is an R-tree of rows by location, for bounding box and
+// nearest neighbour queries. Rows without a location are not indexed.
+type SpatialIndex struct {
+	root  *rtreeNode
+	point func(row *Row) (x, y float64, ok bool)
+	// geographic distances are scaled by the cosine of the latitude.
+	geographic bool
+	size       int
+}
+
+// wgs84Point locates a row by longitude and latitude (see rowLatLon).
+func wgs84Point(row *Row) (float64, float64, bool) {
+	lat, lon, ok := rowLatLon(row)
+	return lon, lat, ok
+}
+
+// osgbPoint locates a row by easting and northing (see rowOS).
+func osgbPoint(row *Row) (float64, float64, bool) {
+	e, n, ok := rowOS(row)
+	return float64(e), float64(n), ok
+}
+
+// BuildSpatialIndex returns a SpatialIndex of the rows by WGS84 longitude
+// (X) and latitude (Y), from the WGS84 columns or else the SID columns.
+func (collection *Collection) BuildSpatialIndex() *SpatialIndex {
+	return collection.buildSpatialIndex(wgs84Point, true)
+}
+
+// BuildSpatialIndexOSGB returns a SpatialIndex of the rows by OSGB36
+// easting (X) and northing (Y), from the OS columns or else the NGR.
+func (collection *Collection) BuildSpatialIndexOSGB() *SpatialIndex {
+	return collection.buildSpatialIndex(osgbPoint, false)
+}
+
+func (collection *Collection) buildSpatialIndex(point func(row *Row) (float64, float64, bool), geographic bool) *SpatialIndex {
+	index := &SpatialIndex{point: point, geographic: geographic}
+	var entries []rtreeEntry
+	for _, row := range collection.Rows {
+		if x, y, ok := point(row); ok {
+			entries = append(entries, rtreeEntry{bbox: pointBBox(x, y), row: row})
+		}
+	}
+	index.size = len(entries)
+	index.root = packRTree(entries, true)
+	return index
+}
+
+// packRTree bulk loads entries by Sort-Tile-Recursive, one level at a time.
+func packRTree(entries []rtreeEntry, leaf bool) *rtreeNode {
+	if len(entries) <= rtreeMaxEntries {
+		return &rtreeNode{leaf: leaf, entries: entries}
+	}
+	nodes := int(math.Ceil(float64(len(entries)) / rtreeMaxEntries))
+	slabs := int(math.Ceil(math.Sqrt(float64(nodes))))
+	slabSize := slabs * rtreeMaxEntries
+
+	sort.Slice(entries, func(i, j int) bool {
+		xi, _ := entries[i].bbox.centre()
+		xj, _ := entries[j].bbox.centre()
+		return xi < xj
+	})
+	var parents []rtreeEntry
+	for start := 0; start < len(entries); start += slabSize {
+		slab := entries[start:minInt(start+slabSize, len(entries))]
+		sort.Slice(slab, func(i, j int) bool {
+			_, yi := slab[i].bbox.centre()
+			_, yj := slab[j].bbox.centre()
+			return yi < yj
+		})
+		for i := 0; i < len(slab); i += rtreeMaxEntries {
+			chunk := append([]rtreeEntry(nil), slab[i:minInt(i+rtreeMaxEntries, len(slab))]...)
+			node := &rtreeNode{leaf: leaf, entries: chunk}
+			parents = append(parents, rtreeEntry{bbox: node.bbox(), child: node})
+		}
+	}
+	return packRTree(parents, false)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Len returns the number of rows indexed.
+func (index *SpatialIndex) Len() int {
+	return index.size
+}
+
+// Query returns the rows inside a bounding box (inclusive).
+func (index *SpatialIndex) Query(bbox BBox) []*Row {
+	var rows []*Row
+	var search func(node *rtreeNode)
+	search = func(node *rtreeNode) {
+		for _, e := range node.entries {
+			if !bbox.intersects(e.bbox) {
+				continue
+			}
+			if node.leaf {
+				rows = append(rows, e.row)
+			} else {
+				search(e.child)
+			}
+		}
+	}
+	search(index.root)
+	return rows
+}
+
+// distance2 returns the (planar) distance from a point to a box, squared.
+// Geographic distances are in degrees of latitude, with longitude scaled by
+// cosLat, which is accurate enough for ordering neighbours.
+func distance2(x, y float64, b BBox, cosLat float64) float64 {
+	dx := math.Max(0, math.Max(b.MinX-x, x-b.MaxX)) * cosLat
+	dy := math.Max(0, math.Max(b.MinY-y, y-b.MaxY))
+	return dx*dx + dy*dy
+}
+
+type nearestItem struct {
+	d2    float64
+	entry *rtreeEntry
+	leaf  bool // entry is a row
+}
+
+type nearestQueue []nearestItem
+
+func (q nearestQueue) Len() int            { return len(q) }
+func (q nearestQueue) Less(i, j int) bool  { return q[i].d2 < q[j].d2 }
+func (q nearestQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *nearestQueue) Push(x interface{}) { *q = append(*q, x.(nearestItem)) }
+func (q *nearestQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Nearest returns the k rows nearest to a point, nearest first, by a best
+// first search of the tree.
+func (index *SpatialIndex) Nearest(x, y float64, k int) []*Row {
+	cosLat := 1.0
+	if index.geographic {
+		cosLat = math.Cos(radians(y))
+	}
+	var rows []*Row
+	q := &nearestQueue{}
+	root := rtreeEntry{child: index.root}
+	heap.Push(q, nearestItem{0, &root, false})
+	for q.Len() > 0 && len(rows) < k {
+		item := heap.Pop(q).(nearestItem)
+		if item.leaf {
+			rows = append(rows, item.entry.row)
+			continue
+		}
+		node := item.entry.child
+		for i := range node.entries {
+			e := &node.entries[i]
+			heap.Push(q, nearestItem{distance2(x, y, e.bbox, cosLat), e, node.leaf})
+		}
+	}
+	return rows
+}
+
+// Add implements Index, inserting a row (if it has a location).
+func (index *SpatialIndex) Add(row *Row) {
+	x, y, ok := index.point(row)
+	if !ok {
+		return
+	}
+	index.size++
+	if split := index.insert(index.root, rtreeEntry{bbox: pointBBox(x, y), row: row}); split != nil {
+		index.root = &rtreeNode{entries: []rtreeEntry{
+			{bbox: index.root.bbox(), child: index.root},
+			{bbox: split.bbox(), child: split},
+		}}
+	}
+}
+
+// insert adds an entry below node, returning the new sibling of node if it
+// had to be split.
+func (index *SpatialIndex) insert(node *rtreeNode, entry rtreeEntry) *rtreeNode {
+	if node.leaf {
+		node.entries = append(node.entries, entry)
+	} else {
+		// The child needing the least enlargement, then the smallest.
+		best, bestGrowth, bestArea := 0, math.Inf(1), math.Inf(1)
+		for i, e := range node.entries {
+			area := e.bbox.area()
+			growth := e.bbox.union(entry.bbox).area() - area
+			if growth < bestGrowth || (growth == bestGrowth && area < bestArea) {
+				best, bestGrowth, bestArea = i, growth, area
+			}
+		}
+		child := node.entries[best].child
+		split := index.insert(child, entry)
+		node.entries[best].bbox = child.bbox()
+		if split != nil {
+			node.entries = append(node.entries, rtreeEntry{bbox: split.bbox(), child: split})
+		}
+	}
+	if len(node.entries) <= rtreeMaxEntries {
+		return nil
+	}
+
+	// Split along the longer axis of the node at the median.
+	b := node.bbox()
+	byX := b.MaxX-b.MinX > b.MaxY-b.MinY
+	sort.Slice(node.entries, func(i, j int) bool {
+		xi, yi := node.entries[i].bbox.centre()
+		xj, yj := node.entries[j].bbox.centre()
+		if byX {
+			return xi < xj
+		}
+		return yi < yj
+	})
+	half := len(node.entries) / 2
+	sibling := &rtreeNode{leaf: node.leaf, entries: append([]rtreeEntry(nil), node.entries[half:]...)}
+	node.entries = node.entries[:half:half]
+	return sibling
+}
+
+// Remove implements Index, removing a row added to the index.
+func (index *SpatialIndex) Remove(row *Row) {
+	x, y, ok := index.point(row)
+	if !ok {
+		return
+	}
+	if index.remove(index.root, row, x, y) {
+		index.size--
+	}
+	for !index.root.leaf && len(index.root.entries) == 1 {
+		index.root = index.root.entries[0].child
+	}
+	if !index.root.leaf && len(index.root.entries) == 0 {
+		index.root = &rtreeNode{leaf: true}
+	}
+}
+
+// remove removes a row below node, reporting whether it was found. Empty
+// children are removed and the boxes on the path shrunk.
+func (index *SpatialIndex) remove(node *rtreeNode, row *Row, x, y float64) bool {
+	for i := range node.entries {
+		e := &node.entries[i]
+		if !e.bbox.Contains(x, y) {
+			continue
+		}
+		if node.leaf {
+			if e.row == row {
+				node.entries = append(node.entries[:i], node.entries[i+1:]...)
+				return true
+			}
+			continue
+		}
+		if index.remove(e.child, row, x, y) {
+			if len(e.child.entries) == 0 {
+				node.entries = append(node.entries[:i], node.entries[i+1:]...)
+			} else {
+				e.bbox = e.child.bbox()
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package wtrcsv
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func randomRows(r *rand.Rand, n int) []*Row {
+	rows := make([]*Row, n)
+	for i := range rows {
+		lon, lat := -8+10*r.Float64(), 50+9*r.Float64()
+		rows[i] = &Row{
+			LicenceNumber:          strconv.Itoa(i),
+			Wgs84Longitude:         lon,
+			Wgs84Latitude:          lat,
+			Wgs84LongitudeAsString: strconv.FormatFloat(lon, 'f', -1, 64),
+			Wgs84LatitudeAsString:  strconv.FormatFloat(lat, 'f', -1, 64),
+			OsEasting:              r.Intn(700000),
+			OsNorthing:             r.Intn(1300000),
+		}
+	}
+	return rows
+}
+
+func licenceSet(rows []*Row) map[string]bool {
+	set := make(map[string]bool)
+	for _, row := range rows {
+		set[row.LicenceNumber] = true
+	}
+	return set
+}
+
+// checkSpatialIndex compares queries against a scan of rows.
+func checkSpatialIndex(t *testing.T, r *rand.Rand, index *SpatialIndex, rows []*Row, point func(*Row) (float64, float64, bool), geographic bool) {
+	if index.Len() != len(rows) {
+		t.Fatalf("Len %d, want %d", index.Len(), len(rows))
+	}
+	for q := 0; q < 20; q++ {
+		var bbox BBox
+		var x, y float64
+		if geographic {
+			bbox = BBox{-8 + 8*r.Float64(), 50 + 7*r.Float64(), 0, 0}
+			bbox.MaxX, bbox.MaxY = bbox.MinX+2*r.Float64(), bbox.MinY+2*r.Float64()
+			x, y = -8+10*r.Float64(), 50+9*r.Float64()
+		} else {
+			bbox = BBox{r.Float64() * 600000, r.Float64() * 1200000, 0, 0}
+			bbox.MaxX, bbox.MaxY = bbox.MinX+100000*r.Float64(), bbox.MinY+100000*r.Float64()
+			x, y = r.Float64()*700000, r.Float64()*1300000
+		}
+
+		var want []*Row
+		for _, row := range rows {
+			if px, py, _ := point(row); bbox.Contains(px, py) {
+				want = append(want, row)
+			}
+		}
+		got := index.Query(bbox)
+		if len(got) != len(want) {
+			t.Fatalf("Query(%v): %d rows, want %d", bbox, len(got), len(want))
+		}
+		wantSet := licenceSet(want)
+		for _, row := range got {
+			if !wantSet[row.LicenceNumber] {
+				t.Fatalf("Query(%v): unexpected row %s", bbox, row.LicenceNumber)
+			}
+		}
+
+		cosLat := 1.0
+		if geographic {
+			cosLat = math.Cos(radians(y))
+		}
+		d2 := func(row *Row) float64 {
+			px, py, _ := point(row)
+			return distance2(x, y, pointBBox(px, py), cosLat)
+		}
+		sorted := append([]*Row(nil), rows...)
+		sort.Slice(sorted, func(i, j int) bool { return d2(sorted[i]) < d2(sorted[j]) })
+		nearest := index.Nearest(x, y, 5)
+		for i, row := range nearest {
+			if d2(row) != d2(sorted[i]) {
+				t.Fatalf("Nearest(%v, %v) %d: got %s, want %s", x, y, i, row.LicenceNumber, sorted[i].LicenceNumber)
+			}
+		}
+		if len(nearest) != minInt(5, len(rows)) {
+			t.Fatalf("Nearest: %d rows", len(nearest))
+		}
+	}
+}
+
+func TestSpatialIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rows := randomRows(r, 2000)
+	collection := &Collection{Rows: append(rows, &Row{LicenceNumber: "nowhere"})}
+
+	checkSpatialIndex(t, r, collection.BuildSpatialIndex(), rows, wgs84Point, true)
+	checkSpatialIndex(t, r, collection.BuildSpatialIndexOSGB(), rows, osgbPoint, false)
+}
+
+func TestSpatialIndexUpdates(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	rows := randomRows(r, 1000)
+	index := (&Collection{Rows: rows[:500]}).BuildSpatialIndex()
+
+	// Add the rest, remove every third row, one at a time.
+	for _, row := range rows[500:] {
+		index.Add(row)
+	}
+	var kept []*Row
+	for i, row := range rows {
+		if i%3 == 0 {
+			index.Remove(row)
+		} else {
+			kept = append(kept, row)
+		}
+	}
+	index.Remove(&Row{Wgs84LongitudeAsString: "0", Wgs84LatitudeAsString: "51", Wgs84Latitude: 51}) // absent
+	checkSpatialIndex(t, r, index, kept, wgs84Point, true)
+
+	for _, row := range kept {
+		index.Remove(row)
+	}
+	if index.Len() != 0 || len(index.Query(BBox{-180, -90, 180, 90})) != 0 {
+		t.Fatal("index not empty")
+	}
+	index.Add(rows[0])
+	if got := index.Nearest(0, 0, 3); len(got) != 1 || got[0] != rows[0] {
+		t.Fatalf("got %v", got)
+	}
+}
+
+var _ Index = (*SpatialIndex)(nil)
@@ -0,0 +1,80 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// SchemaReport is the difference between the header of a csv and the
+// columns of Row, eg. to alert when OFCOM changes the register format.
+type SchemaReport struct {
+	// Unknown are the headings that are not columns of Row, in order.
+	Unknown []string `json:"unknown"`
+	// Missing are the headings of the original OFCOM csv absent from the
+	// csv, in order. The munged columns are optional.
+	Missing []string `json:"missing"`
+	// Renamed maps the headings mapped to a canonical heading (see
+	// HeaderAliases) to that heading.
+	Renamed map[string]string `json:"renamed,omitempty"`
+}
+
+// CheckSchema returns the SchemaReport of a csv header.
+func CheckSchema(header []string) *SchemaReport {
+	report := &SchemaReport{}
+	present := make(map[string]bool, len(header))
+	for i, heading := range canonicalHeader(header) {
+		present[heading] = true
+		switch {
+		case !isHeading(heading):
+			report.Unknown = append(report.Unknown, heading)
+		case heading != header[i]:
+			if report.Renamed == nil {
+				report.Renamed = make(map[string]string)
+			}
+			report.Renamed[header[i]] = heading
+		}
+	}
+	for _, heading := range standardHeader {
+		if !present[heading] {
+			report.Missing = append(report.Missing, heading)
+		}
+	}
+	return report
+}
+
+// checkStrictHeader returns an error describing where a csv header first
+// differs from the header of the original OFCOM csv.
+func checkStrictHeader(header []string) error {
+	for i, heading := range header {
+		if i == len(standardHeader) {
+			return errors.Errorf("header has %d columns, want %d: unexpected column %d %q", len(header), len(standardHeader), i+1, heading)
+		}
+		if heading != standardHeader[i] {
+			return errors.Errorf("header column %d is %q, want %q", i+1, heading, standardHeader[i])
+		}
+	}
+	if len(header) < len(standardHeader) {
+		return errors.Errorf("header has %d columns, want %d: missing column %d %q", len(header), len(standardHeader), len(header)+1, standardHeader[len(header)])
+	}
+	return nil
+}
+
+// OK reports whether there are no unknown or missing columns.
+func (report *SchemaReport) OK() bool {
+	return len(report.Unknown) == 0 && len(report.Missing) == 0
+}
+
+func (report *SchemaReport) String() string {
+	if report.OK() {
+		return "no unknown or missing columns"
+	}
+	var parts []string
+	if len(report.Unknown) > 0 {
+		parts = append(parts, fmt.Sprintf("unknown columns %q", report.Unknown))
+	}
+	if len(report.Missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing columns %q", report.Missing))
+	}
+	return strings.Join(parts, ", ")
+}
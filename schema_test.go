@@ -0,0 +1,51 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestCheckSchema(t *testing.T) {
+	header := append([]string{}, standardHeader...)
+	if report := CheckSchema(append(header, HeadingOsEasting)); !report.OK() || report.Renamed != nil {
+		t.Errorf("drift in the OFCOM header: %v", report)
+	}
+
+	header[0] = "LICENCE NUMBER"
+	header = append(header[:10], header[11:]...) // no NGR
+	report := CheckSchema(append(header, "Notes"))
+	if report.OK() {
+		t.Fatal("no drift")
+	}
+	if len(report.Unknown) != 1 || report.Unknown[0] != "Notes" {
+		t.Errorf("wrong unknown %q", report.Unknown)
+	}
+	if len(report.Missing) != 1 || report.Missing[0] != "NGR" {
+		t.Errorf("wrong missing %q", report.Missing)
+	}
+	if report.Renamed["LICENCE NUMBER"] != "Licence Number" || len(report.Renamed) != 1 {
+		t.Errorf("wrong renamed %v", report.Renamed)
+	}
+	if got := report.String(); got != `unknown columns ["Notes"], missing columns ["NGR"]` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestCheckStrictHeader(t *testing.T) {
+	header := append([]string{}, standardHeader...)
+	if err := checkStrictHeader(header); err != nil {
+		t.Error(err)
+	}
+	for _, test := range []struct {
+		header []string
+		want   string
+	}{
+		{append(append([]string{}, header...), HeadingOsEasting), `header has 47 columns, want 46: unexpected column 47 "OS Easting"`},
+		{header[:45], `header has 45 columns, want 46: missing column 46 "Product Description 32"`},
+		{append([]string{"NGR"}, header[1:]...), `header column 1 is "NGR", want "Licence Number"`},
+		{nil, `header has 0 columns, want 46: missing column 1 "Licence Number"`},
+	} {
+		if err := checkStrictHeader(test.header); err == nil || err.Error() != test.want {
+			t.Errorf("got %v, want %s", err, test.want)
+		}
+	}
+}
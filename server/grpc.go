@@ -0,0 +1,342 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"github.com/recombinant/go-wtrcsv/internal/protowire"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The LicenceService of wtr.proto is served by ServeHTTP for gRPC requests
+// over HTTP/2. The methods are also available directly, for use in-process
+// or from another transport.
+
+// gRPC status codes.
+const (
+	codeOK                = 0
+	codeCanceled          = 1
+	codeInvalidArgument   = 3
+	codeNotFound          = 5
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+	codeUnauthenticated   = 16
+)
+
+// Status is an error with a gRPC status code.
+type Status struct {
+	Code    int
+	Message string
+}
+
+func (status *Status) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", status.Code, status.Message)
+}
+
+func statusf(code int, format string, args ...interface{}) *Status {
+	return &Status{code, fmt.Sprintf(format, args...)}
+}
+
+// ListLicencesRequest is a request of LicenceService.ListLicences, with
+// the parameters of GET /licences.
+type ListLicencesRequest struct {
+	Companies    []string
+	ProductCodes []string
+	FreqMinMHz   float64 // 0 for no minimum
+	FreqMaxMHz   float64 // 0 for no maximum
+	BBox         *[4]float64
+	Sort         string
+	Fields       []string
+	PageSize     int
+	PageToken    string
+}
+
+// values returns the request as /licences query parameters, so that it is
+// interpreted, and its page tokens bound, exactly as the HTTP query.
+func (req *ListLicencesRequest) values() url.Values {
+	query := url.Values{}
+	query["company"] = req.Companies
+	query["product_code"] = req.ProductCodes
+	if req.FreqMinMHz != 0 {
+		query.Set("freq_min", strconv.FormatFloat(req.FreqMinMHz, 'g', -1, 64))
+	}
+	if req.FreqMaxMHz != 0 {
+		query.Set("freq_max", strconv.FormatFloat(req.FreqMaxMHz, 'g', -1, 64))
+	}
+	if req.BBox != nil {
+		parts := make([]string, 4)
+		for i, v := range req.BBox {
+			parts[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		query.Set("bbox", strings.Join(parts, ","))
+	}
+	if req.Sort != "" {
+		query.Set("sort", req.Sort)
+	}
+	if len(req.Fields) > 0 {
+		query.Set("fields", strings.Join(req.Fields, ","))
+	}
+	if req.PageSize != 0 {
+		query.Set("page_size", strconv.Itoa(req.PageSize))
+	}
+	if req.PageToken != "" {
+		query.Set("page_token", req.PageToken)
+	}
+	for key, values := range query {
+		if len(values) == 0 {
+			delete(query, key)
+		}
+	}
+	return query
+}
+
+// ListLicences calls send with each licence matching the request, returning
+// the token of the next page if there is one.
+func (server *Server) ListLicences(ctx context.Context, req *ListLicencesRequest, send func(row *wtrcsv.Row) error) (nextPageToken string, err error) {
+	query := req.values()
+	filters, err := parseFilters(query)
+	if err != nil {
+		return "", statusf(codeInvalidArgument, "%v", err)
+	}
+	list, err := server.parseList(query)
+	if err != nil {
+		return "", statusf(codeInvalidArgument, "%v", err)
+	}
+	collection, next, release := list.page(server.Collection(), filters)
+	defer release()
+
+	for _, row := range collection.Rows {
+		if err := ctx.Err(); err != nil {
+			return "", statusf(codeCanceled, "%v", err)
+		}
+		if list.fields != nil {
+			row = projectRow(row, list.fields)
+		}
+		if err := send(row); err != nil {
+			return "", err
+		}
+	}
+	if next > 0 {
+		nextPageToken = server.pageToken(next, query)
+	}
+	return nextPageToken, nil
+}
+
+// persistentFields are the fields holding the persistent representation of
+// derived fields, which must be projected with them.
+var persistentFields = map[string]string{
+	"wgs84_longitude": "Wgs84LongitudeAsString",
+	"wgs84_latitude":  "Wgs84LatitudeAsString",
+}
+
+// projectRow returns a copy of a row with only the given fields.
+func projectRow(row *wtrcsv.Row, fields []string) *wtrcsv.Row {
+	projected := new(wtrcsv.Row)
+	from, to := reflect.ValueOf(row).Elem(), reflect.ValueOf(projected).Elem()
+	for _, field := range fields {
+		to.Field(rowFields[field]).Set(from.Field(rowFields[field]))
+		if name, ok := persistentFields[field]; ok {
+			to.FieldByName(name).Set(from.FieldByName(name))
+		}
+	}
+	return projected
+}
+
+// GetByLicenceNumber returns the rows of a licence.
+func (server *Server) GetByLicenceNumber(ctx context.Context, licenceNumber string) ([]*wtrcsv.Row, error) {
+	rows := server.licenceIndex().GetByLicenceNumber(licenceNumber)
+	if len(rows) == 0 {
+		return nil, statusf(codeNotFound, "licence %s not found", licenceNumber)
+	}
+	return rows, nil
+}
+
+// ListCompanies returns the licencee company names.
+func (server *Server) ListCompanies(ctx context.Context) []string {
+	return server.Collection().GetCompanies()
+}
+
+// isGRPC reports whether a request is a gRPC request.
+func isGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+const grpcServicePrefix = "/wtrcsv.LicenceService/"
+
+// serveGRPC serves a gRPC request.
+func (server *Server) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	err := server.callGRPC(w, r)
+	status, ok := err.(*Status)
+	switch {
+	case err == nil:
+		status = &Status{Code: codeOK}
+	case !ok:
+		status = statusf(codeInternal, "%v", err)
+	}
+	writeGRPCStatus(w, status)
+}
+
+// writeGRPCStatus writes the status of a gRPC call. If nothing has been
+// written it is a "trailers-only" response.
+func writeGRPCStatus(w http.ResponseWriter, status *Status) {
+	w.Header().Set("Content-Type", "application/grpc+proto")
+	w.Header().Set("Grpc-Status", strconv.Itoa(status.Code))
+	if status.Message != "" {
+		w.Header().Set("Grpc-Message", url.PathEscape(status.Message))
+	}
+}
+
+func (server *Server) callGRPC(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return statusf(codeUnimplemented, "method %s", r.Method)
+	}
+	if encoding := r.Header.Get("Grpc-Encoding"); encoding != "" && encoding != "identity" {
+		return statusf(codeUnimplemented, "compression %s", encoding)
+	}
+	method := strings.TrimPrefix(r.URL.Path, grpcServicePrefix)
+	if method == r.URL.Path {
+		return statusf(codeUnimplemented, "unknown service %s", r.URL.Path)
+	}
+	message, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return statusf(codeInvalidArgument, "%v", err)
+	}
+
+	switch method {
+	case "ListLicences":
+		req, err := unmarshalListLicencesRequest(message)
+		if err != nil {
+			return statusf(codeInvalidArgument, "%v", err)
+		}
+		token, err := server.ListLicences(r.Context(), req, func(row *wtrcsv.Row) error {
+			return writeGRPCMessage(w, protowire.AppendString(nil, 1, string(wtrcsv.MarshalRow(row))))
+		})
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			return writeGRPCMessage(w, protowire.AppendString(nil, 2, token))
+		}
+		return nil
+
+	case "GetByLicenceNumber":
+		var licenceNumber string
+		err := protowire.Fields(message, func(field *protowire.Field) error {
+			if field.Number == 1 {
+				licenceNumber = string(field.Bytes)
+			}
+			return nil
+		})
+		if err != nil {
+			return statusf(codeInvalidArgument, "%v", err)
+		}
+		rows, err := server.GetByLicenceNumber(r.Context(), licenceNumber)
+		if err != nil {
+			return err
+		}
+		var response []byte
+		for _, row := range rows {
+			response = protowire.AppendString(response, 1, string(wtrcsv.MarshalRow(row)))
+		}
+		return writeGRPCMessage(w, response)
+
+	case "ListCompanies":
+		var response []byte
+		for _, company := range server.ListCompanies(r.Context()) {
+			response = protowire.AppendString(response, 1, company)
+		}
+		return writeGRPCMessage(w, response)
+	}
+	return statusf(codeUnimplemented, "unknown method %s", method)
+}
+
+func unmarshalListLicencesRequest(b []byte) (*ListLicencesRequest, error) {
+	req := &ListLicencesRequest{}
+	err := protowire.Fields(b, func(field *protowire.Field) error {
+		switch field.Number {
+		case 1:
+			req.Companies = append(req.Companies, string(field.Bytes))
+		case 2:
+			req.ProductCodes = append(req.ProductCodes, string(field.Bytes))
+		case 3:
+			req.FreqMinMHz = field.Double()
+		case 4:
+			req.FreqMaxMHz = field.Double()
+		case 5:
+			var bbox [4]float64
+			err := protowire.Fields(field.Bytes, func(field *protowire.Field) error {
+				if field.Number >= 1 && field.Number <= 4 {
+					bbox[field.Number-1] = field.Double()
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			req.BBox = &bbox
+		case 6:
+			req.Sort = string(field.Bytes)
+		case 7:
+			req.Fields = append(req.Fields, string(field.Bytes))
+		case 8:
+			req.PageSize = int(int32(field.Varint))
+		case 9:
+			req.PageToken = string(field.Bytes)
+		}
+		return nil
+	})
+	return req, errors.Wrap(err, "could not decode request")
+}
+
+// maxGRPCMessage is the largest request message accepted.
+const maxGRPCMessage = 1 << 20
+
+// readGRPCMessage reads the single message of a unary or server streaming
+// request.
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, errors.Wrap(err, "could not read message")
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > maxGRPCMessage {
+		return nil, errors.Errorf("message of %d bytes is too large", length)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, errors.Wrap(err, "could not read message")
+	}
+	io.Copy(ioutil.Discard, r)
+	return message, nil
+}
+
+// writeGRPCMessage writes a length-prefixed message and flushes it, so that
+// streamed messages are sent as they are written.
+func writeGRPCMessage(w http.ResponseWriter, message []byte) error {
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(message)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
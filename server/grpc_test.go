@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/recombinant/go-wtrcsv"
+	"github.com/recombinant/go-wtrcsv/internal/protowire"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// callGRPC makes a gRPC call over HTTP/2, returning the response messages
+// and the grpc-status trailer.
+func callGRPC(t *testing.T, ts *httptest.Server, method string, request []byte) ([][]byte, string) {
+	body := new(bytes.Buffer)
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(request)))
+	body.Write(prefix[:])
+	body.Write(request)
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+grpcServicePrefix+method, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("HTTP/%d", resp.ProtoMajor)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages [][]byte
+	for len(b) >= 5 {
+		n := binary.BigEndian.Uint32(b[1:5])
+		messages = append(messages, b[5:5+n])
+		b = b[5+n:]
+	}
+	status := resp.Trailer.Get("Grpc-Status")
+	if status == "" { // trailers-only response
+		status = resp.Header.Get("Grpc-Status")
+	}
+	return messages, status
+}
+
+func startGRPC(t *testing.T) *httptest.Server {
+	ts := httptest.NewUnstartedServer(testServer(t))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	return ts
+}
+
+func rowsOf(t *testing.T, messages [][]byte, field int) (numbers []string, token string) {
+	for _, message := range messages {
+		protowire.Fields(message, func(f *protowire.Field) error {
+			switch {
+			case f.Number == field:
+				row, err := wtrcsv.UnmarshalRow(f.Bytes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				numbers = append(numbers, row.LicenceNumber+":"+row.LicenseeCompany)
+			case f.Number == 2:
+				token = string(f.Bytes)
+			}
+			return nil
+		})
+	}
+	return numbers, token
+}
+
+func TestGRPC(t *testing.T) {
+	ts := startGRPC(t)
+	defer ts.Close()
+
+	var request []byte
+	request = protowire.AppendString(request, 1, "Acme")
+	request = protowire.AppendString(request, 6, "-licence_number")
+	request = protowire.AppendString(request, 7, "licence_number")
+	request = protowire.AppendInt64(request, 8, 1)
+	messages, status := callGRPC(t, ts, "ListLicences", request)
+	numbers, token := rowsOf(t, messages, 1)
+	if status != "0" || strings.Join(numbers, " ") != "0000002/1:" || token == "" {
+		t.Fatalf("status %s: %v, token %q", status, numbers, token)
+	}
+	messages, status = callGRPC(t, ts, "ListLicences", protowire.AppendString(request, 9, token))
+	if numbers, token := rowsOf(t, messages, 1); status != "0" || strings.Join(numbers, " ") != "0000001/1:" || token != "" {
+		t.Fatalf("status %s: %v, token %q", status, numbers, token)
+	}
+
+	var bbox []byte
+	for i, v := range []float64{-1, 51, 1, 52} {
+		bbox = protowire.AppendDouble(bbox, i+1, v)
+	}
+	messages, status = callGRPC(t, ts, "ListLicences", protowire.AppendString(nil, 5, string(bbox)))
+	if numbers, _ := rowsOf(t, messages, 1); status != "0" || strings.Join(numbers, " ") != "0000001/1:Acme" {
+		t.Fatalf("status %s: %v", status, numbers)
+	}
+
+	messages, status = callGRPC(t, ts, "GetByLicenceNumber", protowire.AppendString(nil, 1, "0000003/1"))
+	if numbers, _ := rowsOf(t, messages, 1); status != "0" || strings.Join(numbers, " ") != "0000003/1:Other" {
+		t.Fatalf("status %s: %v", status, numbers)
+	}
+
+	messages, status = callGRPC(t, ts, "ListCompanies", nil)
+	if status != "0" || len(messages) != 1 {
+		t.Fatalf("status %s: %d messages", status, len(messages))
+	}
+
+	for _, test := range []struct {
+		method  string
+		request []byte
+		status  string
+	}{
+		{"GetByLicenceNumber", protowire.AppendString(nil, 1, "nonsense"), "5"},
+		{"ListLicences", protowire.AppendString(nil, 6, "colour"), "3"},
+		{"Nonsense", nil, "12"},
+	} {
+		if _, status := callGRPC(t, ts, test.method, test.request); status != test.status {
+			t.Errorf("%s: status %s, want %s", test.method, status, test.status)
+		}
+	}
+}
+
+func TestListLicences(t *testing.T) {
+	server := testServer(t)
+	var rows []*wtrcsv.Row
+	token, err := server.ListLicences(context.Background(), &ListLicencesRequest{
+		FreqMinMHz: 1000,
+		Fields:     []string{"licence_number", "wgs84_longitude"},
+	}, func(row *wtrcsv.Row) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil || token != "" || len(rows) != 2 {
+		t.Fatalf("%v, %q, %d rows", err, token, len(rows))
+	}
+	if *rows[0] != (wtrcsv.Row{LicenceNumber: "0000001/1", Wgs84Longitude: -0.1388, Wgs84LongitudeAsString: "-0.1388"}) {
+		t.Errorf("wrong projection %+v", rows[0])
+	}
+}
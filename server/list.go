@@ -0,0 +1,242 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"hash/fnv"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPageSize is the largest page_size accepted.
+const maxPageSize = 10000
+
+// sortKeys are the values of the sort parameter.
+var sortKeys = map[string]wtrcsv.CompareFn{
+	"licence_number": wtrcsv.ByLicenceNumber,
+	"company":        wtrcsv.ByCompany,
+	"product_code":   wtrcsv.ByProductCode,
+	"frequency":      wtrcsv.ByFrequency,
+}
+
+// rowFields maps the json names of Row to field indices, for field masks.
+var rowFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(wtrcsv.Row{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// listOptions are the list semantics of a query.
+type listOptions struct {
+	pageSize int // 0 for all
+	offset   int
+	sort     []wtrcsv.CompareFn
+	fields   []string // json names, nil for all
+}
+
+// parseList parses the page_size, page_token, sort and fields parameters.
+//
+// sort is a comma separated list of sort keys, each optionally prefixed by
+// "-" for descending order. fields is a comma separated list of the json
+// names of Row.
+func (server *Server) parseList(query url.Values) (*listOptions, error) {
+	options := &listOptions{}
+	if s := query.Get("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxPageSize {
+			return nil, errors.Errorf("page_size must be 1 to %d", maxPageSize)
+		}
+		options.pageSize = n
+	}
+	if token := query.Get("page_token"); token != "" {
+		offset, err := server.parsePageToken(token, query)
+		if err != nil {
+			return nil, err
+		}
+		options.offset = offset
+	}
+
+	if s := query.Get("sort"); s != "" {
+		for _, key := range strings.Split(s, ",") {
+			key = strings.TrimSpace(key)
+			descending := strings.HasPrefix(key, "-")
+			compare, ok := sortKeys[strings.TrimPrefix(key, "-")]
+			if !ok {
+				return nil, errors.Errorf("unknown sort key %q", key)
+			}
+			if descending {
+				compare = wtrcsv.Descending(compare)
+			}
+			options.sort = append(options.sort, compare)
+		}
+	}
+
+	if s := query.Get("fields"); s != "" {
+		for _, field := range strings.Split(s, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := rowFields[field]; !ok {
+				return nil, errors.Errorf("unknown field %q", field)
+			}
+			options.fields = append(options.fields, field)
+		}
+	}
+	return options, nil
+}
+
+// queryHash identifies the parameters of a query other than paging, so that
+// a page token cannot be used with a different query.
+func queryHash(query url.Values) uint32 {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if key != "page_token" && key != "page_size" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	h := fnv.New32a()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s=%q;", key, query[key])
+	}
+	return h.Sum32()
+}
+
+// pageToken is an opaque token for the page at an offset. It is only valid
+// for the same query against the same Collection.
+func (server *Server) pageToken(offset int, query url.Values) string {
+	s := fmt.Sprintf("%d.%d.%x", offset, server.generation(), queryHash(query))
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func (server *Server) parsePageToken(token string, query url.Values) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errors.New("invalid page_token")
+	}
+	var offset, generation int
+	var hash uint32
+	if _, err := fmt.Sscanf(string(b), "%d.%d.%x", &offset, &generation, &hash); err != nil || offset < 0 {
+		return 0, errors.New("invalid page_token")
+	}
+	if hash != queryHash(query) {
+		return 0, errors.New("page_token does not match query")
+	}
+	if generation != server.generation() {
+		return 0, errors.New("page_token has expired")
+	}
+	return offset, nil
+}
+
+// rowBuffers are the slices of matching rows reused across requests, so
+// that a query does not grow a new slice of the matching rows each time.
+var rowBuffers = sync.Pool{New: func() interface{} { return new([]*wtrcsv.Row) }}
+
+// errPageFull stops FilterEach once a row beyond the page is found.
+var errPageFull = errors.New("page full")
+
+// page filters, sorts and pages the rows of a collection, returning the
+// page and the offset of the next page or 0 if there is none. Without a
+// sort only the rows of the page are kept. release returns the buffer of
+// the page for reuse; the page must not be used after calling it.
+func (options *listOptions) page(collection *wtrcsv.Collection, filters []wtrcsv.FilterFn) (page *wtrcsv.Collection, next int, release func()) {
+	buffer := rowBuffers.Get().(*[]*wtrcsv.Row)
+	rows := (*buffer)[:0]
+	if len(options.sort) > 0 {
+		rows = collection.FilterAppend(rows, filters...)
+		(&wtrcsv.Collection{Rows: rows}).SortBy(options.sort...)
+		*buffer = rows
+		if options.offset > len(rows) {
+			rows = nil
+		} else {
+			rows = rows[options.offset:]
+		}
+		if options.pageSize > 0 && len(rows) > options.pageSize {
+			rows = rows[:options.pageSize]
+			next = options.offset + options.pageSize
+		}
+	} else {
+		matched := 0
+		collection.FilterEach(func(row *wtrcsv.Row) error {
+			if matched >= options.offset {
+				if options.pageSize > 0 && len(rows) == options.pageSize {
+					next = options.offset + options.pageSize
+					return errPageFull
+				}
+				rows = append(rows, row)
+			}
+			matched++
+			return nil
+		}, filters...)
+		*buffer = rows
+	}
+
+	header := collection.Header
+	if options.fields != nil {
+		header = projectHeader(header, options.fields)
+	}
+	release = func() {
+		for i := range *buffer {
+			(*buffer)[i] = nil // do not keep the rows of a replaced collection
+		}
+		*buffer = (*buffer)[:0]
+		rowBuffers.Put(buffer)
+	}
+	return &wtrcsv.Collection{Header: header, Rows: rows}, next, release
+}
+
+// projectHeader returns the headings of a header named in fields.
+func projectHeader(header []string, fields []string) []string {
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+	var projected []string
+	for _, heading := range header {
+		if wanted[wtrcsv.ColumnName(heading)] {
+			projected = append(projected, heading)
+		}
+	}
+	return projected
+}
+
+// writeFieldsJSON writes the rows as a JSON array of objects with only the
+// given fields, in the order given.
+func writeFieldsJSON(writer io.Writer, rows []*wtrcsv.Row, fields []string) error {
+	w := bufio.NewWriter(writer)
+	w.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteByte('{')
+		v := reflect.ValueOf(row).Elem()
+		for j, field := range fields {
+			if j > 0 {
+				w.WriteByte(',')
+			}
+			b, err := json.Marshal(v.Field(rowFields[field]).Interface())
+			if err != nil {
+				return errors.Wrapf(err, "could not encode licence %s", row.LicenceNumber)
+			}
+			fmt.Fprintf(w, "%q:", field)
+			w.Write(b)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString("]\n")
+	return errors.Wrap(w.Flush(), "could not write JSON")
+}
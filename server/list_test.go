@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLicencesSort(t *testing.T) {
+	server := testServer(t)
+	for _, test := range []struct {
+		sort, want string
+	}{
+		{"frequency", "0000002/1 0000001/1 0000003/1"},
+		{"-frequency", "0000003/1 0000001/1 0000002/1"},
+		{"company,-licence_number", "0000002/1 0000001/1 0000003/1"},
+		{"product_code,-company", "0000003/1 0000001/1 0000002/1"},
+	} {
+		if got := licenceNumbers(t, get(t, server, "/licences?sort="+test.sort, "")); got != test.want {
+			t.Errorf("sort=%s: got %q, want %q", test.sort, got, test.want)
+		}
+	}
+	if w := get(t, server, "/licences?sort=colour", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+func TestLicencesPaging(t *testing.T) {
+	server := testServer(t)
+	for target, want := range map[string]string{
+		"/licences?sort=-licence_number&page_size=2": "0000003/1 0000002/1 | 0000001/1",
+		"/licences?page_size=2":                      "0000001/1 0000002/1 | 0000003/1",
+		"/licences?page_size=3":                      "0000001/1 0000002/1 0000003/1",
+	} {
+		var pages []string
+		for target != "" {
+			w := get(t, server, target, "")
+			pages = append(pages, licenceNumbers(t, w))
+			target = ""
+			if link := w.Header().Get("Link"); link != "" {
+				target = link[1:strings.Index(link, ">")]
+			}
+		}
+		if got := strings.Join(pages, " | "); got != want {
+			t.Errorf("wrong pages %q, want %q", got, want)
+		}
+	}
+
+	w := get(t, server, "/licences?page_size=1", "")
+	token := w.Header().Get("X-Next-Page-Token")
+	if token == "" {
+		t.Fatal("no page token")
+	}
+	for _, query := range []string{
+		"page_token=" + token + "&company=Acme", // different query
+		"page_token=nonsense",
+		"page_size=0",
+	} {
+		if w := get(t, server, "/licences?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d", query, w.Code)
+		}
+	}
+
+	// Tokens expire when the collection is replaced.
+	server.SetCollection(server.Collection())
+	if w := get(t, server, "/licences?page_size=1&page_token="+token, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+func TestListPageRelease(t *testing.T) {
+	server := testServer(t)
+	options := &listOptions{pageSize: 2}
+	page, next, release := options.page(server.Collection(), nil)
+	if len(page.Rows) != 2 || next != 2 {
+		t.Fatalf("got %d rows, next %d", len(page.Rows), next)
+	}
+	rows := page.Rows[:cap(page.Rows)]
+	release()
+	for i, row := range rows {
+		if row != nil {
+			t.Errorf("row %d kept after release", i)
+		}
+	}
+}
+
+func TestLicencesFields(t *testing.T) {
+	server := testServer(t)
+	w := get(t, server, "/licences?fields=frequency,licence_number&company=Other", "")
+	if got := strings.TrimSpace(w.Body.String()); got != `[{"frequency":"18","licence_number":"0000003/1"}]` {
+		t.Fatalf("wrong body %s", got)
+	}
+
+	w = get(t, server, "/licences?fields=licence_number&format=geojson&company=Other", "")
+	var fc struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.Features) != 1 || len(fc.Features[0].Properties) != 1 || fc.Features[0].Properties["Licence Number"] != "0000003/1" {
+		t.Fatalf("wrong GeoJSON %s", w.Body)
+	}
+
+	if w := get(t, server, "/licences?fields=licence_number,colour", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d", w.Code)
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options are the middleware of a Server, for exposing it beyond localhost,
+// and the Store of its snapshots. The zero value allows every request, logs
+// nothing and stores nothing.
+type Options struct {
+	// Authenticate identifies the client of a request, or returns an error
+	// to reject it as unauthorised. nil allows every request.
+	Authenticate func(r *http.Request) (client string, err error)
+
+	// RateLimit is the sustained number of requests per second allowed to
+	// each client, 0 for no limit. Burst requests are allowed at once
+	// (default the rate limit, and at least 1).
+	RateLimit float64
+	Burst     int
+
+	// ClientKey identifies a client for rate limiting. The default is the
+	// authenticated client, else the remote IP address.
+	ClientKey func(r *http.Request, client string) string
+
+	// Logger logs each request, nil for none. Errors writing responses
+	// are logged by wtrcsv.Logf.
+	Logger wtrcsv.Logger
+
+	// Viewer serves a map viewer of /licences at /.
+	Viewer bool
+
+	// ViewerAssets is the base URL of the leaflet.js and leaflet.css of the
+	// viewer, default DefaultViewerAssets, or /viewer-assets if given a
+	// ViewerAssetDir. ViewerAssetDir is a directory served (without
+	// authentication) at /viewer-assets/, eg. holding the dist directory
+	// of Leaflet so that the viewer works offline.
+	ViewerAssets   string
+	ViewerAssetDir string
+
+	// Store persists the served snapshots, nil for none. NewFromStore
+	// serves its most recent snapshot and Refresh puts each new snapshot
+	// in it, named by the date, eg. "2018-02-07".
+	Store wtrcsv.Store
+}
+
+// ErrUnauthorised may be returned by Options.Authenticate.
+var ErrUnauthorised = errors.New("unauthorised")
+
+// BearerTokens returns an Authenticate func accepting requests with an
+// "Authorization: Bearer <token>" header for one of the tokens, which map
+// to client names.
+func BearerTokens(tokens map[string]string) func(r *http.Request) (string, error) {
+	return func(r *http.Request) (string, error) {
+		token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		if client, ok := tokens[token]; ok && token != "" {
+			return client, nil
+		}
+		return "", ErrUnauthorised
+	}
+}
+
+// NewWithOptions returns a Server for a Collection with middleware.
+func NewWithOptions(collection *wtrcsv.Collection, options *Options) *Server {
+	server := New(collection)
+	if options != nil {
+		server.options = *options
+		if options.Viewer {
+			server.mux.HandleFunc("/", server.handleViewer)
+			if options.ViewerAssetDir != "" {
+				server.mux.Handle(viewerAssetPath, http.StripPrefix(viewerAssetPath, http.FileServer(http.Dir(options.ViewerAssetDir))))
+			}
+		}
+		if options.RateLimit > 0 {
+			server.limiter = newRateLimiter(options.RateLimit, options.Burst)
+		}
+	}
+	return server
+}
+
+// remoteHost is the IP address of the client of a request.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// reject writes an error response, as a gRPC status for a gRPC request.
+func reject(w http.ResponseWriter, r *http.Request, status int, grpcCode int, message string) {
+	if isGRPC(r) {
+		writeGRPCStatus(w, &Status{grpcCode, message})
+		return
+	}
+	writeError(w, status, message)
+}
+
+// middleware authenticates and rate limits a request, returning false if it
+// has been rejected.
+func (server *Server) middleware(w http.ResponseWriter, r *http.Request) bool {
+	options := &server.options
+	var client string
+	if options.Authenticate != nil && !server.isViewer(r.URL.Path) {
+		var err error
+		if client, err = options.Authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			reject(w, r, http.StatusUnauthorized, codeUnauthenticated, err.Error())
+			return false
+		}
+	}
+
+	if server.limiter != nil {
+		var key string
+		switch {
+		case options.ClientKey != nil:
+			key = options.ClientKey(r, client)
+		case client != "":
+			key = client
+		default:
+			key = remoteHost(r)
+		}
+		if wait := server.limiter.allow(key); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			reject(w, r, http.StatusTooManyRequests, codeResourceExhausted, "rate limit exceeded")
+			return false
+		}
+	}
+	return true
+}
+
+// loggingWriter records the status and size of a response.
+type loggingWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *loggingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush is needed for streamed gRPC responses.
+func (w *loggingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// logRequest logs a request after it has been served.
+func (server *Server) logRequest(w *loggingWriter, r *http.Request, start time.Time) {
+	status := strconv.Itoa(w.status)
+	if grpcStatus := w.Header().Get("Grpc-Status"); grpcStatus != "" {
+		status += " grpc-status " + grpcStatus
+	}
+	server.options.Logger.Printf("%s %s %s %s %d %v",
+		remoteHost(r), r.Method, r.URL.RequestURI(), status, w.size, time.Since(start))
+}
+
+// rateLimiter is a token bucket rate limiter per client.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// maxBuckets is the number of clients above which full buckets, which are
+// the same as no bucket, are discarded.
+const maxBuckets = 10000
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token for a client, returning 0 if one was available, else
+// the time until one will be.
+func (limiter *rateLimiter) allow(key string) time.Duration {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := limiter.now()
+
+	b, ok := limiter.buckets[key]
+	if !ok {
+		if len(limiter.buckets) >= maxBuckets {
+			limiter.prune(now)
+		}
+		b = &bucket{tokens: limiter.burst, last: now}
+		limiter.buckets[key] = b
+	}
+	b.tokens = math.Min(limiter.burst, b.tokens+now.Sub(b.last).Seconds()*limiter.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / limiter.rate * float64(time.Second))
+}
+
+func (limiter *rateLimiter) prune(now time.Time) {
+	for key, b := range limiter.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*limiter.rate >= limiter.burst {
+			delete(limiter.buckets, key)
+		}
+	}
+}
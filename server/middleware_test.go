@@ -0,0 +1,91 @@
+package server
+
+import (
+	"bytes"
+	"github.com/recombinant/go-wtrcsv"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	server := NewWithOptions(wtrcsv.ReadCSV(strings.NewReader(testCSV)), &Options{
+		Authenticate: BearerTokens(map[string]string{"secret": "webteam"}),
+	})
+	for _, test := range []struct {
+		authorization string
+		status        int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer nonsense", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/companies", nil)
+		r.Header.Set("Authorization", test.authorization)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%q: status %d, want %d", test.authorization, w.Code, test.status)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := NewWithOptions(wtrcsv.ReadCSV(strings.NewReader(testCSV)), &Options{RateLimit: 1, Burst: 2})
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	server.limiter.now = func() time.Time { return now }
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/companies", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		return w
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if w := request("192.0.2.1:1234"); w.Code != want {
+			t.Errorf("request %d: status %d, want %d", i, w.Code, want)
+		}
+	}
+	// Other clients have their own limit, and the port is not the client.
+	if w := request("192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("status %d", w.Code)
+	}
+	w := request("192.0.2.1:5678")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	now = now.Add(time.Second)
+	if w := request("192.0.2.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("status %d after waiting", w.Code)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	b := new(bytes.Buffer)
+	server := NewWithOptions(wtrcsv.ReadCSV(strings.NewReader(testCSV)), &Options{Logger: log.New(b, "", 0)})
+	get(t, server, "/licences?company=Acme", "")
+	get(t, server, "/nonsense", "")
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "192.0.2.1 GET /licences?company=Acme 200 ") ||
+		!strings.HasPrefix(lines[1], "192.0.2.1 GET /nonsense 404 ") {
+		t.Fatalf("wrong log:\n%s", b)
+	}
+}
+
+func TestGRPCUnauthenticated(t *testing.T) {
+	server := NewWithOptions(wtrcsv.ReadCSV(strings.NewReader(testCSV)), &Options{
+		Authenticate: BearerTokens(map[string]string{"secret": "webteam"}),
+	})
+	ts := httptest.NewUnstartedServer(server)
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+	if _, status := callGRPC(t, ts, "ListCompanies", nil); status != "16" {
+		t.Fatalf("status %s", status)
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/recombinant/go-wtrcsv"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// parameter is an OpenAPI query parameter.
+type parameter struct {
+	Name        string                 `json:"name"`
+	In          string                 `json:"in"`
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+	Explode     *bool                  `json:"explode,omitempty"`
+}
+
+var explode = true
+
+func stringSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func numberSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "number"}
+}
+
+// licenceParameters are the query parameters of /licences (see parseFilters
+// and parseList).
+func licenceParameters() []parameter {
+	repeated := map[string]interface{}{"type": "array", "items": stringSchema()}
+	sortKeyNames := make([]string, 0, len(sortKeys))
+	for key := range sortKeys {
+		sortKeyNames = append(sortKeyNames, key)
+	}
+	sort.Strings(sortKeyNames)
+
+	return []parameter{
+		{"company", "query", "Licencee company. Repeat for any of several.", repeated, &explode},
+		{"product_code", "query", "Numerical product code (Product Description 31). Repeat for any of several.", repeated, &explode},
+		{"freq_min", "query", "Minimum frequency in MHz.", numberSchema(), nil},
+		{"freq_max", "query", "Maximum frequency in MHz.", numberSchema(), nil},
+		{"bbox", "query", "WGS84 bounding box: minlon,minlat,maxlon,maxlat.", stringSchema(), nil},
+		{"format", "query", "Overrides the Accept header.", map[string]interface{}{"type": "string", "enum": []string{"json", "geojson"}}, nil},
+		{"sort", "query", "Comma separated sort keys, each prefixed by - for descending order: " + strings.Join(sortKeyNames, ", ") + ".", stringSchema(), nil},
+		{"fields", "query", "Comma separated Licence property names to return.", stringSchema(), nil},
+		{"page_size", "query", "Maximum number of licences. All by default.", map[string]interface{}{"type": "integer", "minimum": 1, "maximum": maxPageSize}, nil},
+		{"page_token", "query", "X-Next-Page-Token of the previous page.", stringSchema(), nil},
+	}
+}
+
+// licenceSchema is the schema of a Licence, generated from the columns of
+// wtrcsv.Row.
+func licenceSchema() map[string]interface{} {
+	rowType := reflect.TypeOf(wtrcsv.Row{})
+	properties := make(map[string]interface{})
+	for _, heading := range wtrcsv.Headings() {
+		name := wtrcsv.ColumnName(heading)
+		i, ok := rowFields[name]
+		if !ok {
+			continue
+		}
+		schema := map[string]interface{}{"description": heading}
+		switch rowType.Field(i).Type.Kind() {
+		case reflect.Float64:
+			schema["type"] = "number"
+		case reflect.Int:
+			schema["type"] = "integer"
+		default:
+			schema["type"] = "string"
+		}
+		properties[name] = schema
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func jsonResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			contentTypeJSON: map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "array", "items": schema}
+}
+
+// OpenAPI returns an OpenAPI 3.0 document describing the endpoints of the
+// server. It is also served at /openapi.json.
+func OpenAPI() []byte {
+	errorResponse := jsonResponse("Error", ref("Error"))
+	licences := jsonResponse("Licences", arrayOf(ref("Licence")))
+	licences["content"].(map[string]interface{})[contentTypeGeoJSON] = map[string]interface{}{
+		"schema": map[string]interface{}{
+			"type":        "object",
+			"description": "GeoJSON FeatureCollection of Point features with the csv columns as properties.",
+		},
+	}
+	licences["headers"] = map[string]interface{}{
+		"X-Next-Page-Token": map[string]interface{}{
+			"description": "Token of the next page, if any.",
+			"schema":      stringSchema(),
+		},
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Wireless Telegraphy Register",
+			"description": "The OFCOM Wireless Telegraphy Register.",
+			"version":     "1",
+		},
+		"paths": map[string]interface{}{
+			"/licences": map[string]interface{}{
+				"get": map[string]interface{}{
+					"operationId": "listLicences",
+					"summary":     "List licences",
+					"parameters":  licenceParameters(),
+					"responses": map[string]interface{}{
+						"200": licences,
+						"400": errorResponse,
+						"406": errorResponse,
+					},
+				},
+			},
+			"/companies": map[string]interface{}{
+				"get": map[string]interface{}{
+					"operationId": "listCompanies",
+					"summary":     "List licencee companies",
+					"responses": map[string]interface{}{
+						"200": jsonResponse("Companies", arrayOf(stringSchema())),
+					},
+				},
+			},
+			"/product-codes": map[string]interface{}{
+				"get": map[string]interface{}{
+					"operationId": "listProductCodes",
+					"summary":     "List numerical product codes",
+					"responses": map[string]interface{}{
+						"200": jsonResponse("Product codes", arrayOf(ref("ProductCode"))),
+					},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"Licence": licenceSchema(),
+				"ProductCode": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"code":        stringSchema(),
+						"description": stringSchema(),
+						"count":       map[string]interface{}{"type": "integer"},
+					},
+				},
+				"Error": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"error": stringSchema()},
+				},
+			},
+		},
+	}
+	b, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		panic(err) // the document is static
+	}
+	return append(b, '\n')
+}
+
+func (server *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.Write(openAPIDocument)
+}
+
+var openAPIDocument = OpenAPI()
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	w := get(t, testServer(t), "/openapi.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	var document struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Get struct {
+				Parameters []parameter `json:"parameters"`
+			} `json:"get"`
+		} `json:"paths"`
+		Components struct {
+			Schemas struct {
+				Licence struct {
+					Properties map[string]struct {
+						Type, Description string
+					} `json:"properties"`
+				}
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if document.OpenAPI == "" || len(document.Paths) != 3 {
+		t.Fatalf("wrong document %+v", document)
+	}
+
+	properties := document.Components.Schemas.Licence.Properties
+	if len(properties) != len(rowFields) {
+		t.Errorf("%d properties, want %d", len(properties), len(rowFields))
+	}
+	if p := properties["product_description_31"]; p.Type != "string" || p.Description != "Product Description 31" {
+		t.Errorf("wrong product_description_31 %+v", p)
+	}
+	if p := properties["wgs84_latitude"]; p.Type != "number" {
+		t.Errorf("wrong wgs84_latitude %+v", p)
+	}
+	if p := properties["os_easting"]; p.Type != "integer" {
+		t.Errorf("wrong os_easting %+v", p)
+	}
+
+	// Every documented parameter is understood.
+	for _, p := range document.Paths["/licences"].Get.Parameters {
+		query := url.Values{}
+		switch p.Name {
+		case "page_token":
+			continue
+		case "bbox":
+			query.Set(p.Name, "0,0,1,1")
+		case "format":
+			query.Set(p.Name, "json")
+		case "sort":
+			query.Set(p.Name, "frequency")
+		case "fields":
+			query.Set(p.Name, "licence_number")
+		default:
+			query.Set(p.Name, "1")
+		}
+		if _, err := parseFilters(query); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+		if _, err := testServer(t).parseList(query); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
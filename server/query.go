@@ -0,0 +1,133 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// parseFilters returns the filters of the /licences query parameters:
+//
+//	company       licencee company (repeatable)
+//	product_code  numerical product code (repeatable)
+//	freq_min      minimum frequency in MHz
+//	freq_max      maximum frequency in MHz
+//	bbox          WGS84 minlon,minlat,maxlon,maxlat
+func parseFilters(query url.Values) ([]wtrcsv.FilterFn, error) {
+	var filters []wtrcsv.FilterFn
+	if companies := query["company"]; len(companies) > 0 {
+		filters = append(filters, wtrcsv.FilterCompanies(companies...))
+	}
+	if codes := query["product_code"]; len(codes) > 0 {
+		filters = append(filters, wtrcsv.FilterNumericalProductCodes(codes...))
+	}
+
+	if query.Get("freq_min") != "" || query.Get("freq_max") != "" {
+		low, err := parseFloat(query, "freq_min", 0)
+		if err != nil {
+			return nil, err
+		}
+		high, err := parseFloat(query, "freq_max", 1e12)
+		if err != nil {
+			return nil, err
+		}
+		if low > high {
+			return nil, errors.New("freq_min exceeds freq_max")
+		}
+		filters = append(filters, wtrcsv.FilterFrequencyRange(low, high))
+	}
+
+	if s := query.Get("bbox"); s != "" {
+		bbox, err := parseBBox(s)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, wtrcsv.FilterBoundingBox(bbox[1], bbox[0], bbox[3], bbox[2]))
+	}
+	return filters, nil
+}
+
+func parseFloat(query url.Values, name string, missing float64) (float64, error) {
+	s := query.Get(name)
+	if s == "" {
+		return missing, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, errors.Wrapf(err, "bad %s", name)
+}
+
+// parseBBox parses "minlon,minlat,maxlon,maxlat".
+func parseBBox(s string) ([4]float64, error) {
+	var bbox [4]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return bbox, errors.New("bbox must be minlon,minlat,maxlon,maxlat")
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return bbox, errors.Wrap(err, "bad bbox")
+		}
+		bbox[i] = v
+	}
+	if bbox[0] > bbox[2] || bbox[1] > bbox[3] {
+		return bbox, errors.New("bbox minimum exceeds maximum")
+	}
+	return bbox, nil
+}
+
+// negotiate returns the offer best matching an Accept header, or "" if none
+// is acceptable. Offers are in order of preference; a missing header
+// accepts the first.
+func negotiate(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	best, bestQ, bestSpecificity := "", 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		for _, offer := range offers {
+			specificity := matches(mediaType, offer)
+			if specificity < 0 {
+				continue
+			}
+			// A more specific range wins at equal quality, then the
+			// order of the offers.
+			if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+				best, bestQ, bestSpecificity = offer, q, specificity
+			}
+			break
+		}
+	}
+	return best
+}
+
+// matches returns how specifically a media range matches a type: 2 exactly,
+// 1 by subtype wildcard, 0 by */*, or -1 if it does not match.
+func matches(mediaRange, mediaType string) int {
+	if mediaRange == mediaType {
+		return 2
+	}
+	if mediaRange == "*/*" {
+		return 0
+	}
+	if strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")) {
+		return 1
+	}
+	return -1
+}
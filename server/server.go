@@ -0,0 +1,265 @@
+// Package server exposes a wtrcsv.Collection over HTTP.
+//
+// Endpoints:
+//
+//	GET /licences       licences, filtered by query parameters
+//	GET /companies      licencee company names
+//	GET /product-codes  numerical product codes with description and count
+//	GET /openapi.json   OpenAPI document describing the endpoints
+//	GET /               map viewer, if Options.Viewer
+//	GET /viewer-assets/ Leaflet assets of the viewer, if Options.ViewerAssetDir
+//
+// /licences is JSON, or GeoJSON when the client prefers
+// application/geo+json (or asks for format=geojson). It may be sorted, paged
+// and restricted to a subset of fields (see parseList); the token of the
+// next page is in the X-Next-Page-Token header and a rel="next" Link.
+//
+// The LicenceService of wtr.proto is served over gRPC on the same handler;
+// gRPC needs HTTP/2, so the server must be served with TLS.
+package server
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeGeoJSON = "application/geo+json"
+)
+
+// Server is an http.Handler serving a Collection. The Collection may be
+// replaced while serving, eg. when a new snapshot is downloaded.
+type Server struct {
+	mu         sync.RWMutex
+	collection *wtrcsv.Collection
+	gen        int // incremented when the collection is replaced
+	index      *wtrcsv.LicenceIndex
+	mux        *http.ServeMux
+	options    Options
+	limiter    *rateLimiter
+}
+
+// New returns a Server for a Collection, without middleware (see
+// NewWithOptions).
+func New(collection *wtrcsv.Collection) *Server {
+	server := &Server{collection: collection, mux: http.NewServeMux()}
+	server.mux.HandleFunc("/licences", server.handleLicences)
+	server.mux.HandleFunc("/companies", server.handleCompanies)
+	server.mux.HandleFunc("/product-codes", server.handleProductCodes)
+	server.mux.HandleFunc("/openapi.json", server.handleOpenAPI)
+	return server
+}
+
+// SetCollection replaces the served Collection.
+func (server *Server) SetCollection(collection *wtrcsv.Collection) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.collection = collection
+	server.gen++
+	server.index = nil
+}
+
+// NewFromStore returns a Server with middleware for the most recent snapshot
+// of options.Store.
+func NewFromStore(options *Options) (*Server, error) {
+	if options == nil || options.Store == nil {
+		return nil, errors.New("no store")
+	}
+	names, err := options.Store.Names()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list snapshots")
+	}
+	if len(names) == 0 {
+		return nil, wtrcsv.ErrSnapshotNotFound
+	}
+	collection, err := options.Store.Get(names[len(names)-1])
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read snapshot %s", names[len(names)-1])
+	}
+	return NewWithOptions(collection, options), nil
+}
+
+// Refresh replaces the served Collection with a new snapshot, first putting
+// it in the Store of the Options as the snapshot of today. The served
+// Collection is unchanged if it cannot be stored.
+func (server *Server) Refresh(collection *wtrcsv.Collection) error {
+	if store := server.options.Store; store != nil {
+		name := time.Now().Format("2006-01-02")
+		if err := store.Put(name, collection); err != nil {
+			return errors.Wrapf(err, "could not store snapshot %s", name)
+		}
+	}
+	server.SetCollection(collection)
+	return nil
+}
+
+// generation identifies the served Collection, for page tokens.
+func (server *Server) generation() int {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+	return server.gen
+}
+
+// Collection returns the served Collection.
+func (server *Server) Collection() *wtrcsv.Collection {
+	server.mu.RLock()
+	defer server.mu.RUnlock()
+	return server.collection
+}
+
+// licenceIndex returns an index of the served Collection by licence number,
+// building it when first needed.
+func (server *Server) licenceIndex() *wtrcsv.LicenceIndex {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.index == nil {
+		server.index = server.collection.IndexByLicenceNumber()
+	}
+	return server.index
+}
+
+// ServeHTTP serves the endpoints, and LicenceService (see wtr.proto) for
+// gRPC requests.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if server.options.Logger != nil {
+		lw := &loggingWriter{ResponseWriter: w}
+		defer server.logRequest(lw, r, time.Now())
+		w = lw
+	}
+	if !server.middleware(w, r) {
+		return
+	}
+	if isGRPC(r) {
+		server.serveGRPC(w, r)
+		return
+	}
+	server.mux.ServeHTTP(w, r)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		wtrcsv.Logf("could not write response: %v", err)
+	}
+}
+
+// allowGet rejects methods other than GET and HEAD.
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	return true
+}
+
+func (server *Server) handleLicences(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	query := r.URL.Query()
+
+	contentType := contentTypeJSON
+	switch query.Get("format") {
+	case "json":
+	case "geojson":
+		contentType = contentTypeGeoJSON
+	case "":
+		contentType = negotiate(r.Header.Get("Accept"), contentTypeJSON, contentTypeGeoJSON)
+		if contentType == "" {
+			writeError(w, http.StatusNotAcceptable, "supported types are "+contentTypeJSON+" and "+contentTypeGeoJSON)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "format must be json or geojson")
+		return
+	}
+
+	filters, err := parseFilters(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list, err := server.parseList(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collection, next, release := list.page(server.Collection(), filters)
+	defer release()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	if next > 0 {
+		token := server.pageToken(next, query)
+		nextQuery := r.URL.Query()
+		nextQuery.Set("page_token", token)
+		nextURL := *r.URL
+		nextURL.RawQuery = nextQuery.Encode()
+		w.Header().Set("X-Next-Page-Token", token)
+		w.Header().Set("Link", "<"+nextURL.RequestURI()+`>; rel="next"`)
+	}
+	switch {
+	case contentType == contentTypeGeoJSON:
+		err = collection.WriteGeoJSON(w)
+	case list.fields != nil:
+		err = writeFieldsJSON(w, collection.Rows, list.fields)
+	default:
+		err = collection.WriteJSON(w)
+	}
+	if err != nil {
+		wtrcsv.Logf("could not write response: %v", err)
+	}
+}
+
+func (server *Server) handleCompanies(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	writeJSON(w, server.Collection().GetCompanies())
+}
+
+// productCode is an element of the /product-codes response.
+type productCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+}
+
+func (server *Server) handleProductCodes(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	lookup := wtrcsv.GetProductCodeLookup()
+	groups := server.Collection().GroupByProductCode()
+	codes := make([]string, 0, len(groups))
+	for code := range groups {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	result := make([]*productCode, len(codes))
+	for i, code := range codes {
+		group := groups[code]
+		description, ok := lookup[code]
+		if !ok {
+			description = group.Rows[0].ProductDescription32
+		}
+		result[i] = &productCode{Code: code, Description: description, Count: len(group.Rows)}
+	}
+	writeJSON(w, result)
+}
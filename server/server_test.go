@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/recombinant/go-wtrcsv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testCSV = `Licence Number,Licencee Company,Frequency,Frequency Type,Product Description 31,Product Description 32,WGS84 Longitude,WGS84 Latitude
+0000001/1,Acme,7.5,GHz,301010,Fixed Links,-0.1388,51.5215
+0000002/1,Acme,450.1,MHz,408010,Business Radio,-3.1883,55.9533
+0000003/1,Other,18,GHz,301010,Fixed Links,,
+`
+
+func testServer(t *testing.T) *Server {
+	return New(wtrcsv.ReadCSV(strings.NewReader(testCSV)))
+}
+
+func get(t *testing.T, server *Server, target, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	return w
+}
+
+func licenceNumbers(t *testing.T, w *httptest.ResponseRecorder) string {
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var rows []struct {
+		LicenceNumber string `json:"licence_number"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	numbers := make([]string, len(rows))
+	for i, row := range rows {
+		numbers[i] = row.LicenceNumber
+	}
+	return strings.Join(numbers, " ")
+}
+
+func TestLicences(t *testing.T) {
+	server := testServer(t)
+	for _, test := range []struct {
+		query, want string
+	}{
+		{"", "0000001/1 0000002/1 0000003/1"},
+		{"company=Acme", "0000001/1 0000002/1"},
+		{"product_code=301010&product_code=408010&company=Other", "0000003/1"},
+		{"freq_min=1000", "0000001/1 0000003/1"},
+		{"freq_min=400&freq_max=8000", "0000001/1 0000002/1"},
+		{"bbox=-1,51,1,52", "0000001/1"},
+	} {
+		if got := licenceNumbers(t, get(t, server, "/licences?"+test.query, "")); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.query, got, test.want)
+		}
+	}
+
+	for _, query := range []string{"freq_min=x", "freq_min=2&freq_max=1", "bbox=1,2,3", "format=kml"} {
+		if w := get(t, server, "/licences?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d", query, w.Code)
+		}
+	}
+}
+
+func TestLicencesNegotiation(t *testing.T) {
+	server := testServer(t)
+	for _, test := range []struct {
+		target, accept, want string
+	}{
+		{"/licences", "", contentTypeJSON},
+		{"/licences", "*/*", contentTypeJSON},
+		{"/licences", "application/geo+json", contentTypeGeoJSON},
+		{"/licences", "application/json;q=0.5, application/geo+json", contentTypeGeoJSON},
+		{"/licences", "application/geo+json;q=0.5, application/*", contentTypeJSON},
+		{"/licences?format=geojson", "application/json", contentTypeGeoJSON},
+	} {
+		w := get(t, server, test.target, test.accept)
+		if got := w.Header().Get("Content-Type"); got != test.want {
+			t.Errorf("%s Accept %q: got %q, want %q", test.target, test.accept, got, test.want)
+		}
+	}
+
+	w := get(t, server, "/licences", "application/geo+json")
+	var fc struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil || fc.Type != "FeatureCollection" || len(fc.Features) != 3 {
+		t.Fatalf("bad GeoJSON (%v): %s", err, w.Body)
+	}
+
+	if w := get(t, server, "/licences", "text/html"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+func TestCompaniesAndProductCodes(t *testing.T) {
+	server := testServer(t)
+	var companies []string
+	json.Unmarshal(get(t, server, "/companies", "").Body.Bytes(), &companies)
+	if strings.Join(companies, ",") != "Acme,Other" {
+		t.Errorf("wrong companies %v", companies)
+	}
+
+	var codes []productCode
+	json.Unmarshal(get(t, server, "/product-codes", "").Body.Bytes(), &codes)
+	if len(codes) != 2 || codes[0] != (productCode{"301010", "Fixed Links", 2}) || codes[1].Code != "408010" {
+		t.Errorf("wrong product codes %+v", codes)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/companies", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := wtrcsv.NewMemoryStore()
+	options := &Options{Store: store}
+	if _, err := NewFromStore(options); err != wtrcsv.ErrSnapshotNotFound {
+		t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+	}
+	if err := store.Put("2018-02-07", wtrcsv.ReadCSV(strings.NewReader(testCSV))); err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := NewFromStore(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := licenceNumbers(t, get(t, server, "/licences", "")); got != "0000001/1 0000002/1 0000003/1" {
+		t.Errorf("got %q", got)
+	}
+
+	next := wtrcsv.ReadCSV(strings.NewReader("Licence Number\n0000004/1\n"))
+	if err := server.Refresh(next); err != nil {
+		t.Fatal(err)
+	}
+	if got := licenceNumbers(t, get(t, server, "/licences", "")); got != "0000004/1" {
+		t.Errorf("got %q after refresh", got)
+	}
+	names, err := store.Names()
+	if err != nil || len(names) != 2 {
+		t.Fatalf("refreshed snapshot not stored: %v %v", names, err)
+	}
+	if stored, err := store.Get(names[1]); err != nil || len(stored.Rows) != 1 {
+		t.Errorf("wrong stored snapshot: %v", err)
+	}
+}
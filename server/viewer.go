@@ -0,0 +1,183 @@
+package server
+
+import (
+	"html"
+	"net/http"
+	"strings"
+)
+
+// DefaultViewerAssets is the default base URL of the Leaflet assets of the
+// viewer.
+const DefaultViewerAssets = "https://unpkg.com/leaflet@1.9.4/dist"
+
+// viewerAssetPath is where Options.ViewerAssetDir is served.
+const viewerAssetPath = "/viewer-assets/"
+
+// isViewer reports whether a path is of the viewer, which is served without
+// authentication.
+func (server *Server) isViewer(path string) bool {
+	options := &server.options
+	return options.Viewer && (path == "/" ||
+		options.ViewerAssetDir != "" && strings.HasPrefix(path, viewerAssetPath))
+}
+
+// viewerAssets returns the base URL of the Leaflet assets.
+func (server *Server) viewerAssets() string {
+	switch {
+	case server.options.ViewerAssets != "":
+		return strings.TrimSuffix(server.options.ViewerAssets, "/")
+	case server.options.ViewerAssetDir != "":
+		return strings.TrimSuffix(viewerAssetPath, "/")
+	}
+	return DefaultViewerAssets
+}
+
+// handleViewer serves viewerHTML at /.
+func (server *Server) handleViewer(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !allowGet(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(strings.Replace(viewerHTML, "{{assets}}", html.EscapeString(server.viewerAssets()), -1)))
+}
+
+// viewerMaxLicences is the page_size of the viewer's queries.
+const viewerMaxLicences = "5000"
+
+// viewerHTML is a single page map viewer of /licences. It has no data of
+// its own, so it is served without authentication; if the server needs a
+// token the page asks for one. {{assets}} is the base URL of Leaflet.
+const viewerHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Wireless Telegraphy Register</title>
+<link rel="stylesheet" href="{{assets}}/leaflet.css">
+<script src="{{assets}}/leaflet.js"></script>
+<style>
+html, body { height: 100%; margin: 0; font: 14px sans-serif; }
+#map { position: absolute; top: 0; bottom: 0; left: 260px; right: 0; }
+#query { position: absolute; top: 0; bottom: 0; left: 0; width: 244px; padding: 8px; overflow: auto; }
+#query label { display: block; margin-top: 8px; }
+#query input { width: 100%; box-sizing: border-box; }
+#status { margin-top: 12px; color: #555; }
+</style>
+</head>
+<body>
+<form id="query">
+<label>Company <input name="company" placeholder="exact name"></label>
+<label>Product code <input name="product_code" placeholder="eg. 301010"></label>
+<label>Frequency from (MHz) <input name="freq_min" type="number" step="any"></label>
+<label>Frequency to (MHz) <input name="freq_max" type="number" step="any"></label>
+<label><input type="checkbox" name="in_view" style="width: auto"> Only the area in view</label>
+<p><button type="submit">Search</button></p>
+<div id="status"></div>
+</form>
+<div id="map"></div>
+<script>
+var fields = "licence_number,licencee_company,licencee_first_name,licencee_surname," +
+  "frequency,frequency_type,antenna_erp,antenna_erp_type,product_description_31,antenna_location";
+var map = L.map("map").setView([54.5, -3], 6);
+L.tileLayer("https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png", {
+  maxZoom: 19,
+  attribution: "&copy; OpenStreetMap contributors"
+}).addTo(map);
+var results = L.layerGroup().addTo(map);
+var form = document.getElementById("query");
+var status = document.getElementById("status");
+var token = sessionStorage.getItem("wtr-token");
+
+function escape(s) {
+  var div = document.createElement("div");
+  div.textContent = s;
+  return div.innerHTML;
+}
+
+function join(value, unit) {
+  return value ? value + " " + (unit || "") : "";
+}
+
+function popup(p) {
+  var licensee = p["Licencee Company"] ||
+    ((p["Licencee First Name"] || "") + " " + (p["Licencee Surname"] || "")).trim();
+  var rows = [
+    ["Licence", p["Licence Number"]],
+    ["Licensee", licensee],
+    ["Frequency", join(p["Frequency"], p["Frequency Type"])],
+    ["ERP", join(p["Antenna ERP"], p["Antenna ERP type"])],
+    ["Product code", p["Product Description 31"]],
+    ["Location", p["Antenna Location"]]
+  ];
+  return rows.filter(function (r) { return r[1]; }).map(function (r) {
+    return "<b>" + r[0] + "</b> " + escape(r[1]);
+  }).join("<br>");
+}
+
+function search() {
+  var params = new URLSearchParams();
+  ["company", "product_code", "freq_min", "freq_max"].forEach(function (name) {
+    var value = form.elements[name].value.trim();
+    if (value) {
+      params.set(name, value);
+    }
+  });
+  if (form.elements.in_view.checked) {
+    var b = map.getBounds();
+    params.set("bbox", [b.getWest(), b.getSouth(), b.getEast(), b.getNorth()].join(","));
+  }
+  params.set("fields", fields);
+  params.set("page_size", "` + viewerMaxLicences + `");
+  var headers = {"Accept": "application/geo+json"};
+  if (token) {
+    headers["Authorization"] = "Bearer " + token;
+  }
+
+  status.textContent = "Searching…";
+  fetch("licences?" + params, {headers: headers}).then(function (response) {
+    if (response.status === 401) {
+      token = prompt("Access token");
+      if (token) {
+        sessionStorage.setItem("wtr-token", token);
+        return search();
+      }
+    }
+    if (!response.ok) {
+      return response.json().then(function (e) { throw new Error(e.error || response.statusText); });
+    }
+    var more = response.headers.get("X-Next-Page-Token");
+    return response.json().then(function (fc) {
+      results.clearLayers();
+      var located = fc.features.filter(function (f) { return f.geometry; });
+      var layer = L.geoJSON({type: "FeatureCollection", features: located}, {
+        pointToLayer: function (feature, latlng) {
+          return L.circleMarker(latlng, {radius: 5, weight: 1, fillOpacity: 0.7});
+        },
+        onEachFeature: function (feature, marker) {
+          marker.bindPopup(popup(feature.properties));
+        }
+      }).addTo(results);
+      status.textContent = fc.features.length + " licences" +
+        (more ? " (the first ` + viewerMaxLicences + `)" : "") +
+        ", " + (fc.features.length - located.length) + " without coordinates.";
+      if (located.length && !form.elements.in_view.checked) {
+        map.fitBounds(layer.getBounds(), {padding: [20, 20]});
+      }
+    });
+  }).catch(function (e) {
+    status.textContent = e.message;
+  });
+}
+
+form.addEventListener("submit", function (e) {
+  e.preventDefault();
+  search();
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"github.com/recombinant/go-wtrcsv"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestViewer(t *testing.T) {
+	collection := wtrcsv.ReadCSV(strings.NewReader(testCSV))
+	if w := get(t, New(collection), "/", ""); w.Code != http.StatusNotFound {
+		t.Errorf("viewer served without Options.Viewer: status %d", w.Code)
+	}
+
+	server := NewWithOptions(collection, &Options{
+		Viewer:       true,
+		Authenticate: BearerTokens(map[string]string{"secret": "webteam"}),
+	})
+	w := get(t, server, "/", "")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status %d, %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `<script src="`+DefaultViewerAssets+`/leaflet.js">`) {
+		t.Error("default assets not in viewer")
+	}
+	if !strings.Contains(w.Body.String(), `params.set("page_size", "5000")`) {
+		t.Error("page size not in viewer")
+	}
+	// The viewer's fields are all understood.
+	start := strings.Index(w.Body.String(), `var fields = "`)
+	end := strings.Index(w.Body.String(), `";`+"\n"+`var map`)
+	fields := strings.NewReplacer(`"`, "", " ", "", "+", "", "\n", "").Replace(w.Body.String()[start+len("var fields = ") : end])
+	for _, field := range strings.Split(fields, ",") {
+		if _, ok := rowFields[field]; !ok {
+			t.Errorf("unknown field %q", field)
+		}
+	}
+
+	if w := get(t, server, "/licences", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("data served without authentication: status %d", w.Code)
+	}
+	if w := get(t, server, "/nonsense", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("status %d", w.Code)
+	}
+}
+
+func TestViewerAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "leaflet.js"), []byte("var L;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	collection := wtrcsv.ReadCSV(strings.NewReader(testCSV))
+	server := NewWithOptions(collection, &Options{
+		Viewer:         true,
+		ViewerAssetDir: dir,
+		Authenticate:   BearerTokens(map[string]string{"secret": "webteam"}),
+	})
+	if w := get(t, server, "/", ""); !strings.Contains(w.Body.String(), `<script src="/viewer-assets/leaflet.js">`) {
+		t.Error("local assets not in viewer")
+	}
+	if w := get(t, server, "/viewer-assets/leaflet.js", ""); w.Code != http.StatusOK || w.Body.String() != "var L;" {
+		t.Errorf("asset status %d: %s", w.Code, w.Body)
+	}
+
+	server = NewWithOptions(collection, &Options{Viewer: true, ViewerAssets: "https://example.com/leaflet/"})
+	if w := get(t, server, "/", ""); !strings.Contains(w.Body.String(), `href="https://example.com/leaflet/leaflet.css"`) {
+		t.Error("asset URL not in viewer")
+	}
+	if w := get(t, server, "/viewer-assets/leaflet.js", ""); w.Code != http.StatusNotFound {
+		t.Errorf("assets served without a directory: status %d", w.Code)
+	}
+}
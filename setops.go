@@ -0,0 +1,51 @@
+package wtrcsv
+
+// The set operations treat a Collection as a set of rows identified by
+// Row.Key: licence number, frequency, NGR and azimuth. Their results hold
+// each key once, as the first row with it, in order, with the header of
+// the receiver.
+
+// Union returns the rows of the collection followed by those of other
+// whose key is not in the collection.
+func (collection *Collection) Union(other *Collection) *Collection {
+	seen := make(map[string]bool, len(collection.Rows)+len(other.Rows))
+	result := collection.withRows(make([]*Row, 0, len(collection.Rows)))
+	for _, rows := range [][]*Row{collection.Rows, other.Rows} {
+		for _, row := range rows {
+			if key := row.Key(); !seen[key] {
+				seen[key] = true
+				result.Rows = append(result.Rows, row)
+			}
+		}
+	}
+	return result
+}
+
+// Intersect returns the rows of the collection whose key is in other.
+func (collection *Collection) Intersect(other *Collection) *Collection {
+	return collection.selectKeys(other, true)
+}
+
+// Difference returns the rows of the collection whose key is not in other.
+func (collection *Collection) Difference(other *Collection) *Collection {
+	return collection.selectKeys(other, false)
+}
+
+// selectKeys returns the rows of the collection whose key is, or is not,
+// in other.
+func (collection *Collection) selectKeys(other *Collection, in bool) *Collection {
+	keys := make(map[string]bool, len(other.Rows))
+	for _, row := range other.Rows {
+		keys[row.Key()] = true
+	}
+	seen := make(map[string]bool)
+	result := collection.withRows(make([]*Row, 0))
+	for _, row := range collection.Rows {
+		key := row.Key()
+		if keys[key] == in && !seen[key] {
+			seen[key] = true
+			result.Rows = append(result.Rows, row)
+		}
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestSetOperations(t *testing.T) {
+	a := testCollection(t, `Licence Number,Frequency,NGR,Antenna AZIMUTH
+0000001/1,7500,TQ 29400 81900,90
+0000002/1,7500,TQ 29400 81900,90
+0000002/1,7500,TQ2940081900,90
+0000003/1,18000,NT 25700 73500,270
+`)
+	b := testCollection(t, `Licence Number,Frequency,NGR,Antenna AZIMUTH
+0000002/1,7500,TQ 29400 81900,90
+0000003/1,18000,NT 25700 73500,180
+0000004/1,450,J 33000 74000,
+`)
+	for _, test := range []struct {
+		name string
+		got  *Collection
+		want string
+	}{
+		{"union", a.Union(b), "0000001/1 0000002/1 0000003/1 0000003/1 0000004/1"},
+		{"intersect", a.Intersect(b), "0000002/1"},
+		{"difference", a.Difference(b), "0000001/1 0000003/1"},
+		{"difference reversed", b.Difference(a), "0000003/1 0000004/1"},
+		{"self", a.Union(a), "0000001/1 0000002/1 0000003/1"},
+	} {
+		if got := licenceNumbers(test.got); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+	if got := a.Difference(b).Rows[1].AntennaAzimuth; got != "270" {
+		t.Errorf("wrong row kept: azimuth %s", got)
+	}
+}
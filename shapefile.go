@@ -0,0 +1,259 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	shapeNull  = 0
+	shapePoint = 1
+
+	dbfMaxFieldLength = 254
+	dbfMaxNameLength  = 10
+)
+
+// wktWGS84 is the .prj content for WGS84 longitude/latitude shapefiles.
+const wktWGS84 = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+// WriteShapefile writes the collection as an ESRI point shapefile. Every Row
+// is a Point located by its WGS84 columns (a null shape if they are absent)
+// with the requested columns as DBF character attributes. If columns is nil
+// all of the header columns are written. See DBFFieldNames for how the
+// column names are shortened.
+func (collection *Collection) WriteShapefile(shp, shx, dbf io.Writer, columns []string) error {
+	_, err := collection.WriteShapefileWithOptions(shp, shx, dbf, columns, nil)
+	return err
+}
+
+// WriteShapefileWithOptions is as WriteShapefile with a policy for rows
+// without coordinates, returning the counts of rows affected and warnings
+// for the values and column names shortened. options may be nil.
+func (collection *Collection) WriteShapefileWithOptions(shp, shx, dbf io.Writer, columns []string, options *GeometryOptions) (*GeometryReport, error) {
+	if columns == nil {
+		columns = collection.Header
+	}
+
+	located, report := collection.locate(options, true)
+	var warnings warningList
+	for i, name := range DBFFieldNames(columns) {
+		if name != strings.Join(dbfFieldWords(columns[i]), "_") {
+			warnings.add(Warning{WarningNameChanged, -1, "", columns[i], "DBF field " + name})
+		}
+	}
+	warnings.truncated(collection, columns, dbfMaxFieldLength, false)
+	report.Warnings = append(report.Warnings, warnings.result()...)
+	if err := located.writeShp(shp, shx); err != nil {
+		return nil, err
+	}
+	return report, located.writeDbf(dbf, columns)
+}
+
+// WriteShapefileFiles writes the .shp, .shx, .dbf, .prj and .cpg files of
+// a shapefile. basePath is the path without an extension.
+func (collection *Collection) WriteShapefileFiles(basePath string, columns []string) error {
+	files := make(map[string]*os.File)
+	for _, ext := range []string{".shp", ".shx", ".dbf", ".prj", ".cpg"} {
+		f, err := os.Create(basePath + ext)
+		if err != nil {
+			return errors.Wrapf(err, "could not create file \"%s\"", basePath+ext)
+		}
+		defer f.Close()
+		files[ext] = f
+	}
+
+	if err := collection.WriteShapefile(files[".shp"], files[".shx"], files[".dbf"], columns); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(files[".prj"], wktWGS84); err != nil {
+		return errors.Wrap(err, "could not write .prj")
+	}
+	if _, err := io.WriteString(files[".cpg"], "UTF-8"); err != nil {
+		return errors.Wrap(err, "could not write .cpg")
+	}
+
+	for ext, f := range files {
+		if err := f.Close(); err != nil {
+			return errors.Wrapf(err, "could not close %s", ext)
+		}
+	}
+	return nil
+}
+
+func (row *Row) hasWgs84() bool {
+	return row.Wgs84LongitudeAsString != "" && row.Wgs84LatitudeAsString != ""
+}
+
+// writeShp writes the main file and the index.
+func (collection *Collection) writeShp(shp, shx io.Writer) error {
+	// Lengths are in 16-bit words.
+	const headerWords, recordHeaderWords = 50, 4
+	const pointWords, nullWords = 10, 2
+
+	fileWords := headerWords
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, row := range collection.Rows {
+		if row.hasWgs84() {
+			fileWords += recordHeaderWords + pointWords
+			minX, maxX = math.Min(minX, row.Wgs84Longitude), math.Max(maxX, row.Wgs84Longitude)
+			minY, maxY = math.Min(minY, row.Wgs84Latitude), math.Max(maxY, row.Wgs84Latitude)
+		} else {
+			fileWords += recordHeaderWords + nullWords
+		}
+	}
+	if fileWords == headerWords || math.IsInf(minX, 1) {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+	bbox := [4]float64{minX, minY, maxX, maxY}
+
+	wShp, wShx := bufio.NewWriter(shp), bufio.NewWriter(shx)
+	writeShpHeader(wShp, fileWords, bbox)
+	writeShpHeader(wShx, headerWords+len(collection.Rows)*4, bbox)
+
+	offset := headerWords
+	for i, row := range collection.Rows {
+		contentWords := nullWords
+		if row.hasWgs84() {
+			contentWords = pointWords
+		}
+		binary.Write(wShx, binary.BigEndian, [2]int32{int32(offset), int32(contentWords)})
+		binary.Write(wShp, binary.BigEndian, [2]int32{int32(i + 1), int32(contentWords)})
+		if row.hasWgs84() {
+			binary.Write(wShp, binary.LittleEndian, int32(shapePoint))
+			binary.Write(wShp, binary.LittleEndian, [2]float64{row.Wgs84Longitude, row.Wgs84Latitude})
+		} else {
+			binary.Write(wShp, binary.LittleEndian, int32(shapeNull))
+		}
+		offset += recordHeaderWords + contentWords
+	}
+
+	if err := wShp.Flush(); err != nil {
+		return errors.Wrap(err, "could not write .shp")
+	}
+	return errors.Wrap(wShx.Flush(), "could not write .shx")
+}
+
+func writeShpHeader(w io.Writer, fileWords int, bbox [4]float64) {
+	binary.Write(w, binary.BigEndian, [7]int32{9994, 0, 0, 0, 0, 0, int32(fileWords)})
+	binary.Write(w, binary.LittleEndian, [2]int32{1000, shapePoint})
+	binary.Write(w, binary.LittleEndian, bbox)
+	binary.Write(w, binary.LittleEndian, [4]float64{}) // Z and M ranges
+}
+
+// writeDbf writes the attribute table as dBase III character fields.
+func (collection *Collection) writeDbf(dbf io.Writer, columns []string) error {
+	names := DBFFieldNames(columns)
+	records := make([][]string, len(collection.Rows))
+	lengths := make([]int, len(columns))
+	encoder := newRowEncoder(columns, collection.computed)
+	for i, row := range collection.Rows {
+		records[i] = append([]string(nil), encoder.encode(row)...)
+		for j, value := range records[i] {
+			value = truncateUTF8(value, dbfMaxFieldLength)
+			records[i][j] = value
+			if len(value) > lengths[j] {
+				lengths[j] = len(value)
+			}
+		}
+	}
+	recordLength := 1 // deletion flag
+	for j := range lengths {
+		if lengths[j] == 0 {
+			lengths[j] = 1
+		}
+		recordLength += lengths[j]
+	}
+
+	w := bufio.NewWriter(dbf)
+	now := time.Now()
+	w.Write([]byte{0x03, byte(now.Year() - 1900), byte(now.Month()), byte(now.Day())})
+	binary.Write(w, binary.LittleEndian, uint32(len(records)))
+	binary.Write(w, binary.LittleEndian, uint16(32+32*len(columns)+1))
+	binary.Write(w, binary.LittleEndian, uint16(recordLength))
+	w.Write(make([]byte, 20))
+
+	for j, name := range names {
+		descriptor := make([]byte, 32)
+		copy(descriptor, name)
+		descriptor[11] = 'C'
+		descriptor[16] = byte(lengths[j])
+		w.Write(descriptor)
+	}
+	w.WriteByte(0x0D)
+
+	for _, record := range records {
+		w.WriteByte(' ')
+		for j, value := range record {
+			w.WriteString(value)
+			w.WriteString(strings.Repeat(" ", lengths[j]-len(value)))
+		}
+	}
+	w.WriteByte(0x1A)
+
+	return errors.Wrap(w.Flush(), "could not write .dbf")
+}
+
+// DBFFieldNames returns the unique DBF field names (at most 10 characters)
+// used for the columns in a shapefile. Non alphanumeric characters become
+// underscores, a trailing number is kept (eg. "Product Description 31"
+// becomes "Product_31") and clashes are resolved with a numerical suffix.
+func DBFFieldNames(columns []string) []string {
+	names := make([]string, len(columns))
+	used := make(map[string]bool)
+	for i, column := range columns {
+		name := dbfFieldName(column)
+		for n := 1; used[strings.ToUpper(name)]; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			name = strings.TrimRight(truncateUTF8(dbfFieldName(column), dbfMaxNameLength-len(suffix)), "_") + suffix
+		}
+		used[strings.ToUpper(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+func dbfFieldName(column string) string {
+	words := dbfFieldWords(column)
+	if len(words) == 0 {
+		return "FIELD"
+	}
+	name := strings.Join(words, "_")
+	if len(name) <= dbfMaxNameLength {
+		return name
+	}
+
+	last := words[len(words)-1]
+	if _, err := strconv.Atoi(last); err == nil && len(words) > 1 && len(last) < dbfMaxNameLength-1 {
+		prefix := truncateUTF8(strings.Join(words[:len(words)-1], "_"), dbfMaxNameLength-len(last)-1)
+		return strings.TrimRight(prefix, "_") + "_" + last
+	}
+	return strings.TrimRight(name[:dbfMaxNameLength], "_")
+}
+
+// dbfFieldWords returns the runs of ASCII letters and digits in column.
+func dbfFieldWords(column string) []string {
+	return strings.FieldsFunc(column, func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+}
+
+// truncateUTF8 truncates s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,49 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestDBFFieldNames(t *testing.T) {
+	names := DBFFieldNames([]string{
+		"Licence Number", "Product Description", "Product Description 31",
+		"Antenna ERP", "Antenna ERP type", "SID_LAT_N_S", "NGR",
+	})
+	want := "Licence_Nu,Product_De,Product_31,Antenna_ER,Antenna_1,SID_LAT_N,NGR"
+	if strings.Join(names, ",") != want {
+		t.Fatalf("unexpected names: %v", names)
+	}
+}
+
+func TestWriteShapefile(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Antenna Location,WGS84 Longitude,WGS84 Latitude
+0000001/1,BT Tower,-0.1388,51.5215
+0000002/1,Nowhere,,
+`)
+	shp, shx, dbf := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	if err := collection.WriteShapefile(shp, shx, dbf, []string{"Licence Number", "Antenna Location"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Header (100) + point record (8 + 20) + null record (8 + 4).
+	if shp.Len() != 140 {
+		t.Fatalf("wrong .shp length: %v", shp.Len())
+	}
+	if words := binary.BigEndian.Uint32(shp.Bytes()[24:]); words != 70 {
+		t.Fatalf("wrong .shp file length: %v", words)
+	}
+	if shx.Len() != 116 {
+		t.Fatalf("wrong .shx length: %v", shx.Len())
+	}
+
+	// Header (32 + 2 * 32 + 1) + 2 records (1 + 9 + 8) + terminator.
+	if dbf.Len() != 97+36+1 {
+		t.Fatalf("wrong .dbf length: %v", dbf.Len())
+	}
+	if !strings.Contains(dbf.String(), " 0000001/1BT Tower") {
+		t.Fatal("missing .dbf record")
+	}
+}
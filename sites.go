@@ -0,0 +1,151 @@
+package wtrcsv
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Site is a group of rows at the same NGR.
+type Site struct {
+	NGR  string // without spaces, see KeyNGR
+	Name string // best display name from the rows' Antenna Location
+	Rows []*Row
+}
+
+// Sites groups the rows by NGR, in order of first appearance. Rows without
+// an NGR are omitted. Each Site is named by SiteName.
+func (collection *Collection) Sites() []*Site {
+	var sites []*Site
+	lookup := make(map[string]*Site)
+	for _, row := range collection.Rows {
+		ngr := KeyNGR(row)
+		if ngr == "" {
+			continue
+		}
+		site, ok := lookup[ngr]
+		if !ok {
+			site = &Site{NGR: ngr}
+			lookup[ngr] = site
+			sites = append(sites, site)
+		}
+		site.Rows = append(site.Rows, row)
+	}
+
+	for _, site := range sites {
+		site.Name = SiteName(site.Rows)
+	}
+	return sites
+}
+
+// NormaliseLocation upper cases an Antenna Location and reduces punctuation
+// and runs of white space to single spaces, eg. "B.T. Tower,  London" is
+// "B T TOWER LONDON".
+func NormaliseLocation(location string) string {
+	words := strings.FieldsFunc(strings.ToUpper(location), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	return strings.Join(words, " ")
+}
+
+// SiteName chooses a single display name for rows at one site. The
+// normalised locations are clustered where one extends another (eg.
+// "BT TOWER" and "BT TOWER LONDON W1"), the cluster covering most rows is
+// chosen, and the name is the most common spelling of its shortest member,
+// preferring mixed case to all capitals.
+func SiteName(rows []*Row) string {
+	type variant struct {
+		words    []string
+		count    int
+		spelling map[string]int
+	}
+	variants := make(map[string]*variant)
+	for _, row := range rows {
+		location := strings.TrimSpace(row.AntennaLocation)
+		normalised := NormaliseLocation(location)
+		if normalised == "" {
+			continue
+		}
+		v, ok := variants[normalised]
+		if !ok {
+			v = &variant{words: strings.Fields(normalised), spelling: make(map[string]int)}
+			variants[normalised] = v
+		}
+		v.count++
+		v.spelling[location]++
+	}
+	if len(variants) == 0 {
+		return ""
+	}
+
+	// Shortest first, so that each cluster's root is its shortest member.
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := variants[names[i]], variants[names[j]]
+		if len(a.words) != len(b.words) {
+			return len(a.words) < len(b.words)
+		}
+		return names[i] < names[j]
+	})
+
+	type cluster struct {
+		root  *variant
+		count int
+	}
+	var clusters []*cluster
+	for _, name := range names {
+		v := variants[name]
+		var found *cluster
+		for _, c := range clusters {
+			if wordsHavePrefix(v.words, c.root.words) {
+				found = c
+				break
+			}
+		}
+		if found == nil {
+			found = &cluster{root: v}
+			clusters = append(clusters, found)
+		}
+		found.count += v.count
+	}
+
+	best := clusters[0]
+	for _, c := range clusters[1:] {
+		if c.count > best.count {
+			best = c
+		}
+	}
+
+	var name string
+	bestCount := 0
+	for spelling, count := range best.root.spelling {
+		if count > bestCount || (count == bestCount && betterSpelling(spelling, name)) {
+			name, bestCount = spelling, count
+		}
+	}
+	return name
+}
+
+func wordsHavePrefix(words, prefix []string) bool {
+	if len(prefix) > len(words) {
+		return false
+	}
+	for i := range prefix {
+		if words[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// betterSpelling prefers mixed case, then the lexically first spelling.
+func betterSpelling(a, b string) bool {
+	mixedA, mixedB := strings.ToUpper(a) != a, strings.ToUpper(b) != b
+	if mixedA != mixedB {
+		return mixedA
+	}
+	return a < b
+}
@@ -0,0 +1,35 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestNormaliseLocation(t *testing.T) {
+	if s := NormaliseLocation(" B.T. Tower,  London "); s != "B T TOWER LONDON" {
+		t.Fatalf("unexpected normalisation: %q", s)
+	}
+}
+
+func TestSites(t *testing.T) {
+	collection := testCollection(t, `Licence Number,NGR,Antenna Location
+0000001/1,TQ 29400 81900,BT TOWER LONDON W1
+0000002/1,TQ2940081900,BT Tower
+0000003/1,TQ 29400 81900,BT TOWER
+0000004/1,TQ 29400 81900,Telecom Tower
+0000005/1,SJ 84000 98000,Rooftop
+0000006/1,,Nowhere
+`)
+	sites := collection.Sites()
+	if len(sites) != 2 {
+		t.Fatalf("wrong number of sites: %v", len(sites))
+	}
+	if sites[0].NGR != "TQ2940081900" || len(sites[0].Rows) != 4 {
+		t.Fatalf("wrong site: %+v", sites[0])
+	}
+	if sites[0].Name != "BT Tower" {
+		t.Fatalf("wrong site name: %q", sites[0].Name)
+	}
+	if sites[1].Name != "Rooftop" {
+		t.Fatalf("wrong site name: %q", sites[1].Name)
+	}
+}
@@ -0,0 +1,212 @@
+package wtrcsv
+
+import (
+	"compress/gzip"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotStore keeps dated downloads of the register in a directory, one
+// gzip compressed csv per date named eg. WTR-2018-02-07.csv.gz, so that
+// they can also be read by other tools (and by LoadMany).
+//
+// It is a Store of snapshots named by their date, eg. "2018-02-07".
+type SnapshotStore struct {
+	dir string
+}
+
+var _ Store = (*SnapshotStore)(nil)
+
+const (
+	snapshotPrefix = "WTR-"
+	snapshotSuffix = ".csv.gz"
+)
+
+// NewSnapshotStore returns a SnapshotStore in a directory, creating it if
+// needed.
+func NewSnapshotStore(dir string) (*SnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create snapshot directory")
+	}
+	return &SnapshotStore{dir: dir}, nil
+}
+
+// Path returns the path of the snapshot of a date, whether or not it
+// exists.
+func (store *SnapshotStore) Path(date time.Time) string {
+	return filepath.Join(store.dir, snapshotPrefix+date.Format(dateLayout)+snapshotSuffix)
+}
+
+// Save compresses the register csv read from reader into the snapshot of
+// a date, replacing any snapshot of that date. The file is replaced
+// atomically.
+func (store *SnapshotStore) Save(date time.Time, reader io.Reader) error {
+	path := store.Path(date)
+	file, err := ioutil.TempFile(store.dir, filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not create snapshot file")
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	w := gzip.NewWriter(file)
+	if _, err := io.Copy(w, reader); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+	return errors.Wrap(os.Rename(file.Name(), path), "could not write snapshot file")
+}
+
+// SaveCollection saves a Collection as the snapshot of a date.
+func (store *SnapshotStore) SaveCollection(date time.Time, collection *Collection) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(collection.WriteCSVWithOptions(writer, nil))
+	}()
+	err := store.Save(date, reader)
+	reader.Close()
+	return err
+}
+
+// Dates returns the dates of the stored snapshots in order.
+func (store *SnapshotStore) Dates() ([]time.Time, error) {
+	matches, err := filepath.Glob(filepath.Join(store.dir, snapshotPrefix+"*"+snapshotSuffix))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list snapshots")
+	}
+	dates := make([]time.Time, 0, len(matches))
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), snapshotPrefix), snapshotSuffix)
+		date, err := time.Parse(dateLayout, name)
+		if err != nil {
+			continue // not a snapshot
+		}
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates, nil
+}
+
+// Latest returns the date of the most recent snapshot, or ErrSnapshotNotFound
+// if there are none.
+func (store *SnapshotStore) Latest() (time.Time, error) {
+	dates, err := store.Dates()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(dates) == 0 {
+		return time.Time{}, ErrSnapshotNotFound
+	}
+	return dates[len(dates)-1], nil
+}
+
+// Load reads the snapshot of a date, or returns ErrSnapshotNotFound.
+func (store *SnapshotStore) Load(date time.Time) (*Collection, error) {
+	file, err := os.Open(store.Path(date))
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open snapshot file")
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decompress snapshot %s", date.Format(dateLayout))
+	}
+	defer r.Close()
+	collection, err := readCSV(r)
+	return collection, errors.Wrapf(err, "could not read snapshot %s", date.Format(dateLayout))
+}
+
+// Prune removes all but the most recent keep snapshots, returning the
+// dates removed.
+func (store *SnapshotStore) Prune(keep int) ([]time.Time, error) {
+	if keep < 0 {
+		keep = 0
+	}
+	dates, err := store.Dates()
+	if err != nil || len(dates) <= keep {
+		return nil, err
+	}
+	removed := dates[:len(dates)-keep]
+	for i, date := range removed {
+		if err := os.Remove(store.Path(date)); err != nil {
+			return removed[:i], errors.Wrap(err, "could not remove snapshot")
+		}
+	}
+	return removed, nil
+}
+
+// parseSnapshotName returns the date of a snapshot name, or
+// ErrSnapshotNotFound if it is not a date.
+func parseSnapshotName(name string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, name)
+	if err != nil {
+		return time.Time{}, ErrSnapshotNotFound
+	}
+	return date, nil
+}
+
+// Put saves a Collection as the snapshot of the date name, its rows ordered
+// by licence number.
+func (store *SnapshotStore) Put(name string, collection *Collection) error {
+	date, err := time.Parse(dateLayout, name)
+	if err != nil {
+		return errors.Errorf("snapshot name \"%s\" is not a date", name)
+	}
+	return store.SaveCollection(date, collection.withRows(byLicenceNumber(collection)))
+}
+
+// Get reads the snapshot of the date name, ordered by licence number.
+func (store *SnapshotStore) Get(name string) (*Collection, error) {
+	date, err := parseSnapshotName(name)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := store.Load(date)
+	if err != nil {
+		return nil, err
+	}
+	return collection.withRows(byLicenceNumber(collection)), nil
+}
+
+// Range reads the rows of the snapshot of the date name in a licence number
+// range. The whole snapshot is read.
+func (store *SnapshotStore) Range(name, from, to string) ([]*Row, error) {
+	collection, err := store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*Row
+	for _, row := range collection.Rows {
+		if inRange(row.LicenceNumber, from, to) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// Names returns the dates of the stored snapshots in order, as names.
+func (store *SnapshotStore) Names() ([]string, error) {
+	dates, err := store.Dates()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(dates))
+	for i, date := range dates {
+		names[i] = date.Format(dateLayout)
+	}
+	return names, nil
+}
@@ -0,0 +1,86 @@
+package wtrcsv
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewSnapshotStore(filepath.Join(dir, "snapshots"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Latest(); err != ErrSnapshotNotFound {
+		t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2018, 2, d, 0, 0, 0, 0, time.UTC) }
+	for d := 7; d >= 5; d-- {
+		csv := "Licence Number\n" + strings.Repeat("0000001/1\n", d)
+		if err := store.Save(day(d), strings.NewReader(csv)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collection := testCollection(t, "Licence Number\n0000002/1\n")
+	if err := store.SaveCollection(day(8), collection); err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "snapshots", "WTR-notes.csv.gz"), nil, 0644) // ignored
+
+	dates, err := store.Dates()
+	if err != nil || len(dates) != 4 || !dates[0].Equal(day(5)) || !dates[3].Equal(day(8)) {
+		t.Fatalf("dates %v: %v", dates, err)
+	}
+	if latest, err := store.Latest(); err != nil || !latest.Equal(day(8)) {
+		t.Errorf("latest %v: %v", latest, err)
+	}
+	loaded, err := store.Load(day(6))
+	if err != nil || len(loaded.Rows) != 6 {
+		t.Fatalf("loaded %v: %v", loaded, err)
+	}
+	if loaded, err := store.Load(day(8)); err != nil || licenceNumbers(loaded) != "0000002/1" {
+		t.Errorf("loaded %v: %v", loaded, err)
+	}
+	if _, err := store.Load(day(1)); err != ErrSnapshotNotFound {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+
+	collections, err := LoadMany(context.Background(), []string{store.Path(day(7))})
+	if err != nil || len(collections[0].Rows) != 7 {
+		t.Errorf("LoadMany of a snapshot: %v", err)
+	}
+
+	removed, err := store.Prune(2)
+	if err != nil || len(removed) != 2 || !removed[1].Equal(day(6)) {
+		t.Fatalf("removed %v: %v", removed, err)
+	}
+	if dates, _ := store.Dates(); len(dates) != 2 || !dates[0].Equal(day(7)) {
+		t.Errorf("dates after prune %v", dates)
+	}
+}
+
+func TestSnapshotStoreIsStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+	if err := store.Put("latest", &Collection{}); err == nil {
+		t.Fatal("expected error for a name that is not a date")
+	}
+}
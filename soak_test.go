@@ -0,0 +1,170 @@
+//go:build soak
+// +build soak
+
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// The soak test runs the whole pipeline against the real register and
+// records the time and memory of each stage, eg.
+//
+//	go test -tags soak -run TestSoak -timeout 1h
+var (
+	soakURL      = flag.String("soak.url", fileURL, "URL of the register")
+	soakBaseline = flag.String("soak.baseline", "test_data/soak.json", "baseline file read then rewritten")
+)
+
+// soakStage is the measurement of a stage of the pipeline.
+type soakStage struct {
+	Name       string  `json:"name"`
+	Seconds    float64 `json:"seconds"`
+	AllocBytes uint64  `json:"alloc_bytes"` // allocated during the stage
+	HeapBytes  uint64  `json:"heap_bytes"`  // in use after the stage
+	Rows       int     `json:"rows"`
+}
+
+// soakBaselineFile is the JSON written to -soak.baseline.
+type soakBaselineFile struct {
+	Date      time.Time   `json:"date"`
+	GoVersion string      `json:"go_version"`
+	Stages    []soakStage `json:"stages"`
+}
+
+func TestSoak(t *testing.T) {
+	var stages []soakStage
+	stage := func(name string, fn func() int) {
+		t.Helper()
+		runtime.GC()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		rows := fn()
+		elapsed := time.Since(start)
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		s := soakStage{name, elapsed.Seconds(), after.TotalAlloc - before.TotalAlloc, after.HeapInuse, rows}
+		t.Logf("%-16s %8.3fs %12d bytes allocated %12d bytes in use %8d rows", s.Name, s.Seconds, s.AllocBytes, s.HeapBytes, s.Rows)
+		stages = append(stages, s)
+	}
+
+	var data []byte
+	stage("fetch", func() int {
+		resp, err := http.Get(*soakURL)
+		if err != nil {
+			t.Fatalf("could not GET URL: \"%s\": %v", *soakURL, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("bad http status: %s", resp.Status)
+		}
+		if data, err = ioutil.ReadAll(resp.Body); err != nil {
+			t.Fatalf("could not read body: %v", err)
+		}
+		return 0
+	})
+
+	var collection *Collection
+	stage("parse", func() int {
+		collection = ReadCSV(bytes.NewReader(data))
+		if len(collection.Rows) == 0 {
+			t.Fatal("no rows")
+		}
+		return len(collection.Rows)
+	})
+	data = nil
+
+	stage("validate", func() int {
+		audit := collection.AuditQuoting()
+		t.Logf("quoting audit: %+v", audit)
+		report := collection.Validate()
+		t.Logf("validation: %v %+v", report, report.Counts)
+		return len(collection.Rows)
+	})
+
+	stage("index", func() int {
+		keys := collection.BuildKeyIndex(KeyLicenceNumber)
+		collection.BuildSpatialIndex()
+		return keys.Len()
+	})
+
+	var filtered *Collection
+	stage("filter", func() int {
+		filtered = collection.Filter(FilterPointToPoint, FilterBoundingBox(49, -9, 61, 2))
+		return len(filtered.Rows)
+	})
+
+	exports := []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{"csv", func(w io.Writer) error { return collection.WriteCSVWithOptions(w, nil) }},
+		{"json", collection.WriteJSON},
+		{"ndjson", collection.WriteNDJSON},
+		{"geojson", collection.WriteGeoJSON},
+		{"proto", collection.WriteProto},
+		{"xlsx", func(w io.Writer) error {
+			_, err := collection.WriteXLSX(w, nil)
+			return err
+		}},
+		{"shapefile", func(w io.Writer) error {
+			return collection.WriteShapefile(w, w, w, nil)
+		}},
+		{"html", func(w io.Writer) error {
+			_, err := filtered.WriteLeafletHTML(w, nil)
+			return err
+		}},
+		{"png", func(w io.Writer) error {
+			_, err := collection.WriteHeatmapPNG(w, nil)
+			return err
+		}},
+	}
+	for _, export := range exports {
+		stage("export "+export.name, func() int {
+			if err := export.write(ioutil.Discard); err != nil {
+				t.Fatalf("%s: %v", export.name, err)
+			}
+			return len(collection.Rows)
+		})
+	}
+
+	// Compare with and replace the previous baseline.
+	var previous soakBaselineFile
+	if b, err := ioutil.ReadFile(*soakBaseline); err == nil {
+		if err := json.Unmarshal(b, &previous); err != nil {
+			t.Fatalf("could not decode baseline: %v", err)
+		}
+	}
+	before := make(map[string]soakStage)
+	for _, s := range previous.Stages {
+		before[s.Name] = s
+	}
+	for _, s := range stages {
+		if p, ok := before[s.Name]; ok && p.Seconds > 0 && p.AllocBytes > 0 {
+			t.Logf("%-16s time x%.2f, allocated x%.2f against %s", s.Name,
+				s.Seconds/p.Seconds, float64(s.AllocBytes)/float64(p.AllocBytes), previous.Date.Format("2006-01-02"))
+		}
+	}
+
+	b, err := json.MarshalIndent(soakBaselineFile{time.Now().UTC(), runtime.Version(), stages}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path.Dir(*soakBaseline), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*soakBaseline, append(b, '\n'), 0644); err != nil {
+		t.Fatalf("could not write baseline: %v", err)
+	}
+}
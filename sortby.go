@@ -0,0 +1,83 @@
+package wtrcsv
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompareFn orders two rows, returning <0 if a sorts before b, >0 if after
+// and 0 if they are equal.
+type CompareFn func(a, b *Row) int
+
+// SortBy sorts the rows of the collection in place by the comparators in
+// turn, each breaking the ties of the one before. The sort is stable, so
+// rows equal under all of them keep their order.
+func (collection *Collection) SortBy(compareFns ...CompareFn) {
+	if len(compareFns) == 0 {
+		return
+	}
+	rows := collection.Rows
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, compare := range compareFns {
+			if c := compare(rows[i], rows[j]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// Descending reverses the order of a comparator.
+func Descending(compare CompareFn) CompareFn {
+	return func(a, b *Row) int { return compare(b, a) }
+}
+
+// ByLicenceNumber orders rows by licence number.
+func ByLicenceNumber(a, b *Row) int {
+	return strings.Compare(a.LicenceNumber, b.LicenceNumber)
+}
+
+// ByCompany orders rows by licensee company.
+func ByCompany(a, b *Row) int {
+	return strings.Compare(a.LicenseeCompany, b.LicenseeCompany)
+}
+
+// ByProductCode orders rows by numerical product code.
+func ByProductCode(a, b *Row) int {
+	return strings.Compare(a.ProductDescription31, b.ProductDescription31)
+}
+
+// ByFrequency orders rows by frequency. Rows without a frequency sort
+// last.
+func ByFrequency(a, b *Row) int {
+	fa, okA := a.FrequencyHz()
+	fb, okB := b.FrequencyHz()
+	return compareKnown(okA, okB, float64(fa), float64(fb))
+}
+
+// ByLicenceIssueDate orders rows by licence issue date. Rows without a
+// recognised date sort last.
+func ByLicenceIssueDate(a, b *Row) int {
+	ta, errA := a.IssueDate()
+	tb, errB := b.IssueDate()
+	return compareKnown(errA == nil, errB == nil, float64(ta.Unix()), float64(tb.Unix()))
+}
+
+// compareKnown compares two values, either of which may be unknown, with
+// the unknown values last.
+func compareKnown(okA, okB bool, a, b float64) int {
+	switch {
+	case !okA || !okB:
+		if okA == okB {
+			return 0
+		} else if okA {
+			return -1
+		}
+		return 1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestSortBy(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licence issue date,Licencee Company,Frequency,Frequency Type
+0000001/1,05/03/2010,Beta,7.5,GHz
+0000002/1,2008-11-20,Alpha,18,GHz
+0000003/1,,Beta,450,MHz
+0000004/1,01/01/2001,Alpha,,
+0000005/1,01/01/2001,Beta,7500,MHz
+`)
+	for _, test := range []struct {
+		name string
+		by   []CompareFn
+		want string
+	}{
+		{"frequency", []CompareFn{ByFrequency}, "0000003/1 0000001/1 0000005/1 0000002/1 0000004/1"},
+		{"company, frequency", []CompareFn{ByCompany, ByFrequency}, "0000002/1 0000004/1 0000003/1 0000001/1 0000005/1"},
+		{"issue date", []CompareFn{ByLicenceIssueDate}, "0000004/1 0000005/1 0000002/1 0000001/1 0000003/1"},
+		{"company descending, licence", []CompareFn{Descending(ByCompany), ByLicenceNumber}, "0000001/1 0000003/1 0000005/1 0000002/1 0000004/1"},
+		{"none", nil, "0000001/1 0000002/1 0000003/1 0000004/1 0000005/1"},
+	} {
+		c := &Collection{Header: collection.Header, Rows: append([]*Row(nil), collection.Rows...)}
+		c.SortBy(test.by...)
+		if got := licenceNumbers(c); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package wtrcsv
+
+import (
+	"math"
+)
+
+// NearestPair is a row of one collection and the nearest row of another.
+type NearestPair struct {
+	A, B       *Row
+	DistanceKm float64 // great-circle
+}
+
+// NearestJoin returns, for every located row (see BuildSpatialIndex) of the
+// collection, the nearest located row of other within maxKm, in the order
+// of the collection. Rows without a location or without a row of other
+// within maxKm are left out. If maxKm is 0 or less there is no limit.
+// other is indexed once so the join is O((n+m) log m) rather than O(n·m).
+func (collection *Collection) NearestJoin(other *Collection, maxKm float64) []NearestPair {
+	index := other.BuildSpatialIndex()
+	if index.Len() == 0 {
+		return nil
+	}
+
+	var pairs []NearestPair
+	for _, a := range collection.Rows {
+		lat, lon, ok := rowLatLon(a)
+		if !ok {
+			continue
+		}
+		radius := maxKm
+		if radius <= 0 {
+			// The nearest by the index's approximate distance bounds the
+			// great-circle nearest.
+			nearest := index.Nearest(lon, lat, 1)
+			bLat, bLon, _ := rowLatLon(nearest[0])
+			radius = distanceKm(lat, lon, bLat, bLon) * 1.01
+		}
+
+		var pair *NearestPair
+		for _, b := range index.Query(radiusBBox(lat, lon, radius)) {
+			bLat, bLon, _ := rowLatLon(b)
+			d := distanceKm(lat, lon, bLat, bLon)
+			if d <= radius && (pair == nil || d < pair.DistanceKm) {
+				pair = &NearestPair{a, b, d}
+			}
+		}
+		if pair != nil {
+			pairs = append(pairs, *pair)
+		}
+	}
+	return pairs
+}
+
+// radiusBBox returns a longitude (X) and latitude (Y) box containing the
+// circle of radiusKm around a position.
+func radiusBBox(lat, lon, radiusKm float64) BBox {
+	dLat := degrees(radiusKm / earthRadiusKm)
+	dLon := 180.0
+	if cosLat := math.Cos(radians(math.Min(math.Abs(lat)+dLat, 90))); cosLat > 0 {
+		dLon = math.Min(180, dLat/cosLat)
+	}
+	return BBox{lon - dLon, lat - dLat, lon + dLon, lat + dLat}
+}
@@ -0,0 +1,58 @@
+package wtrcsv
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNearestJoin(t *testing.T) {
+	sites := testCollection(t, `Licence Number,WGS84 Longitude,WGS84 Latitude
+CANDIDATE/1,-2.2446,53.4794
+CANDIDATE/2,-0.1246,51.5007
+CANDIDATE/3,,
+`)
+	links := testCollection(t, `Licence Number,WGS84 Longitude,WGS84 Latitude
+0000001/1,-2.1516,53.4058
+0000002/1,-2.2400,53.4800
+0000003/1,-3.1883,55.9533
+`)
+	pairs := sites.NearestJoin(links, 25)
+	if len(pairs) != 1 || pairs[0].A.LicenceNumber != "CANDIDATE/1" || pairs[0].B.LicenceNumber != "0000002/1" || pairs[0].DistanceKm > 0.5 {
+		t.Fatalf("wrong pairs %+v", pairs)
+	}
+	if pairs := sites.NearestJoin(links, 0); len(pairs) != 2 || pairs[1].A.LicenceNumber != "CANDIDATE/2" || pairs[1].DistanceKm < 200 {
+		t.Fatalf("unlimited: wrong pairs %+v", pairs)
+	}
+
+	// Against brute force.
+	r := rand.New(rand.NewSource(1))
+	a := &Collection{Rows: randomRows(r, 200)}
+	b := &Collection{Rows: randomRows(r, 500)}
+	for _, maxKm := range []float64{0, 50} {
+		pairs := a.NearestJoin(b, maxKm)
+		i := 0
+		for _, rowA := range a.Rows {
+			latA, lonA, ok := rowLatLon(rowA)
+			if !ok {
+				continue
+			}
+			best := math.Inf(1)
+			for _, rowB := range b.Rows {
+				if latB, lonB, ok := rowLatLon(rowB); ok {
+					best = math.Min(best, distanceKm(latA, lonA, latB, lonB))
+				}
+			}
+			if maxKm > 0 && best > maxKm {
+				continue
+			}
+			if i >= len(pairs) || pairs[i].A != rowA || math.Abs(pairs[i].DistanceKm-best) > 1e-9 {
+				t.Fatalf("maxKm %v: pair %d wrong, want %v km", maxKm, i, best)
+			}
+			i++
+		}
+		if i != len(pairs) {
+			t.Fatalf("maxKm %v: %d pairs, want %d", maxKm, len(pairs), i)
+		}
+	}
+}
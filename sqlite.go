@@ -0,0 +1,146 @@
+package wtrcsv
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"strings"
+	"unicode"
+)
+
+// SQLiteDriverName is the database/sql driver used by WriteSQLite and
+// ReadSQLite. The driver is not imported by this package; the program has to
+// import one, eg. github.com/mattn/go-sqlite3 (which registers "sqlite3") or
+// modernc.org/sqlite (which registers "sqlite").
+var SQLiteDriverName = "sqlite3"
+
+const (
+	sqliteTable       = "licences"
+	sqliteHeaderTable = "licences_header"
+)
+
+// sqliteIndexes are the columns that are indexed if present.
+var sqliteIndexes = []string{"Licence Number", "Licencee Company", "Product Code", "Product Description 31"}
+
+// ColumnName is the snake_case name of a heading, as used for the json tags
+// of Row, eg. "Product Description 31" is "product_description_31".
+func ColumnName(heading string) string {
+	words := strings.FieldsFunc(strings.ToLower(heading), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	return strings.Join(words, "_")
+}
+
+func sqliteLoader(db *sql.DB) *SQLLoader {
+	return &SQLLoader{
+		DB:          db,
+		Dialect:     DialectSQLite,
+		Table:       sqliteTable,
+		CreateTable: true,
+		Indexes:     sqliteIndexes,
+	}
+}
+
+// WriteSQLite writes the collection to a SQLite database at path, replacing
+// any licences table already there. Every header column becomes a table
+// column named as the snake_case json tag of Row, stored verbatim as TEXT
+// except for the munged OS and WGS84 columns, which are numerical. Licence
+// number, company and product code are indexed.
+func (collection *Collection) WriteSQLite(path string) error {
+	db, err := sql.Open(SQLiteDriverName, path)
+	if err != nil {
+		return errors.Wrapf(err, "could not open SQLite database \"%s\"", path)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	for _, table := range []string{sqliteTable, sqliteHeaderTable} {
+		if _, err := tx.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			return errors.Wrapf(err, "could not drop table %s", table)
+		}
+	}
+	if _, err := tx.Exec("CREATE TABLE " + sqliteHeaderTable + " (position INTEGER PRIMARY KEY, heading TEXT NOT NULL)"); err != nil {
+		return errors.Wrap(err, "could not create header table")
+	}
+	for i, heading := range collection.Header {
+		if _, err := tx.Exec("INSERT INTO "+sqliteHeaderTable+" (position, heading) VALUES (?, ?)", i, heading); err != nil {
+			return errors.Wrap(err, "could not insert header")
+		}
+	}
+
+	if err := sqliteLoader(db).LoadTx(tx, collection); err != nil {
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "could not commit")
+}
+
+// ReadSQLite reads a collection written by WriteSQLite. Numerical columns are
+// read back in their shortest representation (eg. "51.521500" is returned as
+// "51.5215").
+func ReadSQLite(path string) (*Collection, error) {
+	db, err := sql.Open(SQLiteDriverName, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open SQLite database \"%s\"", path)
+	}
+	defer db.Close()
+
+	header, err := queryStrings(db, "SELECT heading FROM "+sqliteHeaderTable+" ORDER BY position")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read header")
+	}
+
+	names := make([]string, len(header))
+	for i, heading := range header {
+		names[i] = ColumnName(heading)
+	}
+	rows, err := db.Query("SELECT " + strings.Join(names, ", ") + " FROM " + sqliteTable + " ORDER BY rowid")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not query licences")
+	}
+	defer rows.Close()
+
+	collection := Collection{Header: header, Rows: make([]*Row, 0)}
+	values := make([]sql.NullString, len(names))
+	pointers := make([]interface{}, len(names))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	columns := make(map[string]string, len(header))
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, errors.Wrap(err, "could not read licence")
+		}
+		for i, heading := range header {
+			columns[heading] = values[i].String
+		}
+		row, err := parseRow(columns)
+		if err != nil {
+			return nil, errors.Wrapf(err, "row %d", len(collection.Rows)+1)
+		}
+		collection.Rows = append(collection.Rows, row)
+	}
+	return &collection, errors.Wrap(rows.Err(), "could not read licences")
+}
+
+func queryStrings(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
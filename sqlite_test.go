@@ -0,0 +1,60 @@
+package wtrcsv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQLiteSchema(t *testing.T) {
+	if name := ColumnName("Product Description 31"); name != "product_description_31" {
+		t.Fatalf("wrong column name: %v", name)
+	}
+	if name := ColumnName(HeadingWgs84Longitude); name != "wgs84_longitude" {
+		t.Fatalf("wrong column name: %v", name)
+	}
+
+	statements := sqliteLoader(nil).DDL([]string{"Licence Number", "Frequency", HeadingOsEasting})
+	if len(statements) != 2 {
+		t.Fatalf("unexpected schema: %v", statements)
+	}
+	if statements[0] != `CREATE TABLE IF NOT EXISTS "licences" ("licence_number" TEXT, "frequency" TEXT, "os_easting" INTEGER)` {
+		t.Fatalf("unexpected create: %v", statements[0])
+	}
+}
+
+// The round trip is through the fake driver of sqlload_test.go as this
+// package does not import a SQLite driver.
+func TestSQLiteRoundTrip(t *testing.T) {
+	const csv = "Licence Number,Licencee Company,Frequency,OS Easting,OS Northing\n" +
+		"0000001/1,Example Ltd,7.5,529400,181900\n0000002/1,\"Other, Ltd\",13.0,1,2\n"
+	collection := testCollection(t, csv)
+
+	savedDriverName := SQLiteDriverName
+	SQLiteDriverName = "wtrcsv-fake"
+	defer func() { SQLiteDriverName = savedDriverName }()
+	db, _ := openFakeDB(t, t.Name())
+	defer db.Close()
+
+	// Writing twice replaces the tables rather than appending to them.
+	for i := 0; i < 2; i++ {
+		if err := collection.WriteSQLite(t.Name()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read, err := ReadSQLite(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(read.Header, collection.Header) {
+		t.Fatalf("wrong header %v", read.Header)
+	}
+	if len(read.Rows) != len(collection.Rows) {
+		t.Fatalf("%d rows read", len(read.Rows))
+	}
+	for i, row := range read.Rows {
+		if got, expected := row.toRecord(read.Header), collection.Rows[i].toRecord(collection.Header); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("row %d is %v, expected %v", i, got, expected)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package wtrcsv
+
+import (
+	"database/sql"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL differences between database backends that
+// matter to SQLLoader.
+type Dialect struct {
+	Placeholder     func(n int) string // n'th (from 1) statement parameter
+	QuoteIdentifier func(name string) string
+	IndexColumn     func(name string) string // column expression in CREATE INDEX
+	TextType        string
+	IntegerType     string
+	RealType        string
+	MaxParameters   int  // per statement
+	IfNotExists     bool // CREATE INDEX IF NOT EXISTS is supported
+}
+
+var (
+	DialectSQLite = Dialect{
+		Placeholder:     func(int) string { return "?" },
+		QuoteIdentifier: doubleQuote,
+		IndexColumn:     doubleQuote,
+		TextType:        "TEXT",
+		IntegerType:     "INTEGER",
+		RealType:        "REAL",
+		MaxParameters:   999,
+		IfNotExists:     true,
+	}
+	DialectPostgres = Dialect{
+		Placeholder:     func(n int) string { return "$" + strconv.Itoa(n) },
+		QuoteIdentifier: doubleQuote,
+		IndexColumn:     doubleQuote,
+		TextType:        "TEXT",
+		IntegerType:     "BIGINT",
+		RealType:        "DOUBLE PRECISION",
+		MaxParameters:   65535,
+		IfNotExists:     true,
+	}
+	DialectMySQL = Dialect{
+		Placeholder:     func(int) string { return "?" },
+		QuoteIdentifier: backQuote,
+		// TEXT columns can only be indexed on a prefix.
+		IndexColumn:   func(name string) string { return backQuote(name) + "(191)" },
+		TextType:      "TEXT",
+		IntegerType:   "BIGINT",
+		RealType:      "DOUBLE",
+		MaxParameters: 65535,
+	}
+)
+
+func doubleQuote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func backQuote(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// numericalColumns are the munged columns given a numerical SQL type.
+// All other columns are text, stored verbatim.
+var numericalColumns = map[string]bool{
+	HeadingOsEasting:      true, // integer
+	HeadingOsNorthing:     true, // integer
+	HeadingWgs84Longitude: false,
+	HeadingWgs84Latitude:  false,
+}
+
+// SQLLoader inserts the rows of a Collection into a database/sql backend
+// using batched multi-row INSERT statements.
+type SQLLoader struct {
+	DB      *sql.DB
+	Dialect Dialect
+	Table   string // default "licences"
+	// Columns are the headings to insert, by default the collection's header.
+	Columns []string
+	// ColumnNames maps headings to SQL column names. Unmapped headings use
+	// the snake_case json tag of Row, eg. "Licence Number" is licence_number.
+	ColumnNames map[string]string
+	BatchSize   int  // rows per INSERT, default 500
+	CreateTable bool // create the table (and Indexes) if it does not exist
+	Indexes     []string
+}
+
+func (loader *SQLLoader) table() string {
+	if loader.Table == "" {
+		return "licences"
+	}
+	return loader.Table
+}
+
+func (loader *SQLLoader) columnName(heading string) string {
+	if name, ok := loader.ColumnNames[heading]; ok {
+		return name
+	}
+	return ColumnName(heading)
+}
+
+// DDL returns the statements creating the table and its indexes for the
+// columns. The indexes are only created if they do not exist where the
+// dialect supports it, so that a table can be loaded more than once.
+func (loader *SQLLoader) DDL(columns []string) []string {
+	d := loader.Dialect
+	definitions := make([]string, len(columns))
+	for i, heading := range columns {
+		sqlType := d.TextType
+		if integer, ok := numericalColumns[heading]; ok {
+			if integer {
+				sqlType = d.IntegerType
+			} else {
+				sqlType = d.RealType
+			}
+		}
+		definitions[i] = d.QuoteIdentifier(loader.columnName(heading)) + " " + sqlType
+	}
+
+	table := loader.table()
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS " + d.QuoteIdentifier(table) + " (" + strings.Join(definitions, ", ") + ")",
+	}
+	createIndex := "CREATE INDEX "
+	if d.IfNotExists {
+		createIndex += "IF NOT EXISTS "
+	}
+	present := make(map[string]bool, len(columns))
+	for _, heading := range columns {
+		present[heading] = true
+	}
+	for _, heading := range loader.Indexes {
+		if present[heading] {
+			name := loader.columnName(heading)
+			statements = append(statements, createIndex+d.QuoteIdentifier(table+"_"+name)+
+				" ON "+d.QuoteIdentifier(table)+" ("+d.IndexColumn(name)+")")
+		}
+	}
+	return statements
+}
+
+// insertStatement returns a multi-row INSERT of n rows of the columns.
+func (loader *SQLLoader) insertStatement(columns []string, n int) string {
+	d := loader.Dialect
+	names := make([]string, len(columns))
+	for i, heading := range columns {
+		names[i] = d.QuoteIdentifier(loader.columnName(heading))
+	}
+
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + d.QuoteIdentifier(loader.table()) + " (" + strings.Join(names, ", ") + ") VALUES ")
+	parameter := 1
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(d.Placeholder(parameter))
+			parameter++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// Load inserts the rows of the collection in a single transaction.
+func (loader *SQLLoader) Load(collection *Collection) error {
+	tx, err := loader.DB.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	if err := loader.LoadTx(tx, collection); err != nil {
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "could not commit")
+}
+
+// LoadTx is as Load but within the caller's transaction.
+func (loader *SQLLoader) LoadTx(tx *sql.Tx, collection *Collection) error {
+	columns := loader.Columns
+	if columns == nil {
+		columns = collection.Header
+	}
+	if len(columns) == 0 {
+		return errors.New("no columns to load")
+	}
+
+	if loader.CreateTable {
+		for _, statement := range loader.DDL(columns) {
+			if _, err := tx.Exec(statement); err != nil {
+				return errors.Wrapf(err, "could not execute \"%s\"", statement)
+			}
+		}
+	}
+
+	batchSize := loader.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if max := loader.Dialect.MaxParameters / len(columns); max > 0 && batchSize > max {
+		batchSize = max
+	}
+
+	var stmt *sql.Stmt
+	stmtRows := 0
+	defer func() {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}()
+
+	encoder := newRowEncoder(columns, collection.computed)
+	values := make([]interface{}, 0, batchSize*len(columns))
+	for start := 0; start < len(collection.Rows); start += batchSize {
+		end := start + batchSize
+		if end > len(collection.Rows) {
+			end = len(collection.Rows)
+		}
+
+		// Prepared once for full batches and once more for the remainder.
+		if end-start != stmtRows {
+			if stmt != nil {
+				stmt.Close()
+			}
+			var err error
+			if stmt, err = tx.Prepare(loader.insertStatement(columns, end-start)); err != nil {
+				return errors.Wrap(err, "could not prepare insert")
+			}
+			stmtRows = end - start
+		}
+
+		values = values[:0]
+		for _, row := range collection.Rows[start:end] {
+			for j, value := range encoder.encode(row) {
+				if _, numerical := numericalColumns[columns[j]]; numerical && value == "" {
+					values = append(values, nil) // NULL rather than an empty string
+				} else {
+					values = append(values, value)
+				}
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return errors.Wrapf(err, "could not insert rows %d to %d", start+1, end)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package wtrcsv
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/pkg/errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver recording the statements executed on
+// each named database, and the rows inserted into its tables. Queries select
+// columns of a table in the order the rows were inserted.
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+type fakeDB struct {
+	execs  []fakeExec
+	tables map[string]*fakeTable
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeTable struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var testDriver = &fakeDriver{dbs: make(map[string]*fakeDB)}
+
+func init() {
+	sql.Register("wtrcsv-fake", testDriver)
+}
+
+// openFakeDB returns a new database named name of the fake driver.
+func openFakeDB(t *testing.T, name string) (*sql.DB, *fakeDB) {
+	t.Helper()
+	testDriver.mu.Lock()
+	fake := &fakeDB{tables: make(map[string]*fakeTable)}
+	testDriver.dbs[name] = fake
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("wtrcsv-fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fake, ok := d.dbs[name]
+	if !ok {
+		fake = &fakeDB{tables: make(map[string]*fakeTable)}
+		d.dbs[name] = fake
+	}
+	return &fakeConn{driver: d, db: fake}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	db     *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// unquoteIdentifier removes the double quotes of a SQL identifier.
+func unquoteIdentifier(name string) string {
+	return strings.Replace(strings.Trim(strings.TrimSpace(name), `"`), `""`, `"`, -1)
+}
+
+// fakeColumns returns the identifiers of a parenthesised column list.
+func fakeColumns(list string) []string {
+	list = list[strings.Index(list, "(")+1 : strings.Index(list, ")")]
+	var columns []string
+	for _, column := range strings.Split(list, ",") {
+		columns = append(columns, unquoteIdentifier(strings.Fields(column)[0]))
+	}
+	return columns
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+	db := s.conn.db
+	db.execs = append(db.execs, fakeExec{s.query, args})
+
+	fields := strings.Fields(s.query)
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS "):
+		name := unquoteIdentifier(fields[5])
+		if db.tables[name] == nil {
+			db.tables[name] = &fakeTable{columns: fakeColumns(s.query)}
+		}
+	case strings.HasPrefix(s.query, "CREATE TABLE "):
+		db.tables[unquoteIdentifier(fields[2])] = &fakeTable{columns: fakeColumns(s.query)}
+	case strings.HasPrefix(s.query, "DROP TABLE IF EXISTS "):
+		delete(db.tables, unquoteIdentifier(fields[4]))
+	case strings.HasPrefix(s.query, "INSERT INTO "):
+		table := db.tables[unquoteIdentifier(fields[2])]
+		n := len(fakeColumns(s.query))
+		for start := 0; start < len(args); start += n {
+			table.rows = append(table.rows, args[start:start+n])
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	from := strings.Index(s.query, " FROM ")
+	if !strings.HasPrefix(s.query, "SELECT ") || from < 0 {
+		return nil, errors.Errorf("unsupported query \"%s\"", s.query)
+	}
+	name := unquoteIdentifier(strings.Fields(s.query[from+len(" FROM "):])[0])
+	table, ok := s.conn.db.tables[name]
+	if !ok {
+		return nil, errors.Errorf("no such table: %s", name)
+	}
+
+	positions := make(map[string]int, len(table.columns))
+	for i, column := range table.columns {
+		positions[column] = i
+	}
+	var columns []string
+	var indices []int
+	for _, column := range strings.Split(s.query[len("SELECT "):from], ",") {
+		column = unquoteIdentifier(column)
+		i, ok := positions[column]
+		if !ok {
+			return nil, errors.Errorf("no such column: %s", column)
+		}
+		columns = append(columns, column)
+		indices = append(indices, i)
+	}
+
+	rows := make([][]driver.Value, len(table.rows))
+	for i, row := range table.rows {
+		rows[i] = make([]driver.Value, len(indices))
+		for j, index := range indices {
+			rows[i][j] = row[index]
+		}
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLLoaderStatements(t *testing.T) {
+	loader := &SQLLoader{
+		Dialect:     DialectPostgres,
+		Table:       "wtr",
+		ColumnNames: map[string]string{"Licence Number": "id"},
+		Indexes:     []string{"Licence Number", "Licencee Company"},
+	}
+	columns := []string{"Licence Number", HeadingWgs84Latitude}
+
+	statements := loader.DDL(columns)
+	if len(statements) != 2 {
+		t.Fatalf("unexpected DDL: %v", statements)
+	}
+	if statements[0] != `CREATE TABLE IF NOT EXISTS "wtr" ("id" TEXT, "wgs84_latitude" DOUBLE PRECISION)` {
+		t.Fatalf("unexpected create: %v", statements[0])
+	}
+	if statements[1] != `CREATE INDEX IF NOT EXISTS "wtr_id" ON "wtr" ("id")` {
+		t.Fatalf("unexpected index: %v", statements[1])
+	}
+
+	insert := loader.insertStatement(columns, 2)
+	if insert != `INSERT INTO "wtr" ("id", "wgs84_latitude") VALUES ($1, $2), ($3, $4)` {
+		t.Fatalf("unexpected insert: %v", insert)
+	}
+
+	loader.Dialect = DialectMySQL
+	if index := loader.DDL(columns)[1]; index != "CREATE INDEX `wtr_id` ON `wtr` (`id`(191))" {
+		t.Fatalf("unexpected MySQL index: %v", index)
+	}
+}
+
+func TestSQLLoaderLoad(t *testing.T) {
+	collection := testCollection(t, "Licence Number,Licencee Company,OS Easting\n"+
+		"0000001/1,Example Ltd,529400\n0000002/1,Other Ltd,529500\n0000003/1,Example Ltd,1\n")
+	db, fake := openFakeDB(t, t.Name())
+	defer db.Close()
+
+	loader := &SQLLoader{
+		DB:          db,
+		Dialect:     DialectSQLite,
+		BatchSize:   2,
+		CreateTable: true,
+		Indexes:     []string{"Licence Number"},
+	}
+	if err := loader.Load(collection); err != nil {
+		t.Fatal(err)
+	}
+
+	insert2 := `INSERT INTO "licences" ("licence_number", "licencee_company", "os_easting") VALUES (?, ?, ?), (?, ?, ?)`
+	insert1 := `INSERT INTO "licences" ("licence_number", "licencee_company", "os_easting") VALUES (?, ?, ?)`
+	expected := []fakeExec{
+		{`CREATE TABLE IF NOT EXISTS "licences" ("licence_number" TEXT, "licencee_company" TEXT, "os_easting" INTEGER)`, []driver.Value{}},
+		{`CREATE INDEX IF NOT EXISTS "licences_licence_number" ON "licences" ("licence_number")`, []driver.Value{}},
+		{insert2, []driver.Value{"0000001/1", "Example Ltd", "529400", "0000002/1", "Other Ltd", "529500"}},
+		{insert1, []driver.Value{"0000003/1", "Example Ltd", "1"}},
+	}
+	if !reflect.DeepEqual(fake.execs, expected) {
+		t.Fatalf("unexpected statements:\n%v\nexpected:\n%v", fake.execs, expected)
+	}
+	if rows := len(fake.tables["licences"].rows); rows != 3 {
+		t.Fatalf("%d rows loaded", rows)
+	}
+
+	// The table and index already exist, so loading again only inserts.
+	if err := loader.Load(collection); err != nil {
+		t.Fatal(err)
+	}
+	if rows := len(fake.tables["licences"].rows); rows != 6 {
+		t.Fatalf("%d rows after loading twice", rows)
+	}
+}
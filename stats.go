@@ -0,0 +1,140 @@
+package wtrcsv
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NumericField is an engineering column of a Row for Stats.
+type NumericField string
+
+const (
+	FieldERP           NumericField = "erp_dbw"
+	FieldAntennaHeight NumericField = "antenna_height_m"
+	FieldAntennaGain   NumericField = "antenna_gain"
+	FieldFrequency     NumericField = "frequency_mhz"
+)
+
+// numericFields are the values of the NumericFields, in their units.
+var numericFields = map[NumericField]func(row *Row) (float64, bool){
+	FieldERP:           erpDBW,
+	FieldAntennaHeight: func(row *Row) (float64, bool) { return parseNumber(row.AntennaHeight) },
+	FieldAntennaGain:   func(row *Row) (float64, bool) { return parseNumber(row.AntennaGain) },
+	FieldFrequency:     frequencyMHz,
+}
+
+func parseNumber(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return v, err == nil && !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// HistogramBucket counts the values in [Low, High), the last bucket of a
+// histogram including its High.
+type HistogramBucket struct {
+	Low, High float64
+	Count     int
+}
+
+// Stats are descriptive statistics of a NumericField over the rows with a
+// value. The percentiles interpolate linearly between values.
+type Stats struct {
+	Field     NumericField
+	Count     int // rows with a value
+	Missing   int // rows without
+	Min, Max  float64
+	Mean      float64
+	StdDev    float64 // of the population
+	P5, P25   float64
+	Median    float64
+	P75, P95  float64
+	Histogram []HistogramBucket // equal width buckets from Min to Max
+}
+
+// Stats returns the statistics of a field with a histogram of buckets
+// buckets (none if buckets < 1). The statistics are zero if no row has a
+// value, and nil is returned for an unknown field.
+func (collection *Collection) Stats(field NumericField, buckets int) *Stats {
+	valueFn, ok := numericFields[field]
+	if !ok {
+		return nil
+	}
+	stats := &Stats{Field: field}
+	values := make([]float64, 0, len(collection.Rows))
+	for _, row := range collection.Rows {
+		if v, ok := valueFn(row); ok {
+			values = append(values, v)
+		}
+	}
+	stats.Count, stats.Missing = len(values), len(collection.Rows)-len(values)
+	if len(values) == 0 {
+		return stats
+	}
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	stats.Mean = sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - stats.Mean) * (v - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(values)))
+	stats.Min, stats.Max = values[0], values[len(values)-1]
+	stats.P5, stats.P25 = percentile(values, 5), percentile(values, 25)
+	stats.Median = percentile(values, 50)
+	stats.P75, stats.P95 = percentile(values, 75), percentile(values, 95)
+	stats.Histogram = histogram(values, buckets)
+	return stats
+}
+
+// StatsByProductCode returns the statistics of a field for each numerical
+// product code.
+func (collection *Collection) StatsByProductCode(field NumericField, buckets int) map[string]*Stats {
+	stats := make(map[string]*Stats)
+	for code, group := range collection.GroupByProductCode() {
+		stats[code] = group.Stats(field, buckets)
+	}
+	return stats
+}
+
+// percentile returns the p'th percentile of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	position := p / 100 * float64(len(sorted)-1)
+	i := int(position)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	fraction := position - float64(i)
+	return sorted[i] + fraction*(sorted[i+1]-sorted[i])
+}
+
+// histogram counts sorted values into equal width buckets, a single bucket
+// if all the values are equal.
+func histogram(sorted []float64, buckets int) []HistogramBucket {
+	if buckets < 1 {
+		return nil
+	}
+	min, max := sorted[0], sorted[len(sorted)-1]
+	if min == max {
+		return []HistogramBucket{{min, max, len(sorted)}}
+	}
+	width := (max - min) / float64(buckets)
+	result := make([]HistogramBucket, buckets)
+	for i := range result {
+		result[i].Low = min + float64(i)*width
+		result[i].High = min + float64(i+1)*width
+	}
+	result[buckets-1].High = max
+	for _, v := range sorted {
+		i := int((v - min) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		result[i].Count++
+	}
+	return result
+}
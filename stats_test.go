@@ -0,0 +1,52 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Product Description 31,Antenna ERP,Antenna ERP type,Antenna Height,Antenna Gain,Frequency,Frequency Type
+0000001/1,301010,10,dBW,10,30,7.5,GHz
+0000002/1,301010,40,dBm,20,35,13,GHz
+0000003/1,301010,100,W,30,,18,GHz
+0000004/1,301010,30,dBW,40,40,,
+0000005/1,305010,,,50,10,450,MHz
+`)
+	stats := collection.Stats(FieldAntennaHeight, 4)
+	if stats.Count != 5 || stats.Missing != 0 || stats.Min != 10 || stats.Max != 50 || stats.Mean != 30 || stats.Median != 30 {
+		t.Errorf("got %+v", stats)
+	}
+	if stats.P25 != 20 || stats.P95 != 48 || math.Abs(stats.StdDev-math.Sqrt(200)) > 1e-9 {
+		t.Errorf("got percentiles %v %v, deviation %v", stats.P25, stats.P95, stats.StdDev)
+	}
+	want := []HistogramBucket{{10, 20, 1}, {20, 30, 1}, {30, 40, 1}, {40, 50, 2}}
+	for i := range want {
+		if i >= len(stats.Histogram) || stats.Histogram[i] != want[i] {
+			t.Fatalf("got histogram %v, want %v", stats.Histogram, want)
+		}
+	}
+
+	erp := collection.Stats(FieldERP, 0)
+	if erp.Count != 4 || erp.Missing != 1 || erp.Min != 10 || erp.Max != 30 || erp.Histogram != nil {
+		t.Errorf("got ERP %+v", erp)
+	}
+	if gain := collection.Stats(FieldAntennaGain, 1); gain.Count != 4 || len(gain.Histogram) != 1 || gain.Histogram[0].Count != 4 {
+		t.Errorf("got gain %+v", gain)
+	}
+
+	byCode := collection.StatsByProductCode(FieldFrequency, 2)
+	if len(byCode) != 2 || byCode["301010"].Count != 3 || byCode["301010"].Missing != 1 || byCode["305010"].Median != 450 {
+		t.Errorf("got by product code %+v", byCode)
+	}
+	if byCode["305010"].Histogram[0] != (HistogramBucket{450, 450, 1}) {
+		t.Errorf("got histogram %v", byCode["305010"].Histogram)
+	}
+
+	if collection.Stats("colour", 1) != nil {
+		t.Error("expected nil for unknown field")
+	}
+	if empty := (&Collection{}).Stats(FieldERP, 3); empty.Count != 0 || empty.Histogram != nil {
+		t.Errorf("got %+v", empty)
+	}
+}
@@ -0,0 +1,68 @@
+package wtrcsv
+
+import (
+	"strings"
+)
+
+// parseYesNo interprets the Y/N, Yes/No and True/False variants of a flag
+// column, in any case. ok is false for any other value.
+func parseYesNo(s string) (value, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "y", "yes", "true", "t", "1":
+		return true, true
+	case "n", "no", "false", "f", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// IsTradeable returns whether the licence of the Row is tradeable. ok is
+// false if the Tradeable column is empty or not a recognised yes or no.
+func (row *Row) IsTradeable() (tradeable, ok bool) {
+	return parseYesNo(row.Tradeable)
+}
+
+// IsPublishable returns whether the licence of the Row is publishable. ok
+// is false if the Publishable column is empty or not a recognised yes or
+// no.
+func (row *Row) IsPublishable() (publishable, ok bool) {
+	return parseYesNo(row.Publishable)
+}
+
+// NormalisedStatus returns the Status of the Row with surrounding and
+// repeated whitespace removed, for comparing statuses ignoring case.
+func (row *Row) NormalisedStatus() string {
+	return strings.Join(strings.Fields(row.Status), " ")
+}
+
+// FilterStatus returns a FilterFn keeping the rows with any of the
+// statuses, eg. FilterStatus("Registered"), compared ignoring case and
+// whitespace.
+func FilterStatus(statuses ...string) FilterFn {
+	lookup := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		lookup[strings.ToLower(strings.Join(strings.Fields(status), " "))] = true
+	}
+	return func(row *Row) bool {
+		return lookup[strings.ToLower(row.NormalisedStatus())]
+	}
+}
+
+// FilterTradeable returns a FilterFn keeping the rows whose licence is, or
+// is not, tradeable. Rows with an unrecognised Tradeable value are dropped.
+func FilterTradeable(tradeable bool) FilterFn {
+	return func(row *Row) bool {
+		v, ok := row.IsTradeable()
+		return ok && v == tradeable
+	}
+}
+
+// FilterPublishable returns a FilterFn keeping the rows whose licence is,
+// or is not, publishable. Rows with an unrecognised Publishable value are
+// dropped.
+func FilterPublishable(publishable bool) FilterFn {
+	return func(row *Row) bool {
+		v, ok := row.IsPublishable()
+		return ok && v == publishable
+	}
+}
@@ -0,0 +1,35 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestStatusFilters(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Status,Tradeable,Publishable
+0000001/1,Registered,Y,Yes
+0000002/1, registered ,no,N
+0000003/1,Cancelled,YES,
+0000004/1,Live,maybe,n
+`)
+	for _, test := range []struct {
+		filter FilterFn
+		want   string
+	}{
+		{FilterStatus("Registered"), "0000001/1 0000002/1"},
+		{FilterStatus("cancelled", "LIVE"), "0000003/1 0000004/1"},
+		{FilterTradeable(true), "0000001/1 0000003/1"},
+		{FilterTradeable(false), "0000002/1"},
+		{FilterPublishable(true), "0000001/1"},
+		{FilterPublishable(false), "0000002/1 0000004/1"},
+	} {
+		if got := licenceNumbers(collection.Filter(test.filter)); got != test.want {
+			t.Errorf("got %s, want %s", got, test.want)
+		}
+	}
+	if _, ok := collection.Rows[3].IsTradeable(); ok {
+		t.Error("maybe is tradeable")
+	}
+	if got := collection.Rows[1].NormalisedStatus(); got != "registered" {
+		t.Errorf("got %q", got)
+	}
+}
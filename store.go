@@ -0,0 +1,350 @@
+package wtrcsv
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"github.com/recombinant/go-wtrcsv/internal/protowire"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSnapshotNotFound is returned by a Store for an unknown snapshot.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Store persists named snapshots. Rows within a snapshot are ordered by
+// licence number so that they can be queried by licence number range.
+type Store interface {
+	// Put stores a snapshot, replacing any snapshot of the same name.
+	Put(name string, collection *Collection) error
+	// Get returns a snapshot with rows ordered by licence number.
+	Get(name string) (*Collection, error)
+	// Range returns the rows of a snapshot with from <= licence number < to.
+	// An empty to means no upper bound.
+	Range(name, from, to string) ([]*Row, error)
+	// Names returns the names of the stored snapshots in order.
+	Names() ([]string, error)
+}
+
+// byLicenceNumber returns the rows of a collection ordered by licence number.
+func byLicenceNumber(collection *Collection) []*Row {
+	rows := append([]*Row(nil), collection.Rows...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].LicenceNumber < rows[j].LicenceNumber
+	})
+	return rows
+}
+
+func inRange(key, from, to string) bool {
+	return key >= from && (to == "" || key < to)
+}
+
+// MemoryStore is a Store held in memory.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]*Collection
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: make(map[string]*Collection)}
+}
+
+// Put stores a snapshot.
+func (store *MemoryStore) Put(name string, collection *Collection) error {
+	sorted := collection.withRows(byLicenceNumber(collection))
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.snapshots[name] = sorted
+	return nil
+}
+
+// Get returns a snapshot.
+func (store *MemoryStore) Get(name string) (*Collection, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	collection, ok := store.snapshots[name]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	return collection.withRows(append([]*Row(nil), collection.Rows...)), nil
+}
+
+// Range returns the rows of a snapshot in a licence number range.
+func (store *MemoryStore) Range(name, from, to string) ([]*Row, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	collection, ok := store.snapshots[name]
+	if !ok {
+		return nil, ErrSnapshotNotFound
+	}
+	rows := collection.Rows
+	i := sort.Search(len(rows), func(i int) bool { return rows[i].LicenceNumber >= from })
+	var result []*Row
+	for ; i < len(rows) && inRange(rows[i].LicenceNumber, from, to); i++ {
+		result = append(result, rows[i])
+	}
+	return result, nil
+}
+
+// Names returns the names of the stored snapshots.
+func (store *MemoryStore) Names() ([]string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	names := make([]string, 0, len(store.snapshots))
+	for name := range store.snapshots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FileStore is a Store on disk. Each snapshot is a protobuf snapshot stream
+// (see wtr.proto) with a sparse index of licence numbers, so range queries
+// read only the part of the file they need.
+type FileStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+const (
+	fileStoreData  = ".wtrpb"
+	fileStoreIndex = ".idx"
+	// fileStoreBlock is the number of rows between index entries.
+	fileStoreBlock = 256
+)
+
+// NewFileStore returns a FileStore in a directory, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create store directory")
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (store *FileStore) path(name, extension string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", errors.Errorf("invalid snapshot name %q", name)
+	}
+	return filepath.Join(store.dir, name+extension), nil
+}
+
+// countingWriter tracks the offset of the data written.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// Put stores a snapshot. The data file is replaced before the index and a
+// snapshot is only visible with both, so that after a crash it is either
+// complete or absent.
+func (store *FileStore) Put(name string, collection *Collection) error {
+	dataPath, err := store.path(name, fileStoreData)
+	if err != nil {
+		return err
+	}
+	indexPath, _ := store.path(name, fileStoreIndex)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, err := ioutil.TempFile(store.dir, name+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not create snapshot file")
+	}
+	defer os.Remove(data.Name())
+	defer data.Close()
+
+	// The header is written through a counting writer so that row offsets
+	// are known; each row is flushed before its offset is taken.
+	buffered := bufio.NewWriter(data)
+	counter := &countingWriter{w: buffered}
+	w, err := NewProtoWriter(counter, collection.Header)
+	if err != nil {
+		return err
+	}
+	var index []byte
+	for i, row := range byLicenceNumber(collection) {
+		if i%fileStoreBlock == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			index = protowire.AppendString(index, 1, row.LicenceNumber)
+			index = protowire.AppendInt64(index, 2, counter.n)
+		}
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+	if err := data.Close(); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+
+	if err := ioutil.WriteFile(indexPath+".tmp", index, 0644); err != nil {
+		return errors.Wrap(err, "could not write index file")
+	}
+	defer os.Remove(indexPath + ".tmp")
+
+	// A snapshot is only visible with its index, which is removed before
+	// the data is replaced and renamed into place last, so that a crash
+	// never leaves an index of data that is not there.
+	if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not remove index file")
+	}
+	if err := os.Rename(data.Name(), dataPath); err != nil {
+		return errors.Wrap(err, "could not write snapshot file")
+	}
+	return errors.Wrap(os.Rename(indexPath+".tmp", indexPath), "could not write index file")
+}
+
+// exists reports whether both files of a snapshot are present.
+func (store *FileStore) exists(dataPath, indexPath string) bool {
+	for _, path := range []string{dataPath, indexPath} {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (store *FileStore) open(name string) (*os.File, error) {
+	dataPath, err := store.path(name, fileStoreData)
+	if err != nil {
+		return nil, err
+	}
+	indexPath, _ := store.path(name, fileStoreIndex)
+	if !store.exists(dataPath, indexPath) {
+		return nil, ErrSnapshotNotFound
+	}
+	file, err := os.Open(dataPath)
+	if os.IsNotExist(err) {
+		return nil, ErrSnapshotNotFound
+	}
+	return file, errors.Wrap(err, "could not open snapshot file")
+}
+
+// Get reads a snapshot.
+func (store *FileStore) Get(name string) (*Collection, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	file, err := store.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadProto(file)
+}
+
+// fileStoreEntry is an entry of the sparse index.
+type fileStoreEntry struct {
+	key    string
+	offset int64
+}
+
+func (store *FileStore) readIndex(name string) ([]fileStoreEntry, error) {
+	indexPath, _ := store.path(name, fileStoreIndex)
+	b, err := ioutil.ReadFile(indexPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read index file")
+	}
+	var entries []fileStoreEntry
+	err = protowire.Fields(b, func(field *protowire.Field) error {
+		switch field.Number {
+		case 1:
+			entries = append(entries, fileStoreEntry{key: string(field.Bytes)})
+		case 2:
+			if len(entries) == 0 {
+				return errors.New("offset without key")
+			}
+			entries[len(entries)-1].offset = int64(field.Varint)
+		}
+		return nil
+	})
+	return entries, errors.Wrap(err, "could not decode index file")
+}
+
+// Range reads the rows of a snapshot in a licence number range, starting
+// from the last index block that may contain from.
+func (store *FileStore) Range(name, from, to string) ([]*Row, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	file, err := store.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries, err := store.readIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	// The first block whose first key is >= from; the block before it may
+	// also contain from.
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].key >= from })
+	if i > 0 {
+		i--
+	}
+	if i == len(entries) {
+		return nil, nil
+	}
+
+	reader, err := NewProtoReader(file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(entries[i].offset, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "could not seek snapshot file")
+	}
+	reader.r.Reset(file)
+
+	var rows []*Row
+	for {
+		row, err := reader.ReadRow()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if to != "" && row.LicenceNumber >= to {
+			return rows, nil
+		}
+		if row.LicenceNumber >= from {
+			rows = append(rows, row)
+		}
+	}
+}
+
+// Names returns the names of the stored snapshots.
+func (store *FileStore) Names() ([]string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	matches, err := filepath.Glob(filepath.Join(store.dir, "*"+fileStoreData))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list snapshots")
+	}
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), fileStoreData)
+		if store.exists(match, strings.TrimSuffix(match, fileStoreData)+fileStoreIndex) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
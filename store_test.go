@@ -0,0 +1,94 @@
+package wtrcsv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testStore(t *testing.T, store Store) {
+	var b strings.Builder
+	b.WriteString("Licence Number,Frequency\n")
+	// More rows than an index block, out of order.
+	for i := 999; i >= 0; i-- {
+		b.WriteString("L" + strconv.Itoa(1000+i) + ",7.5\n")
+	}
+	collection := testCollection(t, b.String())
+
+	if _, err := store.Get("missing"); err != ErrSnapshotNotFound {
+		t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+	}
+	if err := store.Put("2019-01-01", collection); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("2019-02-01", testCollection(t, "Licence Number\nL1\n")); err != nil {
+		t.Fatal(err)
+	}
+	names, err := store.Names()
+	if err != nil || strings.Join(names, " ") != "2019-01-01 2019-02-01" {
+		t.Fatalf("wrong names %v: %v", names, err)
+	}
+
+	got, err := store.Get("2019-01-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rows) != 1000 || got.Rows[0].LicenceNumber != "L1000" || got.Rows[999].LicenceNumber != "L1999" {
+		t.Fatalf("wrong snapshot: %d rows", len(got.Rows))
+	}
+
+	for _, test := range []struct {
+		from, to    string
+		first, last string
+		n           int
+	}{
+		{"L1300", "L1600", "L1300", "L1599", 300},
+		{"L1255", "L1257", "L1255", "L1256", 2},
+		{"L1990", "", "L1990", "L1999", 10},
+		{"", "L1002", "L1000", "L1001", 2},
+		{"M", "", "", "", 0},
+	} {
+		rows, err := store.Range("2019-01-01", test.from, test.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != test.n || (test.n > 0 && (rows[0].LicenceNumber != test.first || rows[test.n-1].LicenceNumber != test.last)) {
+			t.Errorf("Range(%q, %q): wrong %d rows", test.from, test.to, len(rows))
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+	if err := store.Put("../escape", &Collection{}); err == nil {
+		t.Fatal("expected error for invalid name")
+	}
+
+	// Data without its index, as left by a crash before the index is
+	// renamed into place, is not a snapshot.
+	if err := os.Remove(filepath.Join(dir, "2019-02-01"+fileStoreIndex)); err != nil {
+		t.Fatal(err)
+	}
+	if names, err := store.Names(); err != nil || strings.Join(names, " ") != "2019-01-01" {
+		t.Fatalf("wrong names %v: %v", names, err)
+	}
+	if _, err := store.Get("2019-02-01"); err != ErrSnapshotNotFound {
+		t.Fatalf("expected ErrSnapshotNotFound, got %v", err)
+	}
+}
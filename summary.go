@@ -0,0 +1,138 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// SummaryCount is the number of rows and of distinct licences with a key.
+type SummaryCount struct {
+	Key      string
+	Rows     int
+	Licences int
+}
+
+// Summary counts the rows of a Collection by the dimensions users most
+// often need. Each dimension is ordered by rows, most first, then key.
+type Summary struct {
+	Rows          int
+	Licences      int
+	ByProductCode []SummaryCount // numerical product code
+	ByCompany     []SummaryCount
+	ByStatus      []SummaryCount
+	ByStationType []SummaryCount
+	ByBand        []SummaryCount // ITU band, eg. "SHF", see KeyITUBand
+	Quoting       *QuotingAudit  // the columns with embedded commas, quotes or newlines
+}
+
+// ituBands are the upper limits in MHz of the ITU bands, which include
+// their upper limit and exclude their lower.
+var ituBands = []struct {
+	name     string
+	upperMHz float64
+}{
+	{"VLF", 0.03}, {"LF", 0.3}, {"MF", 3}, {"HF", 30}, {"VHF", 300},
+	{"UHF", 3000}, {"SHF", 30000}, {"EHF", 300000}, {"THF", 3000000},
+}
+
+// KeyITUBand keys a Row by the ITU band of its frequency, eg. "UHF" for
+// above 300 MHz up to and including 3 GHz, or "" without a frequency.
+func KeyITUBand(row *Row) string {
+	f, ok := frequencyMHz(row)
+	if !ok {
+		return ""
+	}
+	for _, band := range ituBands {
+		if f <= band.upperMHz {
+			return band.name
+		}
+	}
+	return ""
+}
+
+// KeyStatus keys a Row by its licence status (see NormalisedStatus).
+func KeyStatus(row *Row) string {
+	return row.NormalisedStatus()
+}
+
+// KeyStationType keys a Row by its station type.
+func KeyStationType(row *Row) string {
+	return row.StationType
+}
+
+// Summary counts the rows and licences of the collection by product code,
+// company, status, station type and ITU band, with the quoting audit of
+// its columns (see AuditQuoting).
+func (collection *Collection) Summary() *Summary {
+	return &Summary{
+		Rows:          len(collection.Rows),
+		Licences:      countLicences(collection.Rows),
+		ByProductCode: collection.summaryCounts(KeyProductCode),
+		ByCompany:     collection.summaryCounts(KeyCompany),
+		ByStatus:      collection.summaryCounts(KeyStatus),
+		ByStationType: collection.summaryCounts(KeyStationType),
+		ByBand:        collection.summaryCounts(KeyITUBand),
+		Quoting:       collection.AuditQuoting(),
+	}
+}
+
+func (collection *Collection) summaryCounts(keyFn KeyFn) []SummaryCount {
+	groups := collection.GroupBy(keyFn)
+	counts := make([]SummaryCount, 0, len(groups))
+	for key, group := range groups {
+		counts = append(counts, SummaryCount{key, len(group.Rows), countLicences(group.Rows)})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Rows != counts[j].Rows {
+			return counts[i].Rows > counts[j].Rows
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return counts
+}
+
+// countLicences returns the number of distinct licence numbers.
+func countLicences(rows []*Row) int {
+	licences := make(map[string]bool)
+	for _, row := range rows {
+		licences[row.LicenceNumber] = true
+	}
+	return len(licences)
+}
+
+// WriteSummary writes the summary as plain text tables, one per dimension,
+// with an empty key shown as "(none)", followed by a table of the columns
+// with embedded commas, quotes or newlines, if any.
+func (summary *Summary) WriteSummary(writer io.Writer) error {
+	w := tabwriter.NewWriter(writer, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "%d rows\t%d licences\t\n", summary.Rows, summary.Licences)
+	for _, dimension := range []struct {
+		name   string
+		counts []SummaryCount
+	}{
+		{"Product code", summary.ByProductCode},
+		{"Company", summary.ByCompany},
+		{"Status", summary.ByStatus},
+		{"Station type", summary.ByStationType},
+		{"Band", summary.ByBand},
+	} {
+		fmt.Fprintf(w, "\n%s\tRows\tLicences\t\n", dimension.name)
+		for _, count := range dimension.counts {
+			key := count.Key
+			if key == "" {
+				key = "(none)"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t\n", key, count.Rows, count.Licences)
+		}
+	}
+	if summary.Quoting != nil && len(summary.Quoting.Columns) > 0 {
+		fmt.Fprintf(w, "\nQuoting\tCommas\tQuotes\tNewlines\t\n")
+		for _, column := range summary.Quoting.Columns {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", column.Column, column.Commas, column.Quotes, column.Newlines)
+		}
+	}
+	return errors.Wrap(w.Flush(), "could not write summary")
+}
@@ -0,0 +1,74 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Status,Station Type,Product Description 31,Frequency,Frequency Type
+0000001/1,Acme,Live,T,301010,7.5,GHz
+0000001/1,Acme,Live,R,301010,7.5,GHz
+0000002/1,"Other, Ltd",Live,T,305010,450,MHz
+0000003/1,Acme,Cancelled,T,301010,38,GHz
+0000004/1,,Live,,,,
+`)
+	summary := collection.Summary()
+	if summary.Rows != 5 || summary.Licences != 4 {
+		t.Errorf("%d rows, %d licences", summary.Rows, summary.Licences)
+	}
+	if got := summary.ByCompany[0]; got != (SummaryCount{"Acme", 3, 2}) {
+		t.Errorf("got company %v", got)
+	}
+	if got := summary.Quoting.Columns; len(got) != 1 || got[0] != (ColumnQuoting{"Licencee Company", 1, 0, 0, 1}) {
+		t.Errorf("got quoting %v", got)
+	}
+	if got := summary.ByStatus; len(got) != 2 || got[0] != (SummaryCount{"Live", 4, 3}) {
+		t.Errorf("got status %v", got)
+	}
+	want := []SummaryCount{{"SHF", 2, 1}, {"", 1, 1}, {"EHF", 1, 1}, {"UHF", 1, 1}}
+	if got := summary.ByBand; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Errorf("got bands %v, want %v", got, want)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := summary.WriteSummary(buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"5 rows", "Station type", "(none)", "301010"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("no %q in\n%s", s, buf)
+		}
+	}
+	const quoting = `
+Quoting           Commas  Quotes  Newlines
+Licencee Company  1       0       0
+`
+	var trimmed []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		trimmed = append(trimmed, strings.TrimRight(line, " "))
+	}
+	if !strings.HasSuffix(strings.Join(trimmed, "\n"), quoting) {
+		t.Errorf("no quoting table %q in\n%s", quoting, buf)
+	}
+}
+
+func TestKeyITUBand(t *testing.T) {
+	for _, test := range []struct {
+		frequency, frequencyType, band string
+	}{
+		{"30", "GHz", "SHF"}, // the upper limit is in the band
+		{"30.001", "GHz", "EHF"},
+		{"300", "MHz", "VHF"},
+		{"300.0125", "MHz", "UHF"},
+		{"3", "GHz", "UHF"},
+		{"7.5", "GHz", "SHF"},
+		{"", "", ""},
+	} {
+		row := &Row{Frequency: test.frequency, FrequencyType: test.frequencyType}
+		if band := KeyITUBand(row); band != test.band {
+			t.Errorf("%s %s: band %q, want %q", test.frequency, test.frequencyType, band, test.band)
+		}
+	}
+}
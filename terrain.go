@@ -0,0 +1,237 @@
+package wtrcsv
+
+import (
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Terrain is a source of ground elevations, eg. SRTMTiles. Other sources,
+// such as OS Terrain 50 on the National Grid, can be supplied by
+// implementing it.
+type Terrain interface {
+	// ElevationM returns the height of the ground above sea level in
+	// metres at a WGS84 location. ok is false if it is not known.
+	ElevationM(lat, lon float64) (metres float64, ok bool)
+}
+
+// SRTMTiles is a Terrain of SRTM tiles in the .hgt format, each one degree
+// square, added by AddHGT.
+type SRTMTiles struct {
+	tiles map[[2]int]*hgtTile
+}
+
+// hgtTile is the n by n elevations of a tile, from the north west corner
+// in rows, west to east.
+type hgtTile struct {
+	n        int
+	heights  []int16
+	lat, lon int // of the south west corner
+}
+
+// hgtVoid is the value of an unknown elevation.
+const hgtVoid = -32768
+
+// NewSRTMTiles returns an empty SRTMTiles.
+func NewSRTMTiles() *SRTMTiles {
+	return &SRTMTiles{tiles: make(map[[2]int]*hgtTile)}
+}
+
+// parseHGTName returns the south west corner of a tile from a name such as
+// "N51W001.hgt".
+func parseHGTName(name string) (lat, lon int, err error) {
+	base := strings.ToUpper(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+	if len(base) != 7 || (base[0] != 'N' && base[0] != 'S') || (base[3] != 'E' && base[3] != 'W') {
+		return 0, 0, errors.Errorf("invalid SRTM tile name %q", name)
+	}
+	lat, err = strconv.Atoi(base[1:3])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid SRTM tile name %q", name)
+	}
+	lon, err = strconv.Atoi(base[4:7])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid SRTM tile name %q", name)
+	}
+	if base[0] == 'S' {
+		lat = -lat
+	}
+	if base[3] == 'W' {
+		lon = -lon
+	}
+	return lat, lon, nil
+}
+
+// AddHGT reads a tile in the .hgt format, big endian 16 bit heights in
+// metres, of the name of its south west corner, eg. "N51W001.hgt". The
+// tile is square, eg. 1201 by 1201 heights for 3 arc second SRTM.
+func (tiles *SRTMTiles) AddHGT(name string, reader io.Reader) error {
+	lat, lon, err := parseHGTName(name)
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.Wrapf(err, "could not read SRTM tile %s", name)
+	}
+	n := int(math.Sqrt(float64(len(b) / 2)))
+	if n < 2 || 2*n*n != len(b) {
+		return errors.Errorf("SRTM tile %s is not square: %d bytes", name, len(b))
+	}
+	tile := &hgtTile{n: n, heights: make([]int16, n*n), lat: lat, lon: lon}
+	for i := range tile.heights {
+		tile.heights[i] = int16(binary.BigEndian.Uint16(b[2*i:]))
+	}
+	tiles.tiles[[2]int{lat, lon}] = tile
+	return nil
+}
+
+// ElevationM implements Terrain, interpolating bilinearly between the
+// heights of a tile. It is not known outside the tiles or next to a void.
+func (tiles *SRTMTiles) ElevationM(lat, lon float64) (float64, bool) {
+	tile, ok := tiles.tiles[[2]int{int(math.Floor(lat)), int(math.Floor(lon))}]
+	if !ok {
+		return 0, false
+	}
+	last := float64(tile.n - 1)
+	y := (float64(tile.lat+1) - lat) * last
+	x := (lon - float64(tile.lon)) * last
+	row, col := math.Min(math.Floor(y), last-1), math.Min(math.Floor(x), last-1)
+	fy, fx := y-row, x-col
+	i := int(row)*tile.n + int(col)
+	corners := [4]int16{tile.heights[i], tile.heights[i+1], tile.heights[i+tile.n], tile.heights[i+tile.n+1]}
+	for _, h := range corners {
+		if h == hgtVoid {
+			return 0, false
+		}
+	}
+	north := float64(corners[0])*(1-fx) + float64(corners[1])*fx
+	south := float64(corners[2])*(1-fx) + float64(corners[3])*fx
+	return north*(1-fy) + south*fy, true
+}
+
+// KFactor is the ratio of the effective radius of the earth to its radius
+// for the refraction of radio waves, 4/3 in a standard atmosphere.
+var KFactor = 4.0 / 3
+
+// ProfilePoint is a point of a PathProfile.
+type ProfilePoint struct {
+	DistanceKm float64 // from the A end
+	Lat, Lon   float64
+	GroundM    float64 // the height of the ground above sea level
+	// BulgeM is the height of the earth, with KFactor, above the chord
+	// between the ends.
+	BulgeM float64
+	// ClearanceM is the height of the line of sight between the antennas
+	// above the ground and the bulge, negative where it is obstructed.
+	ClearanceM float64
+}
+
+// PathProfile is the terrain along a link between its antennas.
+type PathProfile struct {
+	Link     *Link
+	LengthKm float64
+	// AntennaA and AntennaB are the heights of the antennas above sea
+	// level: the height above sea level of the row, or else the ground of
+	// the terrain, plus the antenna height.
+	AntennaA, AntennaB float64
+	Points             []ProfilePoint // from the A end to the B end
+}
+
+// PathProfile returns the profile of the link over the terrain at samples
+// points, at least 2, equally spaced along the great circle between its
+// ends, which must be located. It is an error if the terrain is not known
+// at a point.
+func (link *Link) PathProfile(terrain Terrain, samples int) (*PathProfile, error) {
+	if samples < 2 {
+		return nil, errors.Errorf("invalid number of samples %d", samples)
+	}
+	latA, lonA, okA := rowLatLon(link.A)
+	latB, lonB, okB := rowLatLon(link.B)
+	if !okA || !okB {
+		return nil, errors.Errorf("link %s is not located", link.LicenceNumber())
+	}
+	profile := &PathProfile{Link: link, LengthKm: distanceKm(latA, lonA, latB, lonB), Points: make([]ProfilePoint, samples)}
+	for i := range profile.Points {
+		fraction := float64(i) / float64(samples-1)
+		lat, lon := intermediatePoint(latA, lonA, latB, lonB, fraction)
+		ground, ok := terrain.ElevationM(lat, lon)
+		if !ok {
+			return nil, errors.Errorf("no elevation at %.5f, %.5f", lat, lon)
+		}
+		d1, d2 := fraction*profile.LengthKm, (1-fraction)*profile.LengthKm
+		profile.Points[i] = ProfilePoint{
+			DistanceKm: d1,
+			Lat:        lat,
+			Lon:        lon,
+			GroundM:    ground,
+			BulgeM:     d1 * d2 * 1000 / (2 * KFactor * earthRadiusKm),
+		}
+	}
+	profile.AntennaA = antennaAboveSeaLevel(link.A, profile.Points[0].GroundM)
+	profile.AntennaB = antennaAboveSeaLevel(link.B, profile.Points[samples-1].GroundM)
+	for i := range profile.Points {
+		point := &profile.Points[i]
+		fraction := float64(i) / float64(samples-1)
+		ray := profile.AntennaA + fraction*(profile.AntennaB-profile.AntennaA)
+		point.ClearanceM = ray - point.GroundM - point.BulgeM
+	}
+	return profile, nil
+}
+
+// antennaAboveSeaLevel returns the height of the antenna of a row above sea
+// level, from its height above sea level if known, otherwise from the
+// ground of the terrain.
+func antennaAboveSeaLevel(row *Row, ground float64) float64 {
+	if h, ok := parseNumber(row.HeightAboveSeaLevel); ok {
+		ground = h
+	}
+	height, _ := parseNumber(row.AntennaHeight)
+	return ground + height
+}
+
+// intermediatePoint returns the point a fraction of the way along the great
+// circle from point 1 to point 2.
+func intermediatePoint(lat1, lon1, lat2, lon2, fraction float64) (lat, lon float64) {
+	phi1, lambda1, phi2, lambda2 := radians(lat1), radians(lon1), radians(lat2), radians(lon2)
+	delta := distanceKm(lat1, lon1, lat2, lon2) / earthRadiusKm
+	if delta == 0 {
+		return lat1, lon1
+	}
+	a := math.Sin((1-fraction)*delta) / math.Sin(delta)
+	b := math.Sin(fraction*delta) / math.Sin(delta)
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+	return degrees(math.Atan2(z, math.Hypot(x, y))), degrees(math.Atan2(y, x))
+}
+
+// LineOfSight is the result of PathProfile.LineOfSight.
+type LineOfSight struct {
+	Clear bool // whether no point between the ends is obstructed
+	// MinClearanceM is the least clearance of the points between the ends,
+	// at MinClearanceKm from the A end, +Inf if there are none.
+	MinClearanceM  float64
+	MinClearanceKm float64
+	Obstructed     int // the number of points obstructed
+}
+
+// LineOfSight checks the line of sight between the antennas over the
+// points of the profile between the ends.
+func (profile *PathProfile) LineOfSight() LineOfSight {
+	los := LineOfSight{Clear: true, MinClearanceM: math.Inf(1)}
+	for _, point := range profile.Points[1 : len(profile.Points)-1] {
+		if point.ClearanceM < los.MinClearanceM {
+			los.MinClearanceM, los.MinClearanceKm = point.ClearanceM, point.DistanceKm
+		}
+		if point.ClearanceM < 0 {
+			los.Clear = false
+			los.Obstructed++
+		}
+	}
+	return los
+}
@@ -0,0 +1,100 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// testHGT returns a tile of 101 by 101 heights of height(row, col).
+func testHGT(height func(row, col int) int16) *bytes.Reader {
+	var b bytes.Buffer
+	for row := 0; row <= 100; row++ {
+		for col := 0; col <= 100; col++ {
+			binary.Write(&b, binary.BigEndian, height(row, col))
+		}
+	}
+	return bytes.NewReader(b.Bytes())
+}
+
+func TestSRTMTiles(t *testing.T) {
+	tiles := NewSRTMTiles()
+	err := tiles.AddHGT("tiles/n51w001.hgt", testHGT(func(row, col int) int16 {
+		if row == 100 && col == 100 {
+			return hgtVoid
+		}
+		return int16(col)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		lat, lon float64
+		want     float64
+		ok       bool
+	}{
+		{51, -1, 0, true},
+		{51.5, -0.5, 50, true},
+		{51.5, -0.505, 49.5, true},
+		{51.001, -0.001, 0, false}, // next to the void
+		{50.5, -0.5, 0, false},
+	} {
+		got, ok := tiles.ElevationM(test.lat, test.lon)
+		if ok != test.ok || math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("%v, %v: %v %v, want %v %v", test.lat, test.lon, got, ok, test.want, test.ok)
+		}
+	}
+
+	if err := tiles.AddHGT("X51W001.hgt", testHGT(func(int, int) int16 { return 0 })); err == nil {
+		t.Error("added tile of invalid name")
+	}
+	if err := tiles.AddHGT("N51W002.hgt", bytes.NewReader(make([]byte, 10))); err == nil {
+		t.Error("added tile that is not square")
+	}
+}
+
+func TestPathProfile(t *testing.T) {
+	const csv = `Licence Number,NGR,Antenna Height,WGS84 Longitude,WGS84 Latitude
+0000001/1,TQ 00000 00000,30,0.0,51.5
+0000001/1,TQ 10000 00000,30,0.1,51.5
+`
+	link := testCollection(t, csv).Links().Links[0]
+	for _, test := range []struct {
+		ridge int16
+		clear bool
+	}{
+		{10, true},
+		{200, false},
+	} {
+		tiles := NewSRTMTiles()
+		tiles.AddHGT("N51E000.hgt", testHGT(func(row, col int) int16 {
+			if col == 5 {
+				return test.ridge
+			}
+			return 10
+		}))
+		profile, err := link.PathProfile(tiles, 21)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profile.AntennaA != 40 || profile.AntennaB != 40 || len(profile.Points) != 21 {
+			t.Fatalf("profile %+v", profile)
+		}
+		middle := profile.Points[10]
+		if math.Abs(middle.DistanceKm-profile.LengthKm/2) > 1e-9 || math.Abs(middle.Lon-0.05) > 1e-9 || middle.GroundM != float64(test.ridge) {
+			t.Fatalf("middle point %+v", middle)
+		}
+		los := profile.LineOfSight()
+		if los.Clear != test.clear || (los.Obstructed == 0) != test.clear {
+			t.Errorf("ridge of %vm: %+v", test.ridge, los)
+		}
+		if test.clear && math.Abs(los.MinClearanceM-(30-middle.BulgeM)) > 1e-6 {
+			t.Errorf("least clearance %v, bulge %v", los.MinClearanceM, middle.BulgeM)
+		}
+	}
+
+	if _, err := link.PathProfile(NewSRTMTiles(), 21); err == nil {
+		t.Error("profile without terrain")
+	}
+}
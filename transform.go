@@ -0,0 +1,35 @@
+package wtrcsv
+
+// TransformFn rewrites a Row, returning the rewritten Row or nil to drop it.
+type TransformFn func(row *Row) *Row
+
+// Transform returns a Collection of the rows rewritten by fn, in order and
+// with the same header. fn is given a copy of each row, so it may modify
+// and return it without changing the collection. Rows for which fn returns
+// nil are dropped.
+func (collection *Collection) Transform(fn TransformFn) *Collection {
+	rows := make([]*Row, 0, len(collection.Rows))
+	for _, row := range collection.Rows {
+		copied := *row
+		if transformed := fn(&copied); transformed != nil {
+			rows = append(rows, transformed)
+		}
+	}
+	return collection.withRows(rows)
+}
+
+// TransformInPlace is as Transform but rewrites the collection itself: fn
+// is given the rows themselves and the rows of the collection are replaced
+// by the results. Any index of the collection must be rebuilt afterwards.
+func (collection *Collection) TransformInPlace(fn TransformFn) {
+	rows := collection.Rows[:0]
+	for _, row := range collection.Rows {
+		if transformed := fn(row); transformed != nil {
+			rows = append(rows, transformed)
+		}
+	}
+	for i := len(rows); i < len(collection.Rows); i++ {
+		collection.Rows[i] = nil
+	}
+	collection.Rows = rows
+}
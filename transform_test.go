@@ -0,0 +1,41 @@
+package wtrcsv
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Antenna Height
+0000001/1,acme ltd,12.4
+0000002/1,Other,30.6
+0000003/1,acme ltd,
+`)
+	upper := func(row *Row) *Row {
+		row.LicenseeCompany = strings.ToUpper(row.LicenseeCompany)
+		return row
+	}
+	transformed := collection.Transform(upper)
+	if transformed.Rows[0].LicenseeCompany != "ACME LTD" || collection.Rows[0].LicenseeCompany != "acme ltd" {
+		t.Errorf("got %q from %q", transformed.Rows[0].LicenseeCompany, collection.Rows[0].LicenseeCompany)
+	}
+	if len(transformed.Header) != len(collection.Header) || len(transformed.Rows) != 3 {
+		t.Errorf("wrong collection %v", transformed)
+	}
+
+	collection.TransformInPlace(func(row *Row) *Row {
+		if row.AntennaHeight == "" {
+			return nil
+		}
+		row.AntennaHeight = strconv.FormatFloat(math.Round(row.AntennaHeightAsFloat()), 'f', -1, 64)
+		return row
+	})
+	if got := licenceNumbers(collection); got != "0000001/1 0000002/1" {
+		t.Fatalf("got %s", got)
+	}
+	if collection.Rows[0].AntennaHeight != "12" || collection.Rows[1].AntennaHeight != "31" {
+		t.Errorf("heights %s %s not rounded", collection.Rows[0].AntennaHeight, collection.Rows[1].AntennaHeight)
+	}
+}
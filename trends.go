@@ -0,0 +1,166 @@
+package wtrcsv
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TrendDimension is a dimension of Trends: a name and the key of a row.
+type TrendDimension struct {
+	Name string
+	Key  KeyFn
+}
+
+var (
+	// TrendByCompany counts licences per licensee company.
+	TrendByCompany = TrendDimension{"company", KeyCompany}
+	// TrendByProductCode counts licences per numerical product code.
+	TrendByProductCode = TrendDimension{"product_code", KeyProductCode}
+)
+
+// TrendPoint is the count of a key of a dimension in a snapshot.
+type TrendPoint struct {
+	Date      time.Time `json:"date"`
+	Dimension string    `json:"dimension"`
+	Key       string    `json:"key"`
+	Licences  int       `json:"licences"` // distinct licence numbers
+	Rows      int       `json:"rows"`
+}
+
+// Trends is a tidy time series: a TrendPoint for every date and every key
+// of each dimension seen on any date (zero where it is absent), ordered by
+// dimension, key and date.
+type Trends []TrendPoint
+
+// trendCounts accumulates the counts of the keys of the dimensions.
+type trendCounts struct {
+	dimensions []TrendDimension
+	dates      []time.Time
+	// counts[dimension][key][date index]
+	counts []map[string][]TrendPoint
+}
+
+func newTrendCounts(dimensions []TrendDimension) *trendCounts {
+	counts := &trendCounts{dimensions: dimensions, counts: make([]map[string][]TrendPoint, len(dimensions))}
+	for i := range counts.counts {
+		counts.counts[i] = make(map[string][]TrendPoint)
+	}
+	return counts
+}
+
+func (counts *trendCounts) add(date time.Time, collection *Collection) {
+	n := len(counts.dates)
+	counts.dates = append(counts.dates, date)
+	for i, dimension := range counts.dimensions {
+		licences := make(map[string]map[string]bool)
+		for _, row := range collection.Rows {
+			key := dimension.Key(row)
+			points := counts.counts[i][key]
+			for len(points) <= n {
+				points = append(points, TrendPoint{})
+			}
+			points[n].Rows++
+			counts.counts[i][key] = points
+			if licences[key] == nil {
+				licences[key] = make(map[string]bool)
+			}
+			licences[key][row.LicenceNumber] = true
+		}
+		for key, numbers := range licences {
+			counts.counts[i][key][n].Licences = len(numbers)
+		}
+	}
+}
+
+func (counts *trendCounts) trends() Trends {
+	var trends Trends
+	for i, dimension := range counts.dimensions {
+		keys := make([]string, 0, len(counts.counts[i]))
+		for key := range counts.counts[i] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			points := counts.counts[i][key]
+			for j, date := range counts.dates {
+				var point TrendPoint
+				if j < len(points) {
+					point = points[j]
+				}
+				point.Date, point.Dimension, point.Key = date, dimension.Name, key
+				trends = append(trends, point)
+			}
+		}
+	}
+	return trends
+}
+
+// ComputeTrends counts the licences of each key of the dimensions in
+// snapshots of the register, given in date order.
+func ComputeTrends(dates []time.Time, collections []*Collection, dimensions ...TrendDimension) (Trends, error) {
+	if len(dates) != len(collections) {
+		return nil, errors.Errorf("%d dates for %d collections", len(dates), len(collections))
+	}
+	counts := newTrendCounts(dimensions)
+	for i, collection := range collections {
+		counts.add(dates[i], collection)
+	}
+	return counts.trends(), nil
+}
+
+// Trends counts the licences of each key of the dimensions in every
+// snapshot of the store, reading one snapshot at a time.
+func (store *SnapshotStore) Trends(dimensions ...TrendDimension) (Trends, error) {
+	dates, err := store.Dates()
+	if err != nil {
+		return nil, err
+	}
+	counts := newTrendCounts(dimensions)
+	for _, date := range dates {
+		collection, err := store.Load(date)
+		if err != nil {
+			return nil, err
+		}
+		counts.add(date, collection)
+	}
+	return counts.trends(), nil
+}
+
+// trendsHeader is the header of WriteTrendsCSV.
+var trendsHeader = []string{"date", "dimension", "key", "licences", "rows"}
+
+// WriteTrendsCSV writes the trends as csv, one record per point, with the
+// dates as YYYY-MM-DD.
+func (trends Trends) WriteTrendsCSV(writer io.Writer) error {
+	w := newCSVRecordWriter(writer, nil)
+	if err := w.write(trendsHeader); err != nil {
+		return errors.Wrap(err, "could not write trends header")
+	}
+	for _, point := range trends {
+		record := []string{
+			point.Date.Format(dateLayout), point.Dimension, point.Key,
+			strconv.Itoa(point.Licences), strconv.Itoa(point.Rows),
+		}
+		if err := w.write(record); err != nil {
+			return errors.Wrap(err, "could not write trends")
+		}
+	}
+	return errors.Wrap(w.flush(), "could not write trends")
+}
+
+// WriteTrendsJSON writes the trends as a JSON array of points.
+func (trends Trends) WriteTrendsJSON(writer io.Writer) error {
+	if trends == nil {
+		trends = Trends{}
+	}
+	b, err := json.Marshal(trends)
+	if err != nil {
+		return errors.Wrap(err, "could not encode trends")
+	}
+	_, err = writer.Write(append(b, '\n'))
+	return errors.Wrap(err, "could not write trends")
+}
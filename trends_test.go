@@ -0,0 +1,79 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTrends(t *testing.T) {
+	const header = "Licence Number,Licencee Company,Product Description 31\n"
+	first := testCollection(t, header+`0000001/1,Acme,301010
+0000001/1,Acme,301010
+0000002/1,Other,301010
+`)
+	second := testCollection(t, header+`0000001/1,Acme,301010
+0000003/1,Acme,305010
+`)
+	dates := []time.Time{time.Date(2018, 2, 7, 0, 0, 0, 0, time.UTC), time.Date(2018, 2, 14, 0, 0, 0, 0, time.UTC)}
+	trends, err := ComputeTrends(dates, []*Collection{first, second}, TrendByCompany, TrendByProductCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := trends.WriteTrendsCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	const want = `date,dimension,key,licences,rows
+2018-02-07,company,Acme,1,2
+2018-02-14,company,Acme,2,2
+2018-02-07,company,Other,1,1
+2018-02-14,company,Other,0,0
+2018-02-07,product_code,301010,2,3
+2018-02-14,product_code,301010,1,1
+2018-02-07,product_code,305010,0,0
+2018-02-14,product_code,305010,1,1
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf, want)
+	}
+
+	buf.Reset()
+	if err := trends.WriteTrendsJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	var points []TrendPoint
+	if err := json.Unmarshal(buf.Bytes(), &points); err != nil || len(points) != 8 || points[1] != trends[1] {
+		t.Errorf("bad json (%v):\n%s", err, buf)
+	}
+
+	if _, err := ComputeTrends(dates[:1], []*Collection{first, second}); err == nil {
+		t.Error("expected error for mismatched dates")
+	}
+}
+
+func TestSnapshotStoreTrends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wtrcsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := NewSnapshotStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range []string{"0000001/1,a\n0000002/1,b\n", "0000001/1,a\n"} {
+		collection := testCollection(t, "Licence Number,Licencee Company\n"+text)
+		if err := store.SaveCollection(time.Date(2018, 2, 7+i, 0, 0, 0, 0, time.UTC), collection); err != nil {
+			t.Fatal(err)
+		}
+	}
+	trends, err := store.Trends(TrendByCompany)
+	if err != nil || len(trends) != 4 || trends[1].Licences != 1 || trends[3].Key != "b" || trends[3].Licences != 0 {
+		t.Errorf("trends %v: %v", trends, err)
+	}
+}
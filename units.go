@@ -0,0 +1,145 @@
+package wtrcsv
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// frequencyMHz returns the frequency of a Row in MHz, as the MHz of the
+// exact FrequencyHz.
+func frequencyMHz(row *Row) (float64, bool) {
+	f, ok := row.FrequencyHz()
+	return f.MHz(), ok
+}
+
+// FrequencyMHz returns the frequency of the Row in MHz, combining the
+// Frequency and Frequency Type columns. ok is false if either is unknown.
+func (row *Row) FrequencyMHz() (mhz float64, ok bool) {
+	return frequencyMHz(row)
+}
+
+// DisplayFrequency returns the frequency of the Row formatted by
+// FormatFrequency, or the Frequency and Frequency Type columns as they are
+// if they cannot be converted.
+func (row *Row) DisplayFrequency() string {
+	if f, ok := row.FrequencyHz(); ok {
+		return f.String()
+	}
+	return strings.TrimSpace(strings.TrimSpace(row.Frequency) + " " + strings.TrimSpace(row.FrequencyType))
+}
+
+// displayUnits are the units of FormatFrequency, largest first.
+var displayUnits = []struct {
+	name string
+	hz   float64
+}{
+	{"GHz", 1e9},
+	{"MHz", 1e6},
+	{"kHz", 1e3},
+	{"Hz", 1},
+}
+
+// FormatFrequency formats a frequency in Hz in the largest unit in which it
+// is at least 1, to the nearest Hz and without trailing zeros, eg.
+// "7.4565 GHz" or "458.5 MHz". It is used wherever the package and its
+// commands display a frequency.
+func FormatFrequency(hz float64) string {
+	if math.IsNaN(hz) || math.IsInf(hz, 0) || math.Abs(hz) >= math.MaxInt64 {
+		return strconv.FormatFloat(hz, 'f', -1, 64)
+	}
+	return Frequency(math.Round(hz)).String()
+}
+
+// channelWidthMHz returns the channel width of a Row in MHz.
+func channelWidthMHz(row *Row) (float64, bool) {
+	w, ok := row.ChannelWidthHz()
+	return w.MHz(), ok
+}
+
+// erpDBW returns the ERP of a Row in dBW.
+func erpDBW(row *Row) (float64, bool) {
+	v, unit, ok := rowERP(row)
+	if !ok {
+		return 0, false
+	}
+	switch unit {
+	case "dbw":
+		return v, true
+	case "dbm":
+		return v - 30, true
+	}
+	return wattsToDBW(v * erpWattUnits[unit])
+}
+
+// erpWatts returns the ERP of a Row in W.
+func erpWatts(row *Row) (float64, bool) {
+	v, unit, ok := rowERP(row)
+	if !ok {
+		return 0, false
+	}
+	switch unit {
+	case "dbw":
+		return math.Pow(10, v/10), true
+	case "dbm":
+		return math.Pow(10, (v-30)/10), true
+	}
+	if v < 0 {
+		return 0, false
+	}
+	return v * erpWattUnits[unit], true
+}
+
+// erpWattUnits are the multipliers to W of the linear ERP types.
+var erpWattUnits = map[string]float64{"w": 1, "mw": 1e-3, "kw": 1e3}
+
+// rowERP returns the Antenna ERP of a Row and its lower case Antenna ERP
+// type, which is dBW, dBm or one of erpWattUnits.
+func rowERP(row *Row) (v float64, unit string, ok bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(row.AntennaErp), 64)
+	if err != nil {
+		return 0, "", false
+	}
+	unit = strings.ToLower(strings.TrimSpace(row.AntennaErpType))
+	if _, ok := erpWattUnits[unit]; !ok && unit != "dbw" && unit != "dbm" {
+		return 0, "", false
+	}
+	return v, unit, true
+}
+
+func wattsToDBW(watts float64) (float64, bool) {
+	if watts <= 0 {
+		return 0, false
+	}
+	return 10 * math.Log10(watts), true
+}
+
+// ERPdBW returns the ERP of the Row in dBW, converting from the Antenna ERP
+// type (dBW, dBm, W, mW or kW). ok is false if the ERP or its type is
+// unknown, or is a power of zero watts.
+func (row *Row) ERPdBW() (dbw float64, ok bool) {
+	return erpDBW(row)
+}
+
+// ERPWatts returns the ERP of the Row in W, converting from the Antenna ERP
+// type as ERPdBW.
+func (row *Row) ERPWatts() (watts float64, ok bool) {
+	return erpWatts(row)
+}
+
+// FilterERPAbove returns a FilterFn keeping the rows with an ERP above dbw.
+func FilterERPAbove(dbw float64) FilterFn {
+	return func(row *Row) bool {
+		erp, ok := erpDBW(row)
+		return ok && erp > dbw
+	}
+}
+
+// azimuthDeg returns the antenna azimuth of a Row in degrees.
+func azimuthDeg(row *Row) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(row.AntennaAzimuth), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
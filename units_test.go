@@ -0,0 +1,82 @@
+package wtrcsv
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatFrequency(t *testing.T) {
+	for hz, want := range map[float64]string{
+		7456500000:  "7.4565 GHz",
+		458500000:   "458.5 MHz",
+		458500001:   "458.500001 MHz",
+		12500:       "12.5 kHz",
+		50:          "50 Hz",
+		0:           "0 Hz",
+		-2.5e6:      "-2.5 MHz",
+		0.4:         "0 Hz",
+		math.Inf(1): "+Inf",
+		18e9:        "18 GHz",
+	} {
+		if got := FormatFrequency(hz); got != want {
+			t.Errorf("%v: got %q, want %q", hz, got, want)
+		}
+	}
+}
+
+func TestDisplayFrequency(t *testing.T) {
+	for _, test := range []struct {
+		row  Row
+		want string
+	}{
+		{Row{Frequency: "7456.5", FrequencyType: "MHz"}, "7.4565 GHz"},
+		{Row{Frequency: "0.4585", FrequencyType: "GHz"}, "458.5 MHz"},
+		{Row{Frequency: "7.5", FrequencyType: "furlongs"}, "7.5 furlongs"},
+		{Row{}, ""},
+	} {
+		if got := test.row.DisplayFrequency(); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.row, got, test.want)
+		}
+	}
+}
+
+func TestERP(t *testing.T) {
+	for _, test := range []struct {
+		erp, unit string
+		dbw, w    float64
+		ok        bool
+	}{
+		{"10", "dBW", 10, 10, true},
+		{"40", "dBm", 10, 10, true},
+		{"100", "W", 20, 100, true},
+		{"500", "mW", -3.0103, 0.5, true},
+		{"2", "kW", 33.0103, 2000, true},
+		{"-3", "dBW", -3, 0.501187, true},
+		{"10", "furlongs", 0, 0, false},
+		{"", "dBW", 0, 0, false},
+	} {
+		row := Row{AntennaErp: test.erp, AntennaErpType: test.unit}
+		dbw, ok := row.ERPdBW()
+		w, wOK := row.ERPWatts()
+		if ok != test.ok || wOK != test.ok || math.Abs(dbw-test.dbw) > 1e-4 || math.Abs(w-test.w) > 1e-4 {
+			t.Errorf("%s %s: got %v dBW, %v W (%v), want %v dBW, %v W", test.erp, test.unit, dbw, w, ok, test.dbw, test.w)
+		}
+	}
+	zero := Row{AntennaErp: "0", AntennaErpType: "W"}
+	if _, ok := zero.ERPdBW(); ok {
+		t.Error("0 W converted to dBW")
+	}
+	if w, ok := zero.ERPWatts(); !ok || w != 0 {
+		t.Errorf("got %v W", w)
+	}
+
+	collection := testCollection(t, `Licence Number,Antenna ERP,Antenna ERP type
+0000001/1,10,dBW
+0000002/1,50,dBm
+0000003/1,5,W
+0000004/1,,
+`)
+	if got := licenceNumbers(collection.Filter(FilterERPAbove(7))); got != "0000001/1 0000002/1" {
+		t.Errorf("got %s", got)
+	}
+}
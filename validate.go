@@ -0,0 +1,196 @@
+package wtrcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// ValidationRule is a check of the values of a Row. Check returns nil if
+// the row passes, otherwise an error describing the problem.
+type ValidationRule struct {
+	Name        string
+	Description string
+	Check       func(row *Row) error
+}
+
+// UK bounds of the coordinates of a station, including the Channel Islands
+// and Northern Ireland, in degrees.
+const (
+	ukMinLatitude  = 49.0
+	ukMaxLatitude  = 61.0
+	ukMinLongitude = -8.7
+	ukMaxLongitude = 2.0
+)
+
+// Sane limits of the ERP of a station, in dBW.
+const (
+	minERPdBW = -50.0
+	maxERPdBW = 90.0
+)
+
+var creLicenceNumber = regexp.MustCompile("^(ES)?[0-9]{7}/[0-9]")
+
+// ValidationRules are the rules used by Validate when none are given. Rules
+// of values that may be empty pass an empty value. See
+// RegisterValidationRule.
+var ValidationRules = []ValidationRule{
+	{"licence-number", "the licence number is seven digits, a slash and the issue", checkLicenceNumber},
+	{"product-code", "the numerical product code (Product Description 31) is six digits", checkProductCode},
+	{"ngr", "the NGR is a valid National Grid Reference", checkNGR},
+	{"emission-code", "the emission code is a known emission designator", checkEmissionCode},
+	{"uk-coordinates", "the coordinates are within the UK", checkUKCoordinates},
+	{"erp", "the ERP is from -50 to 90 dBW", checkERP},
+}
+
+// RegisterValidationRule adds a rule to ValidationRules. It is an error if a
+// rule of the same name is registered.
+func RegisterValidationRule(rule ValidationRule) error {
+	if rule.Name == "" || rule.Check == nil {
+		return errors.New("validation rule needs a name and a check")
+	}
+	for _, r := range ValidationRules {
+		if r.Name == rule.Name {
+			return errors.Errorf("validation rule %q already registered", rule.Name)
+		}
+	}
+	ValidationRules = append(ValidationRules, rule)
+	return nil
+}
+
+func checkLicenceNumber(row *Row) error {
+	if !creLicenceNumber.MatchString(row.LicenceNumber) {
+		return errors.Errorf("licence number %q", row.LicenceNumber)
+	}
+	return nil
+}
+
+func checkProductCode(row *Row) error {
+	if len(row.ProductDescription31) != 6 || !isDigits(row.ProductDescription31) {
+		return errors.Errorf("product code %q", row.ProductDescription31)
+	}
+	return nil
+}
+
+func checkNGR(row *Row) error {
+	if row.NGR == "" {
+		return nil
+	}
+	if _, _, ok := parseNGR(row.NGR); !ok {
+		return errors.Errorf("NGR %q", row.NGR)
+	}
+	return nil
+}
+
+func checkEmissionCode(row *Row) error {
+	if row.EmissionCode == "" {
+		return nil
+	}
+	_, err := ParseEmission(row.EmissionCode)
+	return err
+}
+
+func checkUKCoordinates(row *Row) error {
+	lat, lon, ok := rowLatLon(row)
+	if !ok {
+		return nil
+	}
+	if lat < ukMinLatitude || lat > ukMaxLatitude || lon < ukMinLongitude || lon > ukMaxLongitude {
+		return errors.Errorf("coordinates %.5f, %.5f outside the UK", lat, lon)
+	}
+	return nil
+}
+
+func checkERP(row *Row) error {
+	if row.AntennaErp == "" {
+		return nil
+	}
+	dbw, ok := row.ERPdBW()
+	if !ok {
+		if _, err := strconv.ParseFloat(row.AntennaErp, 64); err != nil {
+			return errors.Errorf("ERP %q is not a number", row.AntennaErp)
+		}
+		return nil // zero watts or an unknown type
+	}
+	if dbw < minERPdBW || dbw > maxERPdBW {
+		return errors.Errorf("ERP %s %s is %.1f dBW", row.AntennaErp, row.AntennaErpType, dbw)
+	}
+	return nil
+}
+
+// ValidationIssue is a row failing a rule.
+type ValidationIssue struct {
+	Rule          string `json:"rule"`
+	Row           int    `json:"row"` // the index of the row in the collection
+	LicenceNumber string `json:"licence_number"`
+	Message       string `json:"message"`
+}
+
+// ValidationCount is the number of rows failing a rule.
+type ValidationCount struct {
+	Rule   string `json:"rule"`
+	Failed int    `json:"failed"`
+}
+
+// ValidationReport is the result of Validate: the number of rows failing
+// any rule and each rule, in order of the rules, and the first maxWarnings
+// issues of each rule.
+type ValidationReport struct {
+	Rows   int               `json:"rows"`
+	Failed int               `json:"failed"`
+	Counts []ValidationCount `json:"counts"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// OK reports whether every row passed every rule.
+func (report *ValidationReport) OK() bool {
+	return report.Failed == 0
+}
+
+// WriteValidationJSON writes the report as a JSON object.
+func (report *ValidationReport) WriteValidationJSON(writer io.Writer) error {
+	b, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "could not encode validation report")
+	}
+	_, err = writer.Write(append(b, '\n'))
+	return errors.Wrap(err, "could not write validation report")
+}
+
+// String summarises the report, eg. "2 of 100 rows failed validation".
+func (report *ValidationReport) String() string {
+	return fmt.Sprintf("%d of %d rows failed validation", report.Failed, report.Rows)
+}
+
+// Validate checks every row against the rules, or ValidationRules if none
+// are given.
+func (collection *Collection) Validate(rules ...ValidationRule) *ValidationReport {
+	if len(rules) == 0 {
+		rules = ValidationRules
+	}
+	report := &ValidationReport{Rows: len(collection.Rows), Counts: make([]ValidationCount, len(rules)), Issues: []ValidationIssue{}}
+	for j, rule := range rules {
+		report.Counts[j].Rule = rule.Name
+	}
+	for i, row := range collection.Rows {
+		failed := false
+		for j, rule := range rules {
+			err := rule.Check(row)
+			if err == nil {
+				continue
+			}
+			failed = true
+			report.Counts[j].Failed++
+			if report.Counts[j].Failed <= maxWarnings {
+				report.Issues = append(report.Issues, ValidationIssue{rule.Name, i, row.LicenceNumber, err.Error()})
+			}
+		}
+		if failed {
+			report.Failed++
+		}
+	}
+	return report
+}
@@ -0,0 +1,89 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Product Description 31,NGR,Emission Code,SID_LAT_N_S,SID_LAT_DEG,SID_LAT_MIN,SID_LAT_SEC,SID_LONG_E_W,SID_LONG_DEG,SID_LONG_MIN,SID_LONG_SEC,Antenna ERP,Antenna ERP type
+0000001/1,301010,TQ 29400 81900,28M0G7W,N,51,30,0,W,0,7,0,30,dBW
+0000002/1,30101,TQ 2940 8190,28M0G7W,N,40,0,0,W,0,7,0,30,dBW
+ABC,301010,XX 29400 81900,28M0Z7W,,,,,,,,,200,dBW
+0000004/1,301010,,,,,,,,,,,x,W
+`)
+	report := collection.Validate()
+	if report.Rows != 4 || report.OK() || report.String() != "3 of 4 rows failed validation" {
+		t.Fatalf("wrong report %+v", report)
+	}
+	var counts []string
+	for _, count := range report.Counts {
+		counts = append(counts, fmt.Sprintf("%s=%d", count.Rule, count.Failed))
+	}
+	if got := strings.Join(counts, " "); got != "licence-number=1 product-code=1 ngr=1 emission-code=1 uk-coordinates=1 erp=2" {
+		t.Errorf("got counts %s", got)
+	}
+	if issue := report.Issues[0]; issue.Rule != "product-code" || issue.Row != 1 || issue.LicenceNumber != "0000002/1" {
+		t.Errorf("wrong first issue %+v", issue)
+	}
+
+	if !(&Collection{Rows: collection.Rows[:1]}).Validate().OK() {
+		t.Error("valid row failed")
+	}
+}
+
+func TestRegisterValidationRule(t *testing.T) {
+	defer func(rules []ValidationRule) { ValidationRules = rules }(ValidationRules)
+	rule := ValidationRule{Name: "company", Check: func(row *Row) error {
+		if row.LicenseeCompany == "" {
+			return errors.New("no company")
+		}
+		return nil
+	}}
+	if err := RegisterValidationRule(rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterValidationRule(rule); err == nil {
+		t.Error("no error registering a rule twice")
+	}
+	report := (&Collection{Rows: []*Row{{LicenceNumber: "0000001/1", ProductDescription31: "301010"}}}).Validate()
+	if len(report.Issues) != 1 || report.Issues[0].Rule != "company" || report.Issues[0].Message != "no company" {
+		t.Errorf("wrong issues %+v", report.Issues)
+	}
+}
+
+func TestCheckProductCode(t *testing.T) {
+	for _, test := range []struct {
+		code string
+		ok   bool
+	}{
+		{"301010", true},
+		{"000000", true},
+		{"30101", false},
+		{"3010100", false},
+		{"30101X", false},
+		{"", false},
+	} {
+		if err := checkProductCode(&Row{ProductDescription31: test.code}); (err == nil) != test.ok {
+			t.Errorf("%q: %v", test.code, err)
+		}
+	}
+}
+
+func TestCheckLicenceNumber(t *testing.T) {
+	for _, test := range []struct {
+		licenceNumber string
+		ok            bool
+	}{
+		{"0000001/1", true},
+		{"ES0000001/2", true},
+		{"000001/1", false},
+		{"ABC", false},
+	} {
+		if err := checkLicenceNumber(&Row{LicenceNumber: test.licenceNumber}); (err == nil) != test.ok {
+			t.Errorf("%q: %v", test.licenceNumber, err)
+		}
+	}
+}
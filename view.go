@@ -0,0 +1,115 @@
+package wtrcsv
+
+// View is a read-only view over the rows of a Collection. Rows are only ever
+// handed out as copies, so code holding a View cannot mutate Rows shared
+// with the Collection (or with other Views of it). Use Materialize to obtain
+// a mutable Collection.
+//
+// FilterView and GroupByView are the query entry points returning Views.
+// Filter, GroupBy and the other query methods of Collection still return a
+// *Collection sharing the Row pointers of the original, as changing their
+// results would break every existing caller.
+type View struct {
+	header []string
+	rows   []*Row
+}
+
+// View returns a read-only View of the collection. Later changes to the
+// collection's Rows slice (eg. FilterInPlace) do not affect the View.
+func (collection *Collection) View() *View {
+	rows := make([]*Row, len(collection.Rows))
+	copy(rows, collection.Rows)
+	header := make([]string, len(collection.Header))
+	copy(header, collection.Header)
+	return &View{header, rows}
+}
+
+// FilterView is as Filter but returns a read-only View (see View.Filter).
+func (collection *Collection) FilterView(filterFuncs ...FilterFn) *View {
+	return collection.View().Filter(filterFuncs...)
+}
+
+// GroupByView is as GroupBy but returns read-only Views (see View.GroupBy).
+func (collection *Collection) GroupByView(keyFn KeyFn) map[string]*View {
+	return collection.View().GroupBy(keyFn)
+}
+
+// Len returns the number of rows in the view.
+func (view *View) Len() int {
+	return len(view.rows)
+}
+
+// Header returns a copy of the header.
+func (view *View) Header() []string {
+	header := make([]string, len(view.header))
+	copy(header, view.header)
+	return header
+}
+
+// Row returns a copy of the i'th row.
+func (view *View) Row(i int) Row {
+	return *view.rows[i]
+}
+
+// Each calls fn with a copy of every row in order until fn returns false.
+func (view *View) Each(fn func(i int, row Row) bool) {
+	for i, row := range view.rows {
+		if !fn(i, *row) {
+			return
+		}
+	}
+}
+
+// Filter is as Collection.Filter but returns a View. The filterFuncs are
+// passed a copy of each Row so that they cannot modify the original.
+func (view *View) Filter(filterFuncs ...FilterFn) *View {
+	filtered := View{view.header, make([]*Row, 0)}
+
+	for _, row := range view.rows {
+		scratch := *row
+		ok := true
+		for _, filterFunc := range filterFuncs {
+			if !filterFunc(&scratch) {
+				ok = false
+				break // not this row
+			}
+		}
+
+		if ok {
+			filtered.rows = append(filtered.rows, row)
+		}
+	}
+
+	return &filtered
+}
+
+// GroupBy is as Collection.GroupBy but returns Views. keyFn is passed a
+// copy of each Row so that it cannot modify the original.
+func (view *View) GroupBy(keyFn KeyFn) map[string]*View {
+	groups := make(map[string]*View)
+	for _, row := range view.rows {
+		scratch := *row
+		key := keyFn(&scratch)
+		group, ok := groups[key]
+		if !ok {
+			group = &View{view.header, nil}
+			groups[key] = group
+		}
+		group.rows = append(group.rows, row)
+	}
+	return groups
+}
+
+// GetCompanies is as Collection.GetCompanies.
+func (view *View) GetCompanies() []string {
+	return (&Collection{Header: view.header, Rows: view.rows}).GetCompanies()
+}
+
+// Materialize returns a mutable deep copy of the view as a Collection.
+func (view *View) Materialize() *Collection {
+	collection := Collection{Header: view.Header(), Rows: make([]*Row, len(view.rows))}
+	for i, row := range view.rows {
+		collection.Rows[i] = row.Clone()
+	}
+	return &collection
+}
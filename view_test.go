@@ -0,0 +1,72 @@
+package wtrcsv
+
+import (
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Product Description 31
+0000001/1,Company One,301010
+0000002/1,Company Two,305010
+`)
+	view := collection.View()
+
+	// A filter that misbehaves by mutating the row.
+	filtered := view.Filter(func(row *Row) bool {
+		row.LicenseeCompany = "mutated"
+		return row.ProductDescription31 == "301010"
+	})
+	if filtered.Len() != 1 {
+		t.Fatalf("wrong number of rows: %v", filtered.Len())
+	}
+	if collection.Rows[0].LicenseeCompany != "Company One" {
+		t.Fatal("filter mutated the collection")
+	}
+
+	row := filtered.Row(0)
+	row.LicenseeCompany = "mutated"
+	if filtered.Row(0).LicenseeCompany != "Company One" {
+		t.Fatal("Row did not return a copy")
+	}
+
+	materialized := filtered.Materialize()
+	materialized.Rows[0].LicenseeCompany = "mutated"
+	if collection.Rows[0].LicenseeCompany != "Company One" {
+		t.Fatal("Materialize did not deep copy")
+	}
+
+	// The view is unaffected by filtering the collection in place.
+	collection.FilterInPlace(FilterNumericalProductCodes("305010"))
+	if view.Len() != 2 || view.Row(0).LicenceNumber != "0000001/1" {
+		t.Fatal("view changed by FilterInPlace")
+	}
+	if len(view.GetCompanies()) != 2 {
+		t.Fatal("wrong number of companies")
+	}
+}
+
+func TestQueryViews(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company,Product Description 31
+0000001/1,Company One,301010
+0000002/1,Company Two,305010
+0000003/1,Company One,305010
+`)
+	filtered := collection.FilterView(func(row *Row) bool {
+		row.LicenceNumber = "mutated"
+		return row.ProductDescription31 == "305010"
+	})
+	if filtered.Len() != 2 || filtered.Row(0).LicenceNumber != "0000002/1" {
+		t.Fatalf("wrong filtered view: %v rows", filtered.Len())
+	}
+
+	groups := collection.GroupByView(func(row *Row) string {
+		row.LicenseeCompany = "mutated"
+		return row.ProductDescription31
+	})
+	if len(groups) != 2 || groups["305010"].Len() != 2 || groups["301010"].Row(0).LicenceNumber != "0000001/1" {
+		t.Fatalf("wrong groups: %v", groups)
+	}
+	if collection.Rows[0].LicenceNumber != "0000001/1" || collection.Rows[0].LicenseeCompany != "Company One" {
+		t.Fatal("query mutated the collection")
+	}
+}
@@ -0,0 +1,116 @@
+package wtrcsv
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// WarningKind classifies the data quality problems an exporter works around.
+type WarningKind string
+
+const (
+	// WarningRowSkipped is a row left out of the export.
+	WarningRowSkipped WarningKind = "row skipped"
+	// WarningGeometryMissing is a row written without a geometry.
+	WarningGeometryMissing WarningKind = "geometry missing"
+	// WarningGeometrySubstituted is a row located by a licence area
+	// centroid rather than its own coordinates.
+	WarningGeometrySubstituted WarningKind = "geometry substituted"
+	// WarningValueTruncated is a value shortened to fit the format.
+	WarningValueTruncated WarningKind = "value truncated"
+	// WarningNameChanged is a column or sheet name changed to fit the
+	// format.
+	WarningNameChanged WarningKind = "name changed"
+)
+
+// maxWarnings is the number of warnings of each kind returned by an
+// exporter. Any more are counted in a final warning of the kind.
+const maxWarnings = 100
+
+// Warning is a data quality problem that an exporter worked around rather
+// than failing the export.
+type Warning struct {
+	Kind WarningKind
+	// Row is the index of the row in the collection, or -1 if the warning
+	// is not about a row.
+	Row           int
+	LicenceNumber string
+	Column        string
+	Message       string
+}
+
+func (w Warning) String() string {
+	s := string(w.Kind)
+	if w.Row >= 0 {
+		s += " row " + strconv.Itoa(w.Row)
+		if w.LicenceNumber != "" {
+			s += " (" + w.LicenceNumber + ")"
+		}
+	}
+	if w.Column != "" {
+		s += " \"" + w.Column + "\""
+	}
+	if w.Message != "" {
+		s += ": " + w.Message
+	}
+	return s
+}
+
+// warningList collects the warnings of an export, keeping the first
+// maxWarnings of each kind.
+type warningList struct {
+	warnings []Warning
+	counts   map[WarningKind]int
+	order    []WarningKind
+}
+
+func (l *warningList) add(w Warning) {
+	if l.counts == nil {
+		l.counts = make(map[WarningKind]int)
+	}
+	if l.counts[w.Kind] == 0 {
+		l.order = append(l.order, w.Kind)
+	}
+	l.counts[w.Kind]++
+	if l.counts[w.Kind] <= maxWarnings {
+		l.warnings = append(l.warnings, w)
+	}
+}
+
+func (l *warningList) addRow(kind WarningKind, i int, row *Row, column, message string) {
+	l.add(Warning{kind, i, row.LicenceNumber, column, message})
+}
+
+// result returns the warnings with one for the number left out of each
+// kind that had too many.
+func (l *warningList) result() []Warning {
+	warnings := l.warnings
+	for _, kind := range l.order {
+		if n := l.counts[kind] - maxWarnings; n > 0 {
+			warnings = append(warnings, Warning{Kind: kind, Row: -1, Message: fmt.Sprintf("%d more", n)})
+		}
+	}
+	return warnings
+}
+
+// truncated adds a warning for every value of the columns longer than
+// limit, in bytes or, if runes, characters.
+func (l *warningList) truncated(collection *Collection, columns []string, limit int, runes bool) {
+	unit := "bytes"
+	if runes {
+		unit = "characters"
+	}
+	encoder := newRowEncoder(columns, collection.computed)
+	for i, row := range collection.Rows {
+		for j, value := range encoder.encode(row) {
+			n := len(value)
+			if runes && n > limit {
+				n = utf8.RuneCountInString(value)
+			}
+			if n > limit {
+				l.addRow(WarningValueTruncated, i, row, columns[j], fmt.Sprintf("%d %s truncated to %d", n, unit, limit))
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package wtrcsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWarningList(t *testing.T) {
+	var l warningList
+	row := &Row{LicenceNumber: "0000001/1"}
+	for i := 0; i < maxWarnings+5; i++ {
+		l.addRow(WarningRowSkipped, i, row, "", "no coordinates")
+	}
+	l.add(Warning{WarningNameChanged, -1, "", "Licence Number", "DBF field Licence_Nu"})
+
+	warnings := l.result()
+	if len(warnings) != maxWarnings+2 {
+		t.Fatalf("got %d warnings", len(warnings))
+	}
+	if s := warnings[0].String(); s != "row skipped row 0 (0000001/1): no coordinates" {
+		t.Errorf("unexpected warning %q", s)
+	}
+	if w := warnings[len(warnings)-1]; w.Kind != WarningRowSkipped || w.Message != "5 more" {
+		t.Errorf("unexpected summary %+v", w)
+	}
+}
+
+func TestExportWarnings(t *testing.T) {
+	long := strings.Repeat("é", xlsxMaxCell+1)
+	collection := testCollection(t, "Licence Number,Licencee Company,WGS84 Longitude,WGS84 Latitude\n"+
+		"0000001/1,"+long+",-1.0,51.0\n"+
+		"0000002/1,Company Two,,\n")
+
+	warnings, err := collection.WriteXLSX(new(bytes.Buffer), &XLSXOptions{SheetName: "Sheet: WTR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 || warnings[0].Kind != WarningNameChanged ||
+		warnings[1].Kind != WarningValueTruncated || warnings[1].Row != 0 || warnings[1].Column != "Licencee Company" {
+		t.Errorf("unexpected XLSX warnings %v", warnings)
+	}
+
+	var shp, shx, dbf bytes.Buffer
+	report, err := collection.WriteShapefileWithOptions(&shp, &shx, &dbf, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[WarningKind]int)
+	for _, w := range report.Warnings {
+		kinds[w.Kind]++
+	}
+	// Licence_Nu, Licencee_C, WGS84_Long and WGS84_Lati are shortened.
+	if kinds[WarningGeometryMissing] != 1 || kinds[WarningNameChanged] != 4 || kinds[WarningValueTruncated] != 1 {
+		t.Errorf("unexpected shapefile warnings %v", report.Warnings)
+	}
+}
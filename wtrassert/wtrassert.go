@@ -0,0 +1,148 @@
+// Package wtrassert provides comparators for test suites that work with
+// wtrcsv Collections and Rows.
+package wtrassert
+
+import (
+	"fmt"
+	"github.com/recombinant/go-wtrcsv"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// isDerived reports whether a Row field is not present in the original
+// OFCOM csv (ie. it is added externally or converted from other fields).
+func isDerived(fieldName string) bool {
+	return !wtrcsv.IsOFCOMField(fieldName)
+}
+
+// DiffRows returns a line per differing field of the two rows. Derived
+// columns are ignored unless includeDerived is true.
+func DiffRows(want, got *wtrcsv.Row, includeDerived bool) []string {
+	var diffs []string
+	if want == nil || got == nil {
+		if want != got {
+			diffs = append(diffs, fmt.Sprintf("want %v, got %v", want, got))
+		}
+		return diffs
+	}
+
+	wantValue := reflect.ValueOf(want).Elem()
+	gotValue := reflect.ValueOf(got).Elem()
+	rowType := wantValue.Type()
+	for i := 0; i < rowType.NumField(); i++ {
+		field := rowType.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if !includeDerived && isDerived(field.Name) {
+			continue
+		}
+		w, g := wantValue.Field(i).Interface(), gotValue.Field(i).Interface()
+		if !reflect.DeepEqual(w, g) {
+			diffs = append(diffs, fmt.Sprintf("%s: want %q, got %q", field.Name, fmt.Sprint(w), fmt.Sprint(g)))
+		}
+	}
+	return diffs
+}
+
+// RowsEqual reports a test error listing the differing fields if the rows
+// differ. Derived columns are ignored.
+func RowsEqual(t testing.TB, want, got *wtrcsv.Row) bool {
+	t.Helper()
+	diffs := DiffRows(want, got, false)
+	if len(diffs) > 0 {
+		t.Errorf("rows differ:\n\t%s", strings.Join(diffs, "\n\t"))
+		return false
+	}
+	return true
+}
+
+// HeadersEqual reports a test error if the headers differ in length, names
+// or order.
+func HeadersEqual(t testing.TB, want, got *wtrcsv.Collection) bool {
+	t.Helper()
+	if reflect.DeepEqual(want.Header, got.Header) {
+		return true
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "headers differ (want %d columns, got %d):", len(want.Header), len(got.Header))
+	for i := 0; i < len(want.Header) || i < len(got.Header); i++ {
+		var w, g string
+		if i < len(want.Header) {
+			w = want.Header[i]
+		}
+		if i < len(got.Header) {
+			g = got.Header[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "\n\tcolumn %d: want %q, got %q", i, w, g)
+		}
+	}
+	t.Error(b.String())
+	return false
+}
+
+// CollectionsEqual reports a test error if the collections do not contain
+// the same rows, ignoring row order and derived columns. Headers must match.
+// The failure message lists the rows only present in one collection.
+func CollectionsEqual(t testing.TB, want, got *wtrcsv.Collection) bool {
+	t.Helper()
+	if !HeadersEqual(t, want, got) {
+		return false
+	}
+
+	// Multiset of row fingerprints.
+	counts := make(map[string]int)
+	for _, row := range want.Rows {
+		counts[fingerprint(row)]++
+	}
+	var extra []string
+	for _, row := range got.Rows {
+		key := fingerprint(row)
+		if counts[key] == 0 {
+			extra = append(extra, key)
+			continue
+		}
+		counts[key]--
+	}
+	var missing []string
+	for key, n := range counts {
+		for ; n > 0; n-- {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "collections differ (want %d rows, got %d):", len(want.Rows), len(got.Rows))
+	for _, key := range missing {
+		fmt.Fprintf(&b, "\n\t- %s", key)
+	}
+	for _, key := range extra {
+		fmt.Fprintf(&b, "\n\t+ %s", key)
+	}
+	t.Error(b.String())
+	return false
+}
+
+// fingerprint is a readable representation of the non-derived fields of
+// a row.
+func fingerprint(row *wtrcsv.Row) string {
+	value := reflect.ValueOf(row).Elem()
+	rowType := value.Type()
+	fields := make([]string, 0, rowType.NumField())
+	for i := 0; i < rowType.NumField(); i++ {
+		field := rowType.Field(i)
+		if field.PkgPath != "" || isDerived(field.Name) {
+			continue
+		}
+		fields = append(fields, fmt.Sprint(value.Field(i).Interface()))
+	}
+	return strings.Join(fields, "|")
+}
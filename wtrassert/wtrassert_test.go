@@ -0,0 +1,67 @@
+package wtrassert
+
+import (
+	"github.com/recombinant/go-wtrcsv"
+	"strings"
+	"testing"
+)
+
+// recorder captures failures instead of failing the enclosing test.
+type recorder struct {
+	testing.TB
+	messages []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...interface{}) {
+	for _, arg := range args {
+		r.messages = append(r.messages, arg.(string))
+	}
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.messages = append(r.messages, format)
+}
+
+func TestCollectionsEqual(t *testing.T) {
+	header := []string{"Licence Number", "Frequency"}
+	row1 := &wtrcsv.Row{LicenceNumber: "0000001/1", Frequency: "7.5"}
+	row2 := &wtrcsv.Row{LicenceNumber: "0000002/1", Frequency: "13.0"}
+	// Derived columns are ignored.
+	row2b := &wtrcsv.Row{LicenceNumber: "0000002/1", Frequency: "13.0", OsEasting: 1}
+
+	a := &wtrcsv.Collection{Header: header, Rows: []*wtrcsv.Row{row1, row2}}
+	b := &wtrcsv.Collection{Header: header, Rows: []*wtrcsv.Row{row2b, row1}}
+	if !CollectionsEqual(t, a, b) {
+		t.Fatal("reordered collections should be equal")
+	}
+
+	r := &recorder{TB: t}
+	c := &wtrcsv.Collection{Header: header, Rows: []*wtrcsv.Row{row1, row1}}
+	if CollectionsEqual(r, a, c) {
+		t.Fatal("different collections should not be equal")
+	}
+	if len(r.messages) != 1 || !strings.Contains(r.messages[0], "- 0000002/1") ||
+		!strings.Contains(r.messages[0], "+ 0000001/1") {
+		t.Fatalf("unexpected message: %v", r.messages)
+	}
+
+	r = &recorder{TB: t}
+	d := &wtrcsv.Collection{Header: header[:1], Rows: a.Rows}
+	if CollectionsEqual(r, a, d) {
+		t.Fatal("collections with different headers should not be equal")
+	}
+}
+
+func TestDiffRows(t *testing.T) {
+	want := &wtrcsv.Row{LicenceNumber: "0000001/1", Frequency: "7.5", OsEasting: 1}
+	got := &wtrcsv.Row{LicenceNumber: "0000001/1", Frequency: "7.6", OsEasting: 2}
+
+	if diffs := DiffRows(want, got, false); len(diffs) != 1 || !strings.HasPrefix(diffs[0], "Frequency:") {
+		t.Fatalf("unexpected diff: %v", diffs)
+	}
+	if diffs := DiffRows(want, got, true); len(diffs) != 2 {
+		t.Fatalf("unexpected diff including derived: %v", diffs)
+	}
+}
@@ -1,74 +1,85 @@
 package wtrcsv
 
 import (
-	"bufio"
 	"encoding/csv"
 	"github.com/pkg/errors"
 	"io"
-	"log"
+	"reflect"
 	"regexp"
 	"sort"
 	"strconv"
 )
 
 type Row struct {
-	LicenceNumber          string
-	LicenceIssueDate       string
-	SidLatNS               string
-	SidLatDeg              string
-	SidLatMin              string
-	SidLatSec              string
-	SidLongEW              string
-	SidLongDeg             string
-	SidLongMin             string
-	SidLongSec             string
-	NGR                    string
-	Frequency              string
-	FrequencyType          string
-	StationType            string
-	ChannelWidth           string
-	ChannelWidthType       string
-	HeightAboveSeaLevel    string
-	AntennaErp             string
-	AntennaErpType         string
-	AntennaType            string
-	AntennaGain            string
-	AntennaAzimuth         string
-	HorizontalElements     string
-	VerticalElements       string
-	AntennaHeight          string // Resolution to 0.5m
-	AntennaLocation        string
-	EflUpperLower          string
-	AntennaDirection       string
-	AntennaElevation       string
-	AntennaPolarisation    string
-	AntennaName            string
-	FeedingLoss            string
-	FadeMargin             string
-	EmissionCode           string
-	ApCommentIntern        string
-	Vector                 string
-	LicenseeSurname        string
-	LicenseeFirstName      string
-	LicenseeCompany        string
-	Status                 string
-	Tradeable              string
-	Publishable            string
-	ProductCode            string
-	ProductDescription     string
-	ProductDescription31   string
-	ProductDescription32   string
-	Wgs84LongitudeAsString string // Persistent representation
-	Wgs84LatitudeAsString  string
-	Wgs84Longitude         float64 // Converted from persistent
-	Wgs84Latitude          float64
-	OsEasting              int
-	OsNorthing             int
+	LicenceNumber          string  `csv:"Licence Number" json:"licence_number"`
+	LicenceIssueDate       string  `csv:"Licence issue date" json:"licence_issue_date"`
+	SidLatNS               string  `csv:"SID_LAT_N_S" json:"sid_lat_n_s"`
+	SidLatDeg              string  `csv:"SID_LAT_DEG" json:"sid_lat_deg"`
+	SidLatMin              string  `csv:"SID_LAT_MIN" json:"sid_lat_min"`
+	SidLatSec              string  `csv:"SID_LAT_SEC" json:"sid_lat_sec"`
+	SidLongEW              string  `csv:"SID_LONG_E_W" json:"sid_long_e_w"`
+	SidLongDeg             string  `csv:"SID_LONG_DEG" json:"sid_long_deg"`
+	SidLongMin             string  `csv:"SID_LONG_MIN" json:"sid_long_min"`
+	SidLongSec             string  `csv:"SID_LONG_SEC" json:"sid_long_sec"`
+	NGR                    string  `csv:"NGR" json:"ngr"`
+	Frequency              string  `csv:"Frequency" json:"frequency"`
+	FrequencyType          string  `csv:"Frequency Type" json:"frequency_type"`
+	StationType            string  `csv:"Station Type" json:"station_type"`
+	ChannelWidth           string  `csv:"Channel Width" json:"channel_width"`
+	ChannelWidthType       string  `csv:"Channel Width type" json:"channel_width_type"`
+	HeightAboveSeaLevel    string  `csv:"Height above sea level" json:"height_above_sea_level"`
+	AntennaErp             string  `csv:"Antenna ERP" json:"antenna_erp"`
+	AntennaErpType         string  `csv:"Antenna ERP type" json:"antenna_erp_type"`
+	AntennaType            string  `csv:"Antenna Type" json:"antenna_type"`
+	AntennaGain            string  `csv:"Antenna Gain" json:"antenna_gain"`
+	AntennaAzimuth         string  `csv:"Antenna AZIMUTH" json:"antenna_azimuth"`
+	HorizontalElements     string  `csv:"Horizontal Elements" json:"horizontal_elements"`
+	VerticalElements       string  `csv:"Vertical Elements" json:"vertical_elements"`
+	AntennaHeight          string  `csv:"Antenna Height" json:"antenna_height"` // Resolution to 0.5m
+	AntennaLocation        string  `csv:"Antenna Location" json:"antenna_location"`
+	EflUpperLower          string  `csv:"EFL_UPPER_LOWER" json:"efl_upper_lower"`
+	AntennaDirection       string  `csv:"Antenna Direction" json:"antenna_direction"`
+	AntennaElevation       string  `csv:"Antenna Elevation" json:"antenna_elevation"`
+	AntennaPolarisation    string  `csv:"Antenna Polarisation" json:"antenna_polarisation"`
+	AntennaName            string  `csv:"Antenna Name" json:"antenna_name"`
+	FeedingLoss            string  `csv:"Feeding Loss" json:"feeding_loss"`
+	FadeMargin             string  `csv:"Fade Margin" json:"fade_margin"`
+	EmissionCode           string  `csv:"Emission Code" json:"emission_code"`
+	ApCommentIntern        string  `csv:"AP_COMMENT_INTERN" json:"ap_comment_intern"`
+	Vector                 string  `csv:"Vector" json:"vector"`
+	LicenseeSurname        string  `csv:"Licencee Surname" json:"licencee_surname"`
+	LicenseeFirstName      string  `csv:"Licencee First Name" json:"licencee_first_name"`
+	LicenseeCompany        string  `csv:"Licencee Company" json:"licencee_company"`
+	Status                 string  `csv:"Status" json:"status"`
+	Tradeable              string  `csv:"Tradeable" json:"tradeable"`
+	Publishable            string  `csv:"Publishable" json:"publishable"`
+	ProductCode            string  `csv:"Product Code" json:"product_code"`
+	ProductDescription     string  `csv:"Product Description" json:"product_description"`
+	ProductDescription31   string  `csv:"Product Description 31" json:"product_description_31"`
+	ProductDescription32   string  `csv:"Product Description 32" json:"product_description_32"`
+	Wgs84LongitudeAsString string  `csv:"WGS84 Longitude,munged" json:"-"` // Persistent representation
+	Wgs84LatitudeAsString  string  `csv:"WGS84 Latitude,munged" json:"-"`
+	Wgs84Longitude         float64 `json:"wgs84_longitude,omitempty"` // Converted from persistent
+	Wgs84Latitude          float64 `json:"wgs84_latitude,omitempty"`
+	OsEasting              int     `csv:"OS Easting,munged" json:"os_easting,omitempty"`
+	OsNorthing             int     `csv:"OS Northing,munged" json:"os_northing,omitempty"`
 	// The last two values are not present in the original OFCOM csv.
 	// They are can be added externally (ie. from outside this package).
 	// Saving to csv will save them if they are present.
 }
 
+// standardHeader is the header of the original OFCOM csv, in order: the
+// csv tags of the Row fields that are not munged.
+var standardHeader = func() []string {
+	var header []string
+	for _, column := range rowColumns {
+		if !column.munged {
+			header = append(header, column.heading)
+		}
+	}
+	return header
+}()
+
 const (
 	HeadingOsEasting      = "OS Easting"
 	HeadingOsNorthing     = "OS Northing"
@@ -76,184 +87,116 @@ const (
 	HeadingWgs84Latitude  = "WGS84 Latitude"
 )
 
+// mungedHeader are the columns that may be added to the OFCOM csv externally.
+var mungedHeader = []string{
+	HeadingOsEasting, HeadingOsNorthing, HeadingWgs84Longitude, HeadingWgs84Latitude,
+}
+
+// Headings returns the headings of all the columns of a Row: the OFCOM
+// columns in order, followed by the munged columns.
+func Headings() []string {
+	return append(append([]string{}, standardHeader...), mungedHeader...)
+}
+
 // newRow tidies each record before returning the Row
 func newRow(columns map[string]string) *Row {
-	// The columns in this map are present in every columns.
-	row := Row{
-		LicenceNumber:        columns["Licence Number"],
-		LicenceIssueDate:     columns["Licence issue date"],
-		SidLatNS:             columns["SID_LAT_N_S"],
-		SidLatDeg:            columns["SID_LAT_DEG"],
-		SidLatMin:            columns["SID_LAT_MIN"],
-		SidLatSec:            columns["SID_LAT_SEC"],
-		SidLongEW:            columns["SID_LONG_E_W"],
-		SidLongDeg:           columns["SID_LONG_DEG"],
-		SidLongMin:           columns["SID_LONG_MIN"],
-		SidLongSec:           columns["SID_LONG_SEC"],
-		NGR:                  columns["NGR"],
-		Frequency:            columns["Frequency"],
-		FrequencyType:        columns["Frequency Type"],
-		StationType:          columns["Station Type"],
-		ChannelWidth:         columns["Channel Width"],
-		ChannelWidthType:     columns["Channel Width type"],
-		HeightAboveSeaLevel:  columns["Height above sea level"],
-		AntennaErp:           columns["Antenna ERP"],
-		AntennaErpType:       columns["Antenna ERP type"],
-		AntennaType:          columns["Antenna Type"],
-		AntennaGain:          columns["Antenna Gain"],
-		AntennaAzimuth:       columns["Antenna AZIMUTH"],
-		HorizontalElements:   columns["Horizontal Elements"],
-		VerticalElements:     columns["Vertical Elements"],
-		AntennaHeight:        columns["Antenna Height"],
-		AntennaLocation:      columns["Antenna Location"],
-		EflUpperLower:        columns["EFL_UPPER_LOWER"],
-		AntennaDirection:     columns["Antenna Direction"],
-		AntennaElevation:     columns["Antenna Elevation"],
-		AntennaPolarisation:  columns["Antenna Polarisation"],
-		AntennaName:          columns["Antenna Name"],
-		FeedingLoss:          columns["Feeding Loss"],
-		FadeMargin:           columns["Fade Margin"],
-		EmissionCode:         columns["Emission Code"],
-		ApCommentIntern:      columns["AP_COMMENT_INTERN"],
-		Vector:               columns["Vector"],
-		LicenseeSurname:      columns["Licencee Surname"],
-		LicenseeFirstName:    columns["Licencee First Name"],
-		LicenseeCompany:      columns["Licencee Company"],
-		Status:               columns["Status"],
-		Tradeable:            columns["Tradeable"],
-		Publishable:          columns["Publishable"],
-		ProductCode:          columns["Product Code"],
-		ProductDescription:   columns["Product Description"],
-		ProductDescription31: columns["Product Description 31"],
-		ProductDescription32: columns["Product Description 32"],
+	row, err := parseRow(columns)
+	if err != nil {
+		fatalf("%v", err)
 	}
+	return row
+}
 
-	// The following columns are not present in the original OFCOM csv but
-	// may be present a munged version.
-	var err error
-
-	if _, ok := columns[HeadingOsEasting]; ok {
-		row.OsEasting, err = strconv.Atoi(columns[HeadingOsEasting])
-		if err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not convert easting"))
+// parseRow is as newRow but returns an error if one of the munged columns
+// could not be converted.
+func parseRow(columns map[string]string) (*Row, error) {
+	var row Row
+	value := reflect.ValueOf(&row).Elem()
+	for _, column := range rowColumns {
+		if err := column.set(value.Field(column.index), columns[column.heading]); err != nil {
+			return nil, err
 		}
 	}
 
-	if _, ok := columns[HeadingOsNorthing]; ok {
-		row.OsNorthing, err = strconv.Atoi(columns[HeadingOsNorthing])
-		if err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not convert northing"))
-		}
+	if err := row.convertWgs84(); err != nil {
+		return nil, err
 	}
+	return &row, nil
+}
 
-	if _, ok := columns[HeadingWgs84Longitude]; ok {
-		row.Wgs84LongitudeAsString = columns[HeadingWgs84Longitude]
+// convertWgs84 converts the WGS84 floats from their persistent
+// representation.
+func (row *Row) convertWgs84() error {
+	var err error
+
+	if row.Wgs84LongitudeAsString != "" {
 		row.Wgs84Longitude, err = strconv.ParseFloat(row.Wgs84LongitudeAsString, 64)
 		if err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not convert WGS84 longitude"))
+			return &columnError{HeadingWgs84Longitude, err}
 		}
 	}
 
-	if _, ok := columns[HeadingWgs84Latitude]; ok {
-		row.Wgs84LatitudeAsString = columns[HeadingWgs84Latitude]
+	if row.Wgs84LatitudeAsString != "" {
 		row.Wgs84Latitude, err = strconv.ParseFloat(row.Wgs84LatitudeAsString, 64)
 		if err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not convert WGS84 latitude"))
+			return &columnError{HeadingWgs84Latitude, err}
 		}
 	}
-
-	return &row
+	return nil
 }
 
 // toMap puts all of the Row member variables in a map (ie. columns). These
 // will only be included in the csv if the associated header column is present.
 func (row *Row) toMap() map[string]string {
-	return map[string]string{
-		"Licence Number":         row.LicenceNumber,
-		"Licence issue date":     row.LicenceIssueDate,
-		"SID_LAT_N_S":            row.SidLatNS,
-		"SID_LAT_DEG":            row.SidLatDeg,
-		"SID_LAT_MIN":            row.SidLatMin,
-		"SID_LAT_SEC":            row.SidLatSec,
-		"SID_LONG_E_W":           row.SidLongEW,
-		"SID_LONG_DEG":           row.SidLongDeg,
-		"SID_LONG_MIN":           row.SidLongMin,
-		"SID_LONG_SEC":           row.SidLongSec,
-		"NGR":                    row.NGR,
-		"Frequency":              row.Frequency,
-		"Frequency Type":         row.FrequencyType,
-		"Station Type":           row.StationType,
-		"Channel Width":          row.ChannelWidth,
-		"Channel Width type":     row.ChannelWidthType,
-		"Height above sea level": row.HeightAboveSeaLevel,
-		"Antenna ERP":            row.AntennaErp,
-		"Antenna ERP type":       row.AntennaErpType,
-		"Antenna Type":           row.AntennaType,
-		"Antenna Gain":           row.AntennaGain,
-		"Antenna AZIMUTH":        row.AntennaAzimuth,
-		"Horizontal Elements":    row.HorizontalElements,
-		"Vertical Elements":      row.VerticalElements,
-		"Antenna Height":         row.AntennaHeight,
-		"Antenna Location":       row.AntennaLocation,
-		"EFL_UPPER_LOWER":        row.EflUpperLower,
-		"Antenna Direction":      row.AntennaDirection,
-		"Antenna Elevation":      row.AntennaElevation,
-		"Antenna Polarisation":   row.AntennaPolarisation,
-		"Antenna Name":           row.AntennaName,
-		"Feeding Loss":           row.FeedingLoss,
-		"Fade Margin":            row.FadeMargin,
-		"Emission Code":          row.EmissionCode,
-		"AP_COMMENT_INTERN":      row.ApCommentIntern,
-		"Vector":                 row.Vector,
-		"Licencee Surname":       row.LicenseeSurname,
-		"Licencee First Name":    row.LicenseeFirstName,
-		"Licencee Company":       row.LicenseeCompany,
-		"Status":                 row.Status,
-		"Tradeable":              row.Tradeable,
-		"Publishable":            row.Publishable,
-		"Product Code":           row.ProductCode,
-		"Product Description":    row.ProductDescription,
-		"Product Description 31": row.ProductDescription31, // Product code number
-		"Product Description 32": row.ProductDescription32,
-		HeadingOsEasting:         strconv.Itoa(row.OsEasting),
-		HeadingOsNorthing:        strconv.Itoa(row.OsNorthing),
-		HeadingWgs84Longitude:    row.Wgs84LongitudeAsString,
-		HeadingWgs84Latitude:     row.Wgs84LatitudeAsString,
+	value := reflect.ValueOf(row).Elem()
+	columns := make(map[string]string, len(rowColumns))
+	for _, column := range rowColumns {
+		columns[column.heading] = column.get(value.Field(column.index))
 	}
+	return columns
 }
 
+// Collection is the rows of a csv and its header.
+//
+// The collections returned by Filter, GroupBy, Dedupe, the set operations
+// and the like share the *Row pointers and the header of the receiver, so
+// a change to a row of one is a change to the row of the others. Clone
+// copies a collection that is to be changed independently, eg. for a what
+// if edit. Transform copies the rows it is given, and AppendRows and
+// AddColumn copy the header before extending it.
 type Collection struct {
 	Header []string
 	Rows   []*Row
+
+	computed []computedColumn // see AddColumn
 }
 
 // ReadCSV to read in the OFCOM WTR csv.
 func ReadCSV(reader io.Reader) *Collection {
-	header, rawColumns := CSVToMap(bufio.NewReader(reader))
-
-	collection := Collection{header, make([]*Row, len(rawColumns))}
-	for i, columns := range rawColumns {
-		collection.Rows[i] = newRow(columns)
+	collection, err := readCSV(reader)
+	if err != nil {
+		fatalf("%v", err)
 	}
-	return &collection
+	return collection
+}
+
+// readCSV is as ReadCSV but returns an error rather than exiting.
+func readCSV(reader io.Reader) (*Collection, error) {
+	collection, _, err := ReadCSVWithOptions(reader, nil)
+	return collection, err
 }
 
 // WriteCSV writes the csv header, then writes the rows.
 func (collection *Collection) WriteCSV(writer io.Writer) {
 	w := csv.NewWriter(writer)
 	if err := w.Write(collection.Header); err != nil {
-		log.Fatalf("%v", errors.Wrap(err, "could not write CSV header"))
+		fatalf("%v", errors.Wrap(err, "could not write CSV header"))
 	}
 
-	var csvRow = make([]string, len(collection.Header))
+	encoder := newRowEncoder(collection.Header, collection.computed)
 	for _, row := range collection.Rows {
-		rowAsMap := row.toMap()
-		for j, heading := range collection.Header {
-			// rowAsMap[heading] checked for existence during development.
-			csvRow[j] = rowAsMap[heading]
-		}
-		if err := w.Write(csvRow); err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not write CSV row"))
+		if err := w.Write(encoder.encode(row)); err != nil {
+			fatalf("%v", errors.Wrap(err, "could not write CSV row"))
 		}
 	}
 	w.Flush()
@@ -284,48 +227,69 @@ type FilterFn func(row *Row) bool
 // each Row in Collection. Every filterFunc has to return true
 // for the Row to be added to the filtered Collection.
 func (collection *Collection) Filter(filterFuncs ...FilterFn) *Collection {
-	header := collection.Header
-	filtered := Collection{header, make([]*Row, 0)}
+	return collection.withRows(collection.FilterAppend(make([]*Row, 0), filterFuncs...))
+}
 
-	// All filters must return true for a row to be appended.
+// FilterAppend is as Filter but appends the matching rows to dst, returning
+// the extended slice, rather than allocating a Collection. Reusing dst[:0]
+// between calls (eg. per request in a server) avoids growing a new slice
+// each time.
+func (collection *Collection) FilterAppend(dst []*Row, filterFuncs ...FilterFn) []*Row {
+	record := filterMetrics()
+	n := len(dst)
+	dst = filterAppend(dst, collection.Rows, filterFuncs)
+	if record != nil {
+		record(len(dst) - n)
+	}
+	return dst
+}
+
+// filterAppend appends the rows matching every filterFunc to dst.
+func filterAppend(dst, rows []*Row, filterFuncs []FilterFn) []*Row {
+	for _, row := range rows {
+		if matchesAll(row, filterFuncs) {
+			dst = append(dst, row)
+		}
+	}
+	return dst
+}
+
+// FilterEach calls fn with each matching Row in order, without collecting
+// them. It stops at the first error from fn and returns it.
+func (collection *Collection) FilterEach(fn func(row *Row) error, filterFuncs ...FilterFn) error {
+	n := 0
+	if record := filterMetrics(); record != nil {
+		defer func() { record(n) }() // also when fn stops the filter
+	}
 	for _, row := range collection.Rows {
-		ok := true
-		for _, filterFunc := range filterFuncs {
-			if !filterFunc(row) {
-				ok = false
-				break // not this row
+		if matchesAll(row, filterFuncs) {
+			n++
+			if err := fn(row); err != nil {
+				return err
 			}
 		}
+	}
+	return nil
+}
 
-		if ok {
-			filtered.Rows = append(filtered.Rows, row)
+// matchesAll reports whether every filterFunc returns true for the Row.
+func matchesAll(row *Row, filterFuncs []FilterFn) bool {
+	for _, filterFunc := range filterFuncs {
+		if !filterFunc(row) {
+			return false // not this row
 		}
 	}
-
-	return &filtered
+	return true
 }
 
 // FilterInPlace is as Filter but overwrites the original backing array with the
 // filtered.
 func (collection *Collection) FilterInPlace(filterFuncs ...FilterFn) *Collection {
-	filteredRows := collection.Rows[:0]
-
-	// All filters must return true for a row to be appended.
-	for _, row := range collection.Rows {
-		ok := true
-		for _, filterFunc := range filterFuncs {
-			if !filterFunc(row) {
-				ok = false
-				break // not this row
-			}
-		}
-
-		if ok {
-			// All filters returned true.
-			filteredRows = append(filteredRows, row)
-		}
+	record := filterMetrics()
+	collection.Rows = filterAppend(collection.Rows[:0], collection.Rows, filterFuncs)
+	if record != nil {
+		record(len(collection.Rows))
 	}
-	collection.Rows = filteredRows
 	return collection
 }
 
@@ -369,9 +333,19 @@ func FilterCompanies(companies ...string) func(*Row) bool {
 }
 
 // CSVToMap takes a reader and returns a slice of maps.
-// Uses the header row as the keys.
+// Uses the header row as the keys, mapped to their canonical headings (see
+// HeaderAliases).
 // From a Gist on GitHub
 func CSVToMap(reader io.Reader) ([]string, []map[string]string) {
+	header, rows, err := csvToMap(reader)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	return header, rows
+}
+
+// csvToMap is as CSVToMap but returns an error rather than exiting.
+func csvToMap(reader io.Reader) ([]string, []map[string]string, error) {
 	r := csv.NewReader(reader)
 	var rows []map[string]string
 	var header []string
@@ -381,10 +355,10 @@ func CSVToMap(reader io.Reader) ([]string, []map[string]string) {
 			break
 		}
 		if err != nil {
-			log.Fatalf("%v", errors.Wrap(err, "could not read from reader"))
+			return nil, nil, errors.Wrap(err, "could not read from reader")
 		}
 		if header == nil {
-			header = record
+			header = canonicalHeader(record)
 		} else {
 			dict := make(map[string]string, len(header))
 			for i := range header {
@@ -393,7 +367,7 @@ func CSVToMap(reader io.Reader) ([]string, []map[string]string) {
 			rows = append(rows, dict)
 		}
 	}
-	return header, rows
+	return header, rows, nil
 }
 
 // GetProductCodeLookup returns a map of numerical product code vs
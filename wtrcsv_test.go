@@ -239,7 +239,7 @@ func TestWTR(t *testing.T) {
 
 			rows := make([]*Row, len(collection.Rows))
 			copy(rows, collection.Rows)
-			collection2 := &Collection{collection.Header, rows}
+			collection2 := &Collection{Header: collection.Header, Rows: rows}
 
 			collection2.FilterInPlace(FilterNumericalProductCodes("301010"), FilterValidNGR)
 
@@ -314,3 +314,43 @@ func TestWTR(t *testing.T) {
 			}
 		})
 }
+
+func TestFilterAppend(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Licencee Company
+0000001/1,Acme
+0000002/1,Other
+0000003/1,Acme
+`)
+	buffer := make([]*Row, 0, 4)
+	rows := collection.FilterAppend(buffer, FilterCompanies("Acme"))
+	if len(rows) != 2 || &rows[:1][0] != &buffer[:1][0] {
+		t.Fatalf("got %d rows, reallocated", len(rows))
+	}
+	if rows = collection.FilterAppend(rows[:0], FilterCompanies("Other")); len(rows) != 1 || rows[0].LicenceNumber != "0000002/1" {
+		t.Fatalf("reuse: got %v", rows)
+	}
+
+	stop := errors.New("stop")
+	var numbers []string
+	err := collection.FilterEach(func(row *Row) error {
+		numbers = append(numbers, row.LicenceNumber)
+		if len(numbers) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || strings.Join(numbers, " ") != "0000001/1 0000002/1" {
+		t.Fatalf("FilterEach: %v %v", err, numbers)
+	}
+}
+
+// testCollection builds a small Collection from csv text for the unit tests
+// that do not need the real data.
+func testCollection(t *testing.T, text string) *Collection {
+	t.Helper()
+	collection := ReadCSV(strings.NewReader(text))
+	if len(collection.Rows) == 0 {
+		t.Fatal("no test rows")
+	}
+	return collection
+}
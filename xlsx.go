@@ -0,0 +1,268 @@
+package wtrcsv
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// xlsxMaxRows is the number of rows in an Excel worksheet, including
+	// the header row.
+	xlsxMaxRows = 1048576
+	// xlsxMaxCell is the most characters in an Excel cell.
+	xlsxMaxCell = 32767
+)
+
+// XLSXOptions control WriteXLSX.
+type XLSXOptions struct {
+	// SplitBy, if set, writes one sheet per key (eg. KeyProductCode or
+	// KeyCompany) in key order rather than a single sheet.
+	SplitBy KeyFn
+	// SheetName is the name of the single sheet, default "WTR".
+	SheetName string
+}
+
+type xlsxSheet struct {
+	name string
+	rows []*Row
+}
+
+// WriteXLSX writes the collection as an Excel workbook. Each sheet has the
+// header as a bold, frozen first row with an auto-filter over the data.
+// Numbers are written as numerical cells, everything else as text. A sheet
+// with more rows than Excel allows is continued on further sheets. Sheet
+// names changed and values too long for a cell are returned as warnings.
+func (collection *Collection) WriteXLSX(writer io.Writer, options *XLSXOptions) ([]Warning, error) {
+	var o XLSXOptions
+	if options != nil {
+		o = *options
+	}
+	if o.SheetName == "" {
+		o.SheetName = "WTR"
+	}
+
+	var groups []xlsxSheet
+	if o.SplitBy == nil {
+		groups = []xlsxSheet{{o.SheetName, collection.Rows}}
+	} else {
+		lookup := collection.GroupBy(o.SplitBy)
+		keys := make([]string, 0, len(lookup))
+		for key := range lookup {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			groups = append(groups, xlsxSheet{key, lookup[key].Rows})
+		}
+	}
+
+	// Split oversized sheets and make the names valid and unique.
+	var sheets []xlsxSheet
+	var warnings warningList
+	used := make(map[string]bool)
+	for _, group := range groups {
+		rows := group.rows
+		for part := 1; part == 1 || len(rows) > 0; part++ {
+			n := len(rows)
+			if n > xlsxMaxRows-1 {
+				n = xlsxMaxRows - 1
+			}
+			name := group.name
+			if part > 1 {
+				name += " (" + strconv.Itoa(part) + ")"
+			}
+			sheet := xlsxSheet{xlsxSheetName(name, used), rows[:n]}
+			if sheet.name != name {
+				warnings.add(Warning{WarningNameChanged, -1, "", "", "sheet \"" + name + "\" named \"" + sheet.name + "\""})
+			}
+			sheets = append(sheets, sheet)
+			rows = rows[n:]
+		}
+	}
+	warnings.truncated(collection, collection.Header, xlsxMaxCell, true)
+
+	z := zip.NewWriter(writer)
+	for i, sheet := range sheets {
+		w, err := z.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create worksheet")
+		}
+		if err := writeXLSXSheet(w, collection.Header, newRowEncoder(collection.Header, collection.computed), sheet.rows); err != nil {
+			return nil, err
+		}
+	}
+
+	parts := map[string]string{
+		"[Content_Types].xml":        xlsxContentTypes(len(sheets)),
+		"_rels/.rels":                xlsxRootRels,
+		"xl/workbook.xml":            xlsxWorkbook(sheets, len(collection.Header)),
+		"xl/_rels/workbook.xml.rels": xlsxWorkbookRels(len(sheets)),
+		"xl/styles.xml":              xlsxStyles,
+	}
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w, err := z.Create(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not create %s", name)
+		}
+		if _, err := io.WriteString(w, parts[name]); err != nil {
+			return nil, errors.Wrapf(err, "could not write %s", name)
+		}
+	}
+	return warnings.result(), errors.Wrap(z.Close(), "could not write XLSX")
+}
+
+var xlsxInvalidSheetName = regexp.MustCompile(`[\[\]:*?/\\]`)
+
+// xlsxSheetName returns a valid (at most 31 characters, no []:*?/\) sheet
+// name not already used, ignoring case as Excel does.
+func xlsxSheetName(name string, used map[string]bool) string {
+	name = strings.TrimSpace(xlsxInvalidSheetName.ReplaceAllString(name, "_"))
+	if name == "" {
+		name = "Sheet"
+	}
+	candidate := truncateUTF8(name, 31)
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		suffix := " " + strconv.Itoa(n)
+		candidate = truncateUTF8(name, 31-len(suffix)) + suffix
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// truncateRunes truncates s to at most n characters.
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// xlsxColumn returns the column letters of the i'th (from 0) column.
+func xlsxColumn(i int) string {
+	var letters []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		letters = append([]byte{byte('A' + (i-1)%26)}, letters...)
+	}
+	return string(letters)
+}
+
+var xlsxNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+func writeXLSXSheet(writer io.Writer, header []string, encoder *rowEncoder, rows []*Row) error {
+	w := bufio.NewWriter(writer)
+	lastColumn := xlsxColumn(len(header) - 1)
+	w.WriteString(xml.Header)
+	w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	w.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	w.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	w.WriteString(`</sheetView></sheetViews><sheetData>`)
+
+	writeRow := func(r int, record []string, style string) {
+		fmt.Fprintf(w, `<row r="%d">`, r)
+		for j, value := range record {
+			if value == "" {
+				continue
+			}
+			value = truncateRunes(value, xlsxMaxCell)
+			ref := xlsxColumn(j) + strconv.Itoa(r)
+			if r > 1 && xlsxNumber.MatchString(value) {
+				fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(w, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			xml.EscapeText(w, []byte(value))
+			w.WriteString(`</t></is></c>`)
+		}
+		w.WriteString(`</row>`)
+	}
+
+	writeRow(1, header, ` s="1"`)
+	for i, row := range rows {
+		writeRow(i+2, encoder.encode(row), "")
+	}
+
+	fmt.Fprintf(w, `</sheetData><autoFilter ref="A1:%s%d"/></worksheet>`, lastColumn, len(rows)+1)
+	return errors.Wrap(w.Flush(), "could not write worksheet")
+}
+
+func xlsxContentTypes(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+const xlsxRootRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func xlsxWorkbook(sheets []xlsxSheet, columns int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `)
+	b.WriteString(`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(sheet.name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString(`</sheets><definedNames>`)
+	for i, sheet := range sheets {
+		fmt.Fprintf(&b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">`, i)
+		xml.EscapeText(&b, []byte("'"+strings.Replace(sheet.name, "'", "''", -1)+"'"))
+		fmt.Fprintf(&b, `!$A$1:$%s$%d</definedName>`, xlsxColumn(columns-1), len(sheet.rows)+1)
+	}
+	b.WriteString(`</definedNames></workbook>`)
+	return b.String()
+}
+
+func xlsxWorkbookRels(sheets int) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// xlsxStyles has the default style (0) and bold for the header (1).
+const xlsxStyles = xml.Header +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,69 @@
+package wtrcsv
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestXLSXHelpers(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Fatalf("column %d: want %v, got %v", i, want, got)
+		}
+	}
+
+	used := make(map[string]bool)
+	if name := xlsxSheetName("Business Radio [Area]: Defined/Assigned", used); name != "Business Radio _Area__ Defined_" {
+		t.Fatalf("unexpected sheet name: %q", name)
+	}
+	if name := xlsxSheetName("business radio _area__ defined_", used); name != "business radio _area__ define 2" {
+		t.Fatalf("unexpected unique sheet name: %q", name)
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	collection := testCollection(t, `Licence Number,Frequency,Licencee Company,Product Description 31
+0000001/1,7.5,Company & One,301010
+0000002/1,13.0,Company Two,305010
+0000003/1,18,Company Two,301010
+`)
+	b := new(bytes.Buffer)
+	warnings, err := collection.WriteXLSX(b, &XLSXOptions{SplitBy: KeyProductCode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings %v", warnings)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+
+	sheet1, ok := parts["xl/worksheets/sheet1.xml"]
+	if !ok || parts["xl/worksheets/sheet2.xml"] == "" {
+		t.Fatal("missing worksheets")
+	}
+	for _, s := range []string{`state="frozen"`, `<autoFilter ref="A1:D3"/>`, `Company &amp; One`, `<c r="B3"><v>18</v></c>`} {
+		if !strings.Contains(sheet1, s) {
+			t.Fatalf("sheet1 missing %s", s)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="305010" sheetId="2" r:id="rId2"/>`) {
+		t.Fatal("missing sheet in workbook")
+	}
+}